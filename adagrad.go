@@ -0,0 +1,81 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+// AdaGrad implements the adaptive subgradient method of
+//
+//  Duchi, J., Hazan, E., Singer, Y.: Adaptive subgradient methods for online
+//  learning and stochastic optimization. J. Mach. Learn. Res. 12 (2011),
+//  2121-2159.
+//
+// for minimizing a StochasticProblem, see Minibatches. The learning rate of
+// every variable is divided by the root of the sum of its squared minibatch
+// gradients,
+//  s_{k+1} = s_k + g_k^2,
+//  x_{k+1} = x_k - rate_k * g_k / (sqrt(s_{k+1}) + Epsilon),
+// so that variables with large or frequent gradients take smaller steps.
+//
+// AdaGrad does not keep the best location found, so the result of Local is
+// the last location. The convergence of the noisy iterations is tested by
+// Converge, see StochasticConverge and DefaultSettingsStochastic.
+type AdaGrad struct {
+	// LearningRate is the schedule of the learning rate.
+	// If LearningRate is nil, a constant rate of 0.01 is used.
+	LearningRate LearningRate
+	// Epsilon avoids the division by zero.
+	// If Epsilon is zero, 1e-8 is used.
+	Epsilon float64
+	// Converge tests the convergence of the iterations. If Converge is nil,
+	// the iterations run until a limit of Settings is reached.
+	Converge *StochasticConverge
+
+	eps float64
+	s   []float64
+	sg  stochasticGradient
+}
+
+func (a *AdaGrad) Init(loc *Location) (Operation, error) {
+	a.eps = a.Epsilon
+	if a.eps == 0 {
+		a.eps = defaultAdaptiveEps
+	}
+	if a.eps < 0 {
+		panic("adagrad: negative Epsilon")
+	}
+	return a.sg.init(loc, a, a.Converge)
+}
+
+func (a *AdaGrad) Iterate(loc *Location) (Operation, error) {
+	return a.sg.iterate(loc, a)
+}
+
+// Status returns the status of the convergence test of Converge.
+func (a *AdaGrad) Status() (Status, error) {
+	return a.sg.status, nil
+}
+
+func (a *AdaGrad) initStep(dim int) {
+	a.s = resize(a.s, dim)
+	for i := range a.s {
+		a.s[i] = 0
+	}
+}
+
+func (a *AdaGrad) step(x, grad []float64, k int) {
+	rate := learningRate(a.LearningRate, k, defaultSGDRate)
+	for i, g := range grad {
+		a.s[i] += g * g
+		x[i] -= rate * g / (math.Sqrt(a.s[i]) + a.eps)
+	}
+}
+
+func (*AdaGrad) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return stochasticNeeds()
+}
@@ -0,0 +1,110 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const (
+	defaultAdamBeta1 = 0.9
+	defaultAdamBeta2 = 0.999
+)
+
+// Adam implements the method of
+//
+//  Kingma, D.P., Ba, J.: Adam: A method for stochastic optimization. ICLR
+//  (2015).
+//
+// for minimizing a StochasticProblem, see Minibatches. Adam steps along
+// a moving average of the minibatch gradients, the momentum, divided by the
+// root of a moving average of their squares,
+//  m_{k+1} = Beta1 * m_k + (1 - Beta1) * g_k,
+//  v_{k+1} = Beta2 * v_k + (1 - Beta2) * g_k^2,
+//  x_{k+1} = x_k - rate_k * m̂_{k+1} / (sqrt(v̂_{k+1}) + Epsilon),
+// where m̂ and v̂ are the averages corrected for their bias towards the zero
+// initial values.
+//
+// Adam does not keep the best location found, so the result of Local is the
+// last location. The convergence of the noisy iterations is tested by
+// Converge, see StochasticConverge and DefaultSettingsStochastic.
+type Adam struct {
+	// LearningRate is the schedule of the learning rate.
+	// If LearningRate is nil, a constant rate of 0.001 is used.
+	LearningRate LearningRate
+	// Beta1 is the decay of the average of the gradients, in [0, 1).
+	// If Beta1 is zero, 0.9 is used.
+	Beta1 float64
+	// Beta2 is the decay of the average of the squared gradients, in [0, 1).
+	// If Beta2 is zero, 0.999 is used.
+	Beta2 float64
+	// Epsilon avoids the division by zero.
+	// If Epsilon is zero, 1e-8 is used.
+	Epsilon float64
+	// Converge tests the convergence of the iterations. If Converge is nil,
+	// the iterations run until a limit of Settings is reached.
+	Converge *StochasticConverge
+
+	beta1, beta2 float64
+	eps          float64
+	m, v         []float64
+	sg           stochasticGradient
+}
+
+func (a *Adam) Init(loc *Location) (Operation, error) {
+	a.beta1 = a.Beta1
+	if a.beta1 == 0 {
+		a.beta1 = defaultAdamBeta1
+	}
+	a.beta2 = a.Beta2
+	if a.beta2 == 0 {
+		a.beta2 = defaultAdamBeta2
+	}
+	if a.beta1 < 0 || a.beta1 >= 1 || a.beta2 < 0 || a.beta2 >= 1 {
+		panic("adam: Beta out of range")
+	}
+	a.eps = a.Epsilon
+	if a.eps == 0 {
+		a.eps = defaultAdaptiveEps
+	}
+	if a.eps < 0 {
+		panic("adam: negative Epsilon")
+	}
+	return a.sg.init(loc, a, a.Converge)
+}
+
+func (a *Adam) Iterate(loc *Location) (Operation, error) {
+	return a.sg.iterate(loc, a)
+}
+
+// Status returns the status of the convergence test of Converge.
+func (a *Adam) Status() (Status, error) {
+	return a.sg.status, nil
+}
+
+func (a *Adam) initStep(dim int) {
+	a.m = resize(a.m, dim)
+	a.v = resize(a.v, dim)
+	for i := range a.m {
+		a.m[i] = 0
+		a.v[i] = 0
+	}
+}
+
+func (a *Adam) step(x, grad []float64, k int) {
+	rate := learningRate(a.LearningRate, k, defaultAdaptiveRate)
+	c1 := 1 - math.Pow(a.beta1, float64(k+1))
+	c2 := 1 - math.Pow(a.beta2, float64(k+1))
+	for i, g := range grad {
+		a.m[i] = a.beta1*a.m[i] + (1-a.beta1)*g
+		a.v[i] = a.beta2*a.v[i] + (1-a.beta2)*g*g
+		x[i] -= rate * (a.m[i] / c1) / (math.Sqrt(a.v[i]/c2) + a.eps)
+	}
+}
+
+func (*Adam) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return stochasticNeeds()
+}
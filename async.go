@@ -0,0 +1,93 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "sync"
+
+// AsyncGlobalMethod is a global optimizer that evaluates locations
+// asynchronously. The method proposes locations one at a time and receives
+// the results of their evaluations as they complete, in any order, so that
+// a slow evaluation does not hold up the other ones. An AsyncGlobalMethod is
+// used with Global through Async.
+//
+// The methods of AsyncGlobalMethod are never called concurrently.
+type AsyncGlobalMethod interface {
+	// InitAsync initializes the method for a problem of dimension dim with
+	// at most concurrent evaluations in progress at the same time. It
+	// returns the number of evaluations it wants in progress, which must be
+	// positive and not larger than concurrent if concurrent is positive.
+	InitAsync(dim, concurrent int) int
+	// Propose stores the next location to evaluate in x and returns an id
+	// that identifies the location in the call to Receive.
+	Propose(x []float64) (id int, err error)
+	// Receive receives the evaluation of the location with the given id in
+	// loc. It returns MajorIteration after storing the best location found
+	// so far in loc, or NoOperation.
+	Receive(id int, loc *Location) (Operation, error)
+	Needser
+	// Done communicates to the method that the optimization has concluded.
+	// Evaluations that are still in progress are not received.
+	Done()
+}
+
+// Async is a GlobalMethod that runs an AsyncGlobalMethod. Each task of
+// Global receives the result of its last evaluation and then immediately
+// evaluates the next proposed location, without waiting for the other tasks.
+type Async struct {
+	Method AsyncGlobalMethod
+
+	mux   sync.Mutex
+	op    Operation // Evaluation requested for every location.
+	tasks []int     // Location evaluated by each task, or -1.
+}
+
+func (a *Async) Needs() struct{ Gradient, Hessian bool } {
+	return a.Method.Needs()
+}
+
+func (a *Async) InitGlobal(dim, tasks int) int {
+	tasks = a.Method.InitAsync(dim, tasks)
+	if tasks < 1 {
+		panic("optimize: no evaluations in progress")
+	}
+	a.op = FuncEvaluation
+	if a.Method.Needs().Gradient {
+		a.op |= GradEvaluation
+	}
+	if a.Method.Needs().Hessian {
+		a.op |= HessEvaluation
+	}
+	a.tasks = make([]int, tasks)
+	for i := range a.tasks {
+		a.tasks[i] = -1
+	}
+	return tasks
+}
+
+func (a *Async) IterateGlobal(task int, loc *Location) (Operation, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if id := a.tasks[task]; id >= 0 {
+		a.tasks[task] = -1
+		op, err := a.Method.Receive(id, loc)
+		if err != nil || op != NoOperation {
+			return op, err
+		}
+	}
+	id, err := a.Method.Propose(loc.X)
+	if err != nil {
+		return NoOperation, err
+	}
+	a.tasks[task] = id
+	return a.op, nil
+}
+
+// Lockstep does nothing, the tasks of Async never wait for each other.
+func (a *Async) Lockstep() {}
+
+func (a *Async) Done() {
+	a.Method.Done()
+}
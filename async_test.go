@@ -0,0 +1,78 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestAsync(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		f      func([]float64) float64
+		method func() AsyncGlobalMethod
+		fOpt   float64
+		evals  int
+		tol    float64
+	}{
+		{
+			name: "BayesianOptimization",
+			f:    branin,
+			method: func() AsyncGlobalMethod {
+				return &BayesianOptimization{
+					Bounds: []Bound{{-5, 10}, {0, 15}},
+					Src:    rand.New(rand.NewSource(1)),
+				}
+			},
+			fOpt:  0.39788735772973816,
+			evals: 60,
+			tol:   1e-2,
+		},
+		{
+			name: "GeneticAlgorithm",
+			f:    sixHumpCamel,
+			method: func() AsyncGlobalMethod {
+				return &GeneticAlgorithm{
+					Bounds: []Bound{{-3, 3}, {-2, 2}},
+					Src:    rand.New(rand.NewSource(1)),
+				}
+			},
+			fOpt:  -1.0316284534898774,
+			evals: 2000,
+			tol:   1e-3,
+		},
+	} {
+		// Evaluations take different amounts of time, so they complete in
+		// a different order than they are proposed.
+		f := func(x []float64) float64 {
+			time.Sleep(time.Duration(int(1e6*math.Abs(x[0]))%200) * time.Microsecond)
+			return test.f(x)
+		}
+		for _, deterministic := range []bool{false, true} {
+			settings := DefaultSettingsGlobal()
+			settings.FunctionConverge = nil
+			settings.FuncEvaluations = test.evals
+			settings.Concurrent = 4
+			settings.Deterministic = deterministic
+			result, err := Global(Problem{Func: f}, 2, settings, &Async{Method: test.method()})
+			if err != nil {
+				t.Errorf("%v, Deterministic=%v: unexpected error: %v", test.name, deterministic, err)
+				continue
+			}
+			if result.Status != FunctionEvaluationLimit {
+				t.Errorf("%v, Deterministic=%v: unexpected status %v", test.name, deterministic, result.Status)
+			}
+			if math.Abs(result.F-test.fOpt) > test.tol {
+				t.Errorf("%v, Deterministic=%v: minimum not found, want %v, got %v", test.name, deterministic, test.fOpt, result.F)
+			}
+			if result.F != test.f(result.X) {
+				t.Errorf("%v, Deterministic=%v: function value at X not equal to F", test.name, deterministic)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "sync"
+
+// batcher is implemented by global methods that evaluate the objective
+// function in batches using batchEvaluator. The methods of batcher are called
+// with the lock of batchEvaluator held.
+type batcher interface {
+	// location stores the location with the given id in x.
+	location(id int, x []float64)
+	// evaluated receives the function value at the location with the given
+	// id in loc.F.
+	evaluated(id int, loc *Location)
+	// nextBatch is called when all the locations of the current batch have
+	// been evaluated. It returns the ids of the locations in the next batch.
+	// If major is true, nextBatch has stored the best location found so far
+	// in loc and a MajorIteration is announced.
+	nextBatch(loc *Location) (ids []int, major bool)
+}
+
+// batchEvaluator distributes the evaluation of batches of locations among the
+// tasks of Global. A new batch is created only after all the locations of
+// the current batch have been evaluated, so the evaluated locations do not
+// depend on the number of tasks or on the order in which the evaluations
+// complete. Tasks that find no location left to evaluate wait for the other
+// tasks to complete the batch.
+type batchEvaluator struct {
+	mux      *sync.Mutex
+	cond     *sync.Cond // Signals a new batch or the end of the optimization.
+	done     bool
+	lockstep bool // Return NoOperation instead of waiting, see Lockstepper.
+
+	queue  []int // Locations of the current batch waiting for evaluation.
+	tasks  []int // Location evaluated by each task, or -1.
+	active int   // Number of evaluations in progress.
+}
+
+// init initializes the batchEvaluator for the given number of tasks and the
+// first batch of locations.
+func (b *batchEvaluator) init(tasks int, ids []int) {
+	b.mux = &sync.Mutex{}
+	b.cond = sync.NewCond(b.mux)
+	b.done = false
+	b.lockstep = false
+	b.queue = append(b.queue[:0], ids...)
+	b.active = 0
+	b.tasks = make([]int, tasks)
+	for i := range b.tasks {
+		b.tasks[i] = -1
+	}
+}
+
+// iterate implements IterateGlobal for the method m.
+func (b *batchEvaluator) iterate(m batcher, task int, loc *Location) Operation {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.done {
+		return NoOperation
+	}
+	if id := b.tasks[task]; id >= 0 {
+		// The task returns with the function value at location id.
+		b.tasks[task] = -1
+		b.active--
+		m.evaluated(id, loc)
+		if b.active == 0 && len(b.queue) == 0 {
+			ids, major := m.nextBatch(loc)
+			b.queue = append(b.queue, ids...)
+			b.cond.Broadcast()
+			if major {
+				return MajorIteration
+			}
+		}
+	}
+
+	if len(b.queue) == 0 && b.lockstep {
+		return NoOperation
+	}
+	for len(b.queue) == 0 && !b.done {
+		b.cond.Wait()
+	}
+	if b.done {
+		return NoOperation
+	}
+	id := b.queue[0]
+	b.queue = b.queue[1:]
+	b.tasks[task] = id
+	b.active++
+	m.location(id, loc.X)
+	return FuncEvaluation
+}
+
+// finish releases the waiting tasks at the end of the optimization.
+func (b *batchEvaluator) finish() {
+	if b.mux == nil {
+		// The method runs asynchronously and has never used the evaluator.
+		return
+	}
+	b.mux.Lock()
+	b.done = true
+	b.cond.Broadcast()
+	b.mux.Unlock()
+}
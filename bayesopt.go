@@ -0,0 +1,443 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gonum/stat/distmv"
+)
+
+const (
+	defaultUCBKappa = 2
+
+	// Number of random candidates per dimension from which the maximization of
+	// the acquisition function starts.
+	acquisitionCandidates = 500
+)
+
+// Acquisition is an acquisition function of Bayesian optimization. It
+// measures how desirable it is to evaluate the objective function at
+// a location given the prediction of the surrogate model there.
+type Acquisition interface {
+	// Acquire returns the value of the acquisition function for a location
+	// at which the surrogate model predicts the given mean and standard
+	// deviation. best is the lowest function value found so far. All values
+	// are standardized to zero mean and unit variance of the observed
+	// function values. BayesianOptimization evaluates the location with the
+	// largest value of Acquire.
+	Acquire(mean, std, best float64) float64
+}
+
+// ExpectedImprovement is the expected amount by which the function value at
+// a location improves on best - Xi,
+//  EI = (best - Xi - mean) Φ(z) + std φ(z),  z = (best - Xi - mean) / std,
+// where Φ and φ are the standard normal distribution and density functions.
+// Positive values of Xi favor exploration.
+type ExpectedImprovement struct {
+	Xi float64
+}
+
+func (e ExpectedImprovement) Acquire(mean, std, best float64) float64 {
+	imp := best - e.Xi - mean
+	if std == 0 {
+		return math.Max(imp, 0)
+	}
+	z := imp / std
+	cdf := 0.5 * math.Erfc(-z/math.Sqrt2)
+	pdf := math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+	return imp*cdf + std*pdf
+}
+
+// UpperConfidenceBound is the upper confidence bound on the improvement of
+// the function value,
+//  UCB = Kappa * std - mean,
+// which is the negative of the lower confidence bound on the function value.
+// Larger values of Kappa favor exploration.
+// If Kappa is zero, 2 is used instead.
+type UpperConfidenceBound struct {
+	Kappa float64
+}
+
+func (u UpperConfidenceBound) Acquire(mean, std, _ float64) float64 {
+	kappa := u.Kappa
+	if kappa == 0 {
+		kappa = defaultUCBKappa
+	}
+	return kappa*std - mean
+}
+
+// BayesianOptimization is a global optimizer for expensive functions over
+// a box. It models the objective function by a Gaussian process that is
+// fitted to all the evaluated locations, and evaluates next the location which
+// maximizes an acquisition function of the prediction of the model. See for
+// example
+//
+//  Shahriari, B., Swersky, K., Wang, Z., Adams, R.P., de Freitas, N.: Taking
+//  the human out of the loop: A review of Bayesian optimization. Proceedings
+//  of the IEEE 104 (2016), 148-175.
+//
+// The optimization starts by evaluating InitialSamples locations generated by
+// Sampler. Afterwards, at every major iteration the hyperparameters of the
+// Gaussian process are fitted by maximizing the marginal likelihood with
+// Local, and a batch of locations is proposed, one for every concurrent task
+// of Global. The locations of a batch are chosen one after another, each time
+// adding the previous proposals to the model with the function value predicted
+// by the model (the kriging believer heuristic), which reduces the
+// uncertainty around them and spreads the batch out. The next batch is
+// proposed when all locations of the current one have been evaluated.
+//
+// The cost of BayesianOptimization grows with the cube of the number of
+// evaluations, so it is only suitable for functions that are much more
+// expensive to evaluate than the model, typically with up to a few hundred
+// evaluations in at most about twenty dimensions. Evaluations that return
+// NaN or infinite values are not included in the model.
+//
+// BayesianOptimization also implements AsyncGlobalMethod for use with Async,
+// which proposes a new location as soon as any evaluation completes.
+type BayesianOptimization struct {
+	// Bounds is the box in which the minimum is sought. It must contain one
+	// Bound for every dimension of the problem, and BayesianOptimization
+	// will panic if Bounds has the wrong size or if Min >= Max for any Bound.
+	Bounds []Bound
+	// Kernel is the covariance function of the Gaussian process. The
+	// locations are scaled to the unit hypercube before they are passed to
+	// Kernel.
+	// If Kernel is nil, Matern52 is used.
+	Kernel Kernel
+	// Acquisition selects the locations to evaluate.
+	// If Acquisition is nil, ExpectedImprovement is used.
+	Acquisition Acquisition
+	// InitialSamples is the number of random locations evaluated before the
+	// Gaussian process is used.
+	// If InitialSamples is zero, it will be set to 2*dim + 1.
+	InitialSamples int
+	// Sampler generates the initial samples within Bounds, for example
+	// a LatinHypercube with InitialSamples Samples or a Sobol sequence.
+	// If Sampler is nil, the initial samples are uniformly random.
+	Sampler distmv.Rander
+	// Src is the source of random numbers. If Src is nil, the global source
+	// of the math/rand package is used.
+	Src *rand.Rand
+
+	dim int
+
+	eval batchEvaluator
+
+	gp       gaussianProcess
+	hyper0   []float64   // Initial hyperparameters of the Gaussian process.
+	initial  []int       // Initial samples not yet proposed by Propose.
+	pending  []int       // Locations proposed by Propose and not yet received.
+	proposed [][]float64 // Proposed locations scaled to the unit hypercube.
+	x        [][]float64 // Evaluated locations scaled to the unit hypercube.
+	f        []float64   // Function values at x.
+	bestX    []float64
+	bestF    float64
+	batch    int // Number of locations proposed at each major iteration.
+}
+
+func (b *BayesianOptimization) Needs() struct{ Gradient, Hessian bool } {
+	return struct{ Gradient, Hessian bool }{false, false}
+}
+
+func (b *BayesianOptimization) InitGlobal(dim, tasks int) int {
+	if tasks < 1 {
+		tasks = 1
+	}
+	b.eval.init(tasks, b.init(dim, tasks))
+	return tasks
+}
+
+// InitAsync initializes BayesianOptimization for asynchronous evaluations
+// with Async. Instead of batches, a single location is proposed whenever an
+// evaluation completes, taking the evaluations in progress into account with
+// the kriging believer heuristic.
+func (b *BayesianOptimization) InitAsync(dim, concurrent int) int {
+	if concurrent < 1 {
+		concurrent = 1
+	}
+	b.initial = b.init(dim, 1)
+	b.pending = b.pending[:0]
+	return concurrent
+}
+
+// init initializes the method and returns the ids of the initial samples.
+func (b *BayesianOptimization) init(dim, batch int) []int {
+	if len(b.Bounds) != dim {
+		panic("bayesopt: bounds size mismatch")
+	}
+	for _, bound := range b.Bounds {
+		if !(bound.Min < bound.Max) {
+			panic("bayesopt: invalid bound")
+		}
+	}
+	if b.InitialSamples < 0 {
+		panic("bayesopt: negative InitialSamples")
+	}
+	b.dim = dim
+	b.batch = batch
+
+	kernel := b.Kernel
+	if kernel == nil {
+		kernel = Matern52{}
+	}
+	nHyper := kernel.NumHyper(dim)
+	b.gp = gaussianProcess{
+		kernel: kernel,
+		hyper:  make([]float64, nHyper+2),
+	}
+	// Start from length scales of a fraction of the box, unit signal
+	// variance and small noise.
+	for i := 0; i < nHyper; i++ {
+		b.gp.hyper[i] = math.Log(0.3)
+	}
+	b.gp.hyper[nHyper+1] = math.Log(1e-6)
+	b.hyper0 = append(b.hyper0[:0], b.gp.hyper...)
+
+	b.x = b.x[:0]
+	b.f = b.f[:0]
+	b.bestX = resize(b.bestX, dim)
+	b.bestF = math.Inf(1)
+
+	n := b.InitialSamples
+	if n == 0 {
+		n = 2*dim + 1
+	}
+	b.proposed = b.proposed[:0]
+	var ids []int
+	for i := 0; i < n; i++ {
+		if b.Sampler == nil {
+			ids = append(ids, b.addRandom())
+			continue
+		}
+		x := b.Sampler.Rand(make([]float64, dim))
+		for j, v := range x {
+			bound := b.Bounds[j]
+			x[j] = math.Max(0, math.Min((v-bound.Min)/(bound.Max-bound.Min), 1))
+		}
+		ids = append(ids, b.add(x))
+	}
+	return ids
+}
+
+func (b *BayesianOptimization) IterateGlobal(task int, loc *Location) (Operation, error) {
+	return b.eval.iterate(b, task, loc), nil
+}
+
+func (b *BayesianOptimization) Lockstep() {
+	b.eval.lockstep = true
+}
+
+func (b *BayesianOptimization) Done() {
+	b.eval.finish()
+}
+
+func (b *BayesianOptimization) Propose(x []float64) (int, error) {
+	var id int
+	if len(b.initial) > 0 {
+		id = b.initial[0]
+		b.initial = b.initial[1:]
+	} else {
+		id = b.proposeOne()
+	}
+	b.pending = append(b.pending, id)
+	b.location(id, x)
+	return id, nil
+}
+
+func (b *BayesianOptimization) Receive(id int, loc *Location) (Operation, error) {
+	for i, p := range b.pending {
+		if p == id {
+			b.pending = append(b.pending[:i], b.pending[i+1:]...)
+			break
+		}
+	}
+	b.evaluated(id, loc)
+	if math.IsInf(b.bestF, 1) {
+		return NoOperation, nil
+	}
+	copy(loc.X, b.bestX)
+	loc.F = b.bestF
+	return MajorIteration, nil
+}
+
+func (b *BayesianOptimization) location(id int, x []float64) {
+	for i, v := range b.proposed[id] {
+		bound := b.Bounds[i]
+		x[i] = bound.Min + v*(bound.Max-bound.Min)
+	}
+}
+
+func (b *BayesianOptimization) evaluated(id int, loc *Location) {
+	if math.IsNaN(loc.F) || math.IsInf(loc.F, 0) {
+		return
+	}
+	b.x = append(b.x, b.proposed[id])
+	b.f = append(b.f, loc.F)
+	if loc.F < b.bestF {
+		b.bestF = loc.F
+		copy(b.bestX, loc.X)
+	}
+}
+
+// nextBatch proposes the next batch of locations once the current one has
+// been evaluated.
+func (b *BayesianOptimization) nextBatch(loc *Location) (ids []int, major bool) {
+	ids = b.propose()
+	if math.IsInf(b.bestF, 1) {
+		return ids, false
+	}
+	copy(loc.X, b.bestX)
+	loc.F = b.bestF
+	return ids, true
+}
+
+// addRandom proposes a uniformly random location and returns its id.
+func (b *BayesianOptimization) addRandom() int {
+	x := make([]float64, b.dim)
+	for j := range x {
+		x[j] = b.uniform()
+	}
+	return b.add(x)
+}
+
+// add proposes the location x and returns its id.
+func (b *BayesianOptimization) add(x []float64) int {
+	b.proposed = append(b.proposed, x)
+	return len(b.proposed) - 1
+}
+
+func (b *BayesianOptimization) uniform() float64 {
+	if b.Src == nil {
+		return rand.Float64()
+	}
+	return b.Src.Float64()
+}
+
+// propose fits the Gaussian process to the evaluated locations and returns
+// the ids of the next batch of locations to evaluate.
+func (b *BayesianOptimization) propose() []int {
+	ids := make([]int, 0, b.batch)
+	if len(b.f) < 2 {
+		// Not enough data for a model, keep sampling at random.
+		for i := 0; i < b.batch; i++ {
+			ids = append(ids, b.addRandom())
+		}
+		return ids
+	}
+
+	best, ok := b.fit()
+	for i := 0; i < b.batch; i++ {
+		if !ok {
+			// The model has failed, so fall back to random sampling.
+			ids = append(ids, b.addRandom())
+			continue
+		}
+		x := b.maximizeAcquisition(best)
+		ids = append(ids, b.add(x))
+		if i == b.batch-1 {
+			break
+		}
+		ok = b.believe(x)
+	}
+	return ids
+}
+
+// proposeOne fits the Gaussian process to the evaluated locations and the
+// locations whose evaluations are in progress, and returns the id of the next
+// location to evaluate.
+func (b *BayesianOptimization) proposeOne() int {
+	if len(b.f) < 2 {
+		return b.addRandom()
+	}
+	best, ok := b.fit()
+	for _, id := range b.pending {
+		if !ok {
+			break
+		}
+		ok = b.believe(b.proposed[id])
+	}
+	if !ok {
+		return b.addRandom()
+	}
+	return b.add(b.maximizeAcquisition(best))
+}
+
+// fit fits the Gaussian process to the standardized function values at the
+// evaluated locations. It returns the standardized best function value and
+// whether the fit has succeeded.
+func (b *BayesianOptimization) fit() (best float64, ok bool) {
+	var mean, variance float64
+	for _, f := range b.f {
+		mean += f
+	}
+	mean /= float64(len(b.f))
+	for _, f := range b.f {
+		variance += (f - mean) * (f - mean)
+	}
+	std := math.Sqrt(variance / float64(len(b.f)))
+	if std == 0 {
+		std = 1
+	}
+	b.gp.x = append(b.gp.x[:0], b.x...)
+	b.gp.y = b.gp.y[:0]
+	for _, f := range b.f {
+		b.gp.y = append(b.gp.y, (f-mean)/std)
+	}
+	return (b.bestF - mean) / std, b.gp.fit(b.hyper0)
+}
+
+// believe adds the location x to the Gaussian process, pretending that the
+// function value at x is the predicted mean.
+func (b *BayesianOptimization) believe(x []float64) bool {
+	m, _ := b.gp.predict(x)
+	b.gp.x = append(b.gp.x, x)
+	b.gp.y = append(b.gp.y, m)
+	return b.gp.factorize()
+}
+
+// maximizeAcquisition returns the location in the unit hypercube with the
+// largest value of the acquisition function. It takes the best of a set of
+// random locations and refines it by a local optimization.
+func (b *BayesianOptimization) maximizeAcquisition(best float64) []float64 {
+	acq := b.Acquisition
+	if acq == nil {
+		acq = ExpectedImprovement{}
+	}
+	clamped := make([]float64, b.dim)
+	negAcquire := func(x []float64) float64 {
+		for i, v := range x {
+			clamped[i] = math.Max(0, math.Min(v, 1))
+		}
+		m, s := b.gp.predict(clamped)
+		return -acq.Acquire(m, s, best)
+	}
+
+	x := make([]float64, b.dim)
+	bestX := make([]float64, b.dim)
+	bestAcq := math.Inf(1)
+	for i := 0; i < acquisitionCandidates*b.dim; i++ {
+		for j := range x {
+			x[j] = b.uniform()
+		}
+		if a := negAcquire(x); a < bestAcq {
+			bestAcq = a
+			copy(bestX, x)
+		}
+	}
+
+	settings := DefaultSettings()
+	settings.FunctionConverge.Absolute = 1e-8
+	settings.FuncEvaluations = 100 * b.dim
+	result, err := Local(Problem{Func: negAcquire}, bestX, settings, &NelderMead{SimplexSize: 0.01})
+	if err == nil && result.F < bestAcq {
+		copy(bestX, result.X)
+	}
+	for i, v := range bestX {
+		bestX[i] = math.Max(0, math.Min(v, 1))
+	}
+	return bestX
+}
@@ -0,0 +1,97 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// branin is a two-dimensional function with three global minima with the
+// value of 0.39788735772973816.
+func branin(x []float64) float64 {
+	b := 5.1 / (4 * math.Pi * math.Pi)
+	c := 5 / math.Pi
+	t := 1 / (8 * math.Pi)
+	d := x[1] - b*x[0]*x[0] + c*x[0] - 6
+	return d*d + 10*(1-t)*math.Cos(x[0]) + 10
+}
+
+func TestBayesianOptimization(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		f           func([]float64) float64
+		bounds      []Bound
+		fOpt        float64
+		kernel      Kernel
+		acquisition Acquisition
+		concurrent  []int
+		tol         float64
+	}{
+		{
+			name:       "Branin",
+			f:          branin,
+			bounds:     []Bound{{-5, 10}, {0, 15}},
+			fOpt:       0.39788735772973816,
+			concurrent: []int{1, 4},
+			tol:        1e-2,
+		},
+		{
+			name:        "Branin",
+			f:           branin,
+			bounds:      []Bound{{-5, 10}, {0, 15}},
+			fOpt:        0.39788735772973816,
+			kernel:      SquaredExponential{},
+			acquisition: UpperConfidenceBound{},
+			concurrent:  []int{1, 4},
+			tol:         1e-2,
+		},
+		{
+			name:        "Branin",
+			f:           branin,
+			bounds:      []Bound{{-5, 10}, {0, 15}},
+			fOpt:        0.39788735772973816,
+			acquisition: ExpectedImprovement{Xi: 0.01},
+			concurrent:  []int{1, 4},
+			tol:         0.1,
+		},
+		{
+			name:       "SixHumpCamel",
+			f:          sixHumpCamel,
+			bounds:     []Bound{{-3, 3}, {-2, 2}},
+			fOpt:       -1.0316284534898774,
+			concurrent: []int{1},
+			tol:        1e-2,
+		},
+	} {
+		for _, concurrent := range test.concurrent {
+			method := &BayesianOptimization{
+				Bounds:      test.bounds,
+				Kernel:      test.kernel,
+				Acquisition: test.acquisition,
+				Src:         rand.New(rand.NewSource(1)),
+			}
+			settings := DefaultSettingsGlobal()
+			settings.FunctionConverge = nil
+			settings.FuncEvaluations = 40
+			settings.Concurrent = concurrent
+			result, err := Global(Problem{Func: test.f}, len(test.bounds), settings, method)
+			if err != nil {
+				t.Errorf("%v, Concurrent=%v: unexpected error: %v", test.name, concurrent, err)
+				continue
+			}
+			if result.Status != FunctionEvaluationLimit {
+				t.Errorf("%v, Concurrent=%v: unexpected status %v", test.name, concurrent, result.Status)
+			}
+			if math.Abs(result.F-test.fOpt) > test.tol {
+				t.Errorf("%v, Concurrent=%v: minimum not found, want %v, got %v", test.name, concurrent, test.fOpt, result.F)
+			}
+			if result.F != test.f(result.X) {
+				t.Errorf("%v, Concurrent=%v: function value at X not equal to F", test.name, concurrent)
+			}
+		}
+	}
+}
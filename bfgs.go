@@ -7,19 +7,100 @@ package optimize
 import (
 	"math"
 
+	"github.com/gonum/floats"
 	"github.com/gonum/matrix/mat64"
 )
 
+// HessianScaling is the strategy by which BFGS and LBFGS choose the initial
+// approximation H_0 of the inverse Hessian from the step s = x_{k+1} - x_k and
+// the change y = ∇f_{k+1} - ∇f_k of the gradient.
+type HessianScaling int
+
+const (
+	// ShannoPhuaScaling uses the scaled identity matrix
+	//  H_0 = (s·y / y·y) I,
+	// whose scale approximates the inverse of an eigenvalue of the Hessian.
+	ShannoPhuaScaling HessianScaling = iota
+	// IdentityScaling uses the identity matrix H_0 = I. It is appropriate only
+	// if the Hessian is close to the identity, for example after a change of
+	// variables.
+	IdentityScaling
+	// DiagonalScaling uses a diagonal matrix H_0 = D that is updated by the
+	// diagonal of the BFGS update of D^{-1}, which captures different scales
+	// of the variables. See
+	//
+	//  Gilbert, J.C., Lemaréchal, C.: Some numerical experiments with
+	//  variable-storage quasi-Newton algorithms. Math. Program. 45 (1989),
+	//  407-435.
+	DiagonalScaling
+)
+
+// dampingFactor is the constant of Powell's damping of the BFGS update.
+const dampingFactor = 0.2
+
+// updateDiagonal updates the diagonal approximation d of the inverse Hessian
+// with the step s and the change y of the gradient. d is first scaled such that
+// y·D y = s·y, then the diagonal of the BFGS update of D^{-1} is inverted.
+// s·y must be positive.
+func updateDiagonal(d, s, y []float64) {
+	sy := floats.Dot(s, y)
+	var yDy float64
+	for i, v := range y {
+		yDy += v * d[i] * v
+	}
+	floats.Scale(sy/yDy, d)
+	var sBs float64
+	for i, v := range s {
+		sBs += v * v / d[i]
+	}
+	for i, v := range s {
+		b := 1/d[i] + y[i]*y[i]/sy - (v/d[i])*(v/d[i])/sBs
+		if b > 0 && !math.IsInf(b, 1) {
+			d[i] = 1 / b
+		}
+	}
+}
+
+// dampingTheta returns the weight θ of Powell's damped change of the gradient
+//  r = θ y + (1 - θ) B s,
+// given s·y and s·B s, where B is the approximation of the Hessian that is
+// updated. θ is the largest weight in [0, 1] that guarantees
+// s·r >= 0.2 s·B s, so the update preserves positive definiteness.
+func dampingTheta(sy, sBs float64) float64 {
+	if sy >= dampingFactor*sBs {
+		return 1
+	}
+	return (1 - dampingFactor) * sBs / (sBs - sy)
+}
+
 // BFGS implements the Broyden–Fletcher–Goldfarb–Shanno optimization method. It
 // is a quasi-Newton method that performs successive rank-one updates to an
 // estimate of the inverse Hessian of the objective function. It exhibits
 // super-linear convergence when in proximity to a local minimum. It has memory
 // cost that is O(n^2) relative to the input dimension.
+//
+// The update keeps the estimate positive definite only if the curvature
+// condition s_k^T y_k > 0 holds, which the strong Wolfe conditions guarantee.
+// If Damped is true, y_k is replaced by Powell's damped
+//  r_k = θ_k y_k + (1 - θ_k) B_k s_k,
+// where B_k is the inverse of the estimate, and θ_k in (0, 1] is chosen such that
+// s_k^T r_k >= 0.2 s_k^T B_k s_k. The damped update always preserves positive
+// definiteness, so BFGS stays robust with line searches such as Backtracking
+// that enforce only the Armijo condition. See
+//
+//  Nocedal, J., Wright, S.: Numerical Optimization (2nd ed). Springer (2006),
+//  Procedure 18.2.
 type BFGS struct {
 	// Linesearcher selects suitable steps along the descent direction.
-	// Accepted steps should satisfy the strong Wolfe conditions.
+	// Accepted steps should satisfy the strong Wolfe conditions unless
+	// Damped is true.
 	// If Linesearcher == nil, an appropriate default is chosen.
 	Linesearcher Linesearcher
+	// Scaling is the strategy for the initial estimate of the inverse Hessian,
+	// which is chosen at the first update. The default is ShannoPhuaScaling.
+	Scaling HessianScaling
+	// Damped specifies whether the updates are damped.
+	Damped bool
 
 	ls *LinesearchMethod
 
@@ -28,6 +109,7 @@ type BFGS struct {
 	grad mat64.Vector // Gradient at the last major iteration.
 	s    mat64.Vector // Difference between locations in this and the previous iteration.
 	y    mat64.Vector // Difference between gradients in this and the previous iteration.
+	dir  mat64.Vector // Direction of the last line search.
 	tmp  mat64.Vector
 
 	invHess *mat64.SymDense
@@ -36,6 +118,9 @@ type BFGS struct {
 }
 
 func (b *BFGS) Init(loc *Location) (Operation, error) {
+	if b.Scaling < ShannoPhuaScaling || b.Scaling > DiagonalScaling {
+		panic("bfgs: unknown Scaling")
+	}
 	if b.Linesearcher == nil {
 		b.Linesearcher = &Bisection{}
 	}
@@ -78,6 +163,7 @@ func (b *BFGS) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 	// is an identity matrix.
 	d := mat64.NewVector(dim, dir)
 	d.ScaleVec(-1, grad)
+	b.dir.CloneVec(d)
 	return 1 / mat64.Norm(d, 2)
 }
 
@@ -107,18 +193,56 @@ func (b *BFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 		// Rescale the initial Hessian.
 		// From: Nocedal, J., Wright, S.: Numerical Optimization (2nd ed).
 		//       Springer (2006), page 143, eq. 6.20.
-		yDotY := mat64.Dot(&b.y, &b.y)
-		scale := sDotY / yDotY
+		diag := make([]float64, dim)
+		for i := range diag {
+			diag[i] = 1
+		}
+		if sDotY > 0 {
+			switch b.Scaling {
+			case ShannoPhuaScaling:
+				floats.Scale(sDotY/mat64.Dot(&b.y, &b.y), diag)
+			case DiagonalScaling:
+				s := make([]float64, dim)
+				y := make([]float64, dim)
+				for i := range s {
+					s[i] = b.s.At(i, 0)
+					y[i] = b.y.At(i, 0)
+				}
+				updateDiagonal(diag, s, y)
+			}
+		}
 		for i := 0; i < dim; i++ {
 			for j := i; j < dim; j++ {
 				if i == j {
-					b.invHess.SetSym(i, i, scale)
+					b.invHess.SetSym(i, i, diag[i])
 				} else {
 					b.invHess.SetSym(i, j, 0)
 				}
 			}
 		}
 		b.first = false
+
+		if b.Damped {
+			// B_0 s_0 is stored in tmp.
+			b.tmp.CloneVec(&b.s)
+			for i, v := range diag {
+				b.tmp.SetVec(i, b.s.At(i, 0)/v)
+			}
+		}
+	} else if b.Damped {
+		// The last direction is -H_k ∇f_k and s_k is a multiple t of it, so
+		// B_k s_k = -t ∇f_k, which is stored in tmp.
+		t := mat64.Dot(&b.s, &b.dir) / mat64.Dot(&b.dir, &b.dir)
+		b.tmp.ScaleVec(-t, &b.grad)
+	}
+	if b.Damped {
+		sBs := mat64.Dot(&b.s, &b.tmp)
+		if theta := dampingTheta(sDotY, sBs); theta < 1 {
+			b.y.ScaleVec(theta, &b.y)
+			b.tmp.ScaleVec(1-theta, &b.tmp)
+			b.y.AddVec(&b.y, &b.tmp)
+			sDotY = mat64.Dot(&b.s, &b.y)
+		}
 	}
 
 	if math.Abs(sDotY) != 0 {
@@ -145,6 +269,7 @@ func (b *BFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	d := mat64.NewVector(dim, dir)
 	d.MulVec(b.invHess, grad)
 	d.ScaleVec(-1, d)
+	b.dir.CopyVec(d)
 
 	return 1
 }
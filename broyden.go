@@ -0,0 +1,209 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const defaultBroydenDiffStep = 1.4901161193847656e-08 // Square root of the machine epsilon.
+
+// broydenStage is the stage of an iteration of Broyden.
+type broydenStage int
+
+const (
+	broydenDiff broydenStage = iota
+	broydenLine
+	broydenMajor
+)
+
+// Broyden implements Broyden's quasi-Newton method for solving systems of
+// nonlinear equations with Root without derivatives. It approximates the
+// Jacobian of F at the initial location by forward differences and then
+// updates the approximation B with every step s and the change y of the
+// residuals along it by the rank-one update
+//  B_{k+1} = B_k + (y - B_k s) s^T / (s^T s),
+// so that B_{k+1} s = y. Like NewtonRoot, it solves B_k d_k = -F_k for the
+// search direction and finds the step size by a line search on the merit
+// function 1/2 |F(x)|^2, whose directional derivative is estimated with B_k.
+// When the line search fails because the direction from the updated B_k does
+// not decrease the merit function, the approximation is recomputed by forward
+// differences at the current location. See
+//
+//  Broyden, C.G.: A class of methods for solving nonlinear simultaneous
+//  equations. Math. Comp. 19 (1965), 577-593.
+//  Dennis, J.E., Schnabel, R.B.: Numerical Methods for Unconstrained
+//  Optimization and Nonlinear Equations. SIAM (1996), Chapter 8.
+//
+// Every approximation by forward differences takes dim function evaluations,
+// and every iteration is then usually a single evaluation, so Broyden needs
+// fewer evaluations than NewtonRoot with a finite-difference Jacobian.
+type Broyden struct {
+	// Linesearcher is used for selecting suitable steps along the search
+	// direction. It must request only function values.
+	// If Linesearcher == nil, Backtracking is used.
+	Linesearcher Linesearcher
+	// DiffStep is the relative step of the forward differences. The step
+	// along the coordinate i is DiffStep * max(|x_i|, 1).
+	// If DiffStep is 0, it is defaulted to the square root of the machine
+	// epsilon.
+	DiffStep float64
+
+	stage broydenStage
+
+	x     []float64    // Location at the last major iteration.
+	r     []float64    // Residuals at x.
+	f     float64      // Half of the squared norm of r.
+	jac   *mat64.Dense // Approximation of the Jacobian at x.
+	fresh bool         // jac has been computed by forward differences at x.
+	col   int          // Column of the forward difference.
+	h     float64      // Step of the forward difference.
+
+	dir      []float64 // Search direction.
+	s, y, bs []float64 // Step, change of the residuals and B*s.
+}
+
+func (b *Broyden) Init(loc *Location) (Operation, error) {
+	if b.Linesearcher == nil {
+		b.Linesearcher = &Backtracking{}
+	}
+	if b.DiffStep == 0 {
+		b.DiffStep = defaultBroydenDiffStep
+	}
+	if b.DiffStep < 0 {
+		panic("broyden: negative DiffStep")
+	}
+
+	dim := len(loc.X)
+	b.x = resize(b.x, dim)
+	b.r = resize(b.r, dim)
+	b.dir = resize(b.dir, dim)
+	b.s = resize(b.s, dim)
+	b.y = resize(b.y, dim)
+	b.bs = resize(b.bs, dim)
+	if b.jac == nil {
+		b.jac = mat64.NewDense(dim, dim, nil)
+	} else if r, _ := b.jac.Dims(); r != dim {
+		b.jac = mat64.NewDense(dim, dim, nil)
+	}
+
+	copy(b.x, loc.X)
+	copy(b.r, loc.Objectives)
+	b.f = loc.F
+	return b.startDiff(loc)
+}
+
+func (b *Broyden) Iterate(loc *Location) (Operation, error) {
+	switch b.stage {
+	case broydenDiff:
+		for i, v := range loc.Objectives {
+			b.jac.Set(i, b.col, (v-b.r[i])/b.h)
+		}
+		b.col++
+		if b.col < len(b.x) {
+			return b.diff(loc)
+		}
+		b.fresh = true
+		return b.startLine(loc)
+	case broydenMajor:
+		return b.startLine(loc)
+	}
+
+	// loc holds the residuals at a trial location of the line search.
+	op, step, err := b.Linesearcher.Iterate(loc.F, math.NaN())
+	if err != nil {
+		if !b.fresh {
+			return b.startDiff(loc)
+		}
+		return NoOperation, err
+	}
+	switch op {
+	case MajorIteration:
+		// Update the approximation with the accepted step.
+		floats.SubTo(b.s, loc.X, b.x)
+		floats.SubTo(b.y, loc.Objectives, b.r)
+		mat64.NewVector(len(b.bs), b.bs).MulVec(b.jac, mat64.NewVector(len(b.s), b.s))
+		ss := floats.Dot(b.s, b.s)
+		for i := range b.y {
+			c := (b.y[i] - b.bs[i]) / ss
+			for j, sj := range b.s {
+				b.jac.Set(i, j, b.jac.At(i, j)+c*sj)
+			}
+		}
+		copy(b.x, loc.X)
+		copy(b.r, loc.Objectives)
+		b.f = loc.F
+		b.fresh = false
+		b.stage = broydenMajor
+		return MajorIteration, nil
+	case FuncEvaluation:
+		return b.trial(loc, step)
+	default:
+		panic("broyden: Linesearcher requested a derivative")
+	}
+}
+
+// startDiff starts the approximation of the Jacobian at x by forward
+// differences.
+func (b *Broyden) startDiff(loc *Location) (Operation, error) {
+	b.col = 0
+	return b.diff(loc)
+}
+
+// diff requests the evaluation of the forward difference along the current
+// column.
+func (b *Broyden) diff(loc *Location) (Operation, error) {
+	b.stage = broydenDiff
+	b.h = b.DiffStep * math.Max(math.Abs(b.x[b.col]), 1)
+	copy(loc.X, b.x)
+	loc.X[b.col] += b.h
+	return FuncEvaluation, nil
+}
+
+// startLine starts the line search along the quasi-Newton direction from x.
+func (b *Broyden) startLine(loc *Location) (Operation, error) {
+	newtonRootStep(b.dir, b.jac, b.r)
+	// The directional derivative of the merit function estimated with the
+	// approximation of the Jacobian.
+	mat64.NewVector(len(b.bs), b.bs).MulVec(b.jac, mat64.NewVector(len(b.dir), b.dir))
+	deriv := floats.Dot(b.r, b.bs)
+	if !(deriv < 0) {
+		if !b.fresh {
+			return b.startDiff(loc)
+		}
+		return NoOperation, ErrNonDescentDirection
+	}
+	if op := b.Linesearcher.Init(b.f, deriv, 1); op != FuncEvaluation {
+		panic("broyden: Linesearcher requested a derivative")
+	}
+	b.stage = broydenLine
+	return b.trial(loc, 1)
+}
+
+// trial requests the evaluation at the trial location of the line search
+// with the given step size.
+func (b *Broyden) trial(loc *Location, step float64) (Operation, error) {
+	floats.AddScaledTo(loc.X, b.x, step, b.dir)
+	if floats.Equal(loc.X, b.x) {
+		if !b.fresh {
+			return b.startDiff(loc)
+		}
+		return NoOperation, ErrNoProgress
+	}
+	return FuncEvaluation, nil
+}
+
+func (*Broyden) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
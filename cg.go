@@ -22,6 +22,17 @@ type CGVariant interface {
 	Beta(grad, gradPrev, dirPrev []float64) float64
 }
 
+// PreconditionedCGVariant is a CGVariant that also calculates the scaling
+// parameter β for the preconditioned CG method, in which the inner products
+// of gradients are weighted by the inverse M^{-1} of the preconditioner.
+type PreconditionedCGVariant interface {
+	CGVariant
+	// PreconditionedBeta returns the value of the scaling parameter of the
+	// preconditioned method, where precGrad = M^{-1} grad and
+	// precGradPrev = M^{-1} gradPrev.
+	PreconditionedBeta(grad, precGrad, gradPrev, precGradPrev, dirPrev []float64) float64
+}
+
 // CG implements the nonlinear conjugate gradient method for solving nonlinear
 // unconstrained optimization problems. It is a line search method that
 // generates the search directions d_k according to the formula
@@ -52,6 +63,12 @@ type CGVariant interface {
 // See also William Hager, Hongchao Zhang, A survey of nonlinear conjugate
 // gradient methods. Pacific Journal of Optimization, 2 (2006), pp. 35-58, and
 // references therein.
+//
+// If Preconditioner is not nil, CG implements the preconditioned method with
+//  d_{k+1} = -M^{-1}∇f_{k+1} + β_k*d_k,   d_0 = -M^{-1}∇f_0,
+// and β_k computed by PreconditionedCGVariant.PreconditionedBeta, which
+// converges much faster if M approximates the Hessian well. All the variants
+// in this package implement PreconditionedCGVariant.
 type CG struct {
 	// Linesearcher must satisfy the strong Wolfe conditions at every iteration.
 	// If Linesearcher == nil, an appropriate default is chosen.
@@ -63,6 +80,9 @@ type CG struct {
 	// method does not generate well-scaled search directions.
 	// If InitialStep is nil, an appropriate default is chosen.
 	InitialStep StepSizer
+	// Preconditioner, if not nil, is applied to the gradient. Variant must
+	// then implement PreconditionedCGVariant.
+	Preconditioner Preconditioner
 
 	// IterationRestartFactor determines the frequency of restarts based on the
 	// problem dimension. The negative gradient direction is taken whenever
@@ -92,6 +112,10 @@ type CG struct {
 	dirPrev      []float64
 	gradPrev     []float64
 	gradPrevNorm float64
+
+	precVariant  PreconditionedCGVariant
+	precGrad     []float64 // M^{-1} ∇f_{k+1}.
+	precGradPrev []float64 // M^{-1} ∇f_k.
 }
 
 func (cg *CG) Init(loc *Location) (Operation, error) {
@@ -111,6 +135,13 @@ func (cg *CG) Init(loc *Location) (Operation, error) {
 	if cg.InitialStep == nil {
 		cg.InitialStep = &FirstOrderStepSize{}
 	}
+	if cg.Preconditioner != nil {
+		v, ok := cg.Variant.(PreconditionedCGVariant)
+		if !ok {
+			panic("cg: Variant does not implement PreconditionedCGVariant")
+		}
+		cg.precVariant = v
+	}
 
 	if cg.IterationRestartFactor == 0 {
 		cg.IterationRestartFactor = iterationRestartFactor
@@ -138,8 +169,16 @@ func (cg *CG) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 	cg.restartAfter = int(math.Ceil(cg.IterationRestartFactor * float64(dim)))
 	cg.iterFromRestart = 0
 
-	// The initial direction is always the negative gradient.
-	copy(dir, loc.Gradient)
+	// The initial direction is always the negative (preconditioned) gradient.
+	if cg.Preconditioner != nil {
+		cg.Preconditioner.Init(loc)
+		cg.precGrad = resize(cg.precGrad, dim)
+		cg.precGradPrev = resize(cg.precGradPrev, dim)
+		cg.Preconditioner.Apply(cg.precGradPrev, loc.Gradient)
+		copy(dir, cg.precGradPrev)
+	} else {
+		copy(dir, loc.Gradient)
+	}
 	floats.Scale(-1, dir)
 
 	cg.dirPrev = resize(cg.dirPrev, dim)
@@ -153,7 +192,15 @@ func (cg *CG) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 }
 
 func (cg *CG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
-	copy(dir, loc.Gradient)
+	// The steepest descent direction is -∇f_{k+1}, or -M^{-1}∇f_{k+1} with a
+	// preconditioner.
+	steepest := loc.Gradient
+	if cg.Preconditioner != nil {
+		cg.Preconditioner.Update(loc)
+		cg.Preconditioner.Apply(cg.precGrad, loc.Gradient)
+		steepest = cg.precGrad
+	}
+	copy(dir, steepest)
 	floats.Scale(-1, dir)
 
 	cg.iterFromRestart++
@@ -172,7 +219,12 @@ func (cg *CG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 
 	// Compute the scaling factor β_k even when restarting, because cg.Variant
 	// may be keeping an inner state that needs to be updated at every iteration.
-	beta := cg.Variant.Beta(loc.Gradient, cg.gradPrev, cg.dirPrev)
+	var beta float64
+	if cg.Preconditioner != nil {
+		beta = cg.precVariant.PreconditionedBeta(loc.Gradient, cg.precGrad, cg.gradPrev, cg.precGradPrev, cg.dirPrev)
+	} else {
+		beta = cg.Variant.Beta(loc.Gradient, cg.gradPrev, cg.dirPrev)
+	}
 	if beta == 0 {
 		// β_k == 0 means that the steepest descent direction will be taken, so
 		// indicate that the method is in fact being restarted.
@@ -184,7 +236,7 @@ func (cg *CG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 		if floats.Dot(loc.Gradient, dir) >= 0 {
 			// Restart because the new direction is not a descent direction.
 			restart = true
-			copy(dir, loc.Gradient)
+			copy(dir, steepest)
 			floats.Scale(-1, dir)
 		}
 	}
@@ -204,6 +256,9 @@ func (cg *CG) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	copy(cg.gradPrev, loc.Gradient)
 	copy(cg.dirPrev, dir)
 	cg.gradPrevNorm = gNorm
+	if cg.Preconditioner != nil {
+		copy(cg.precGradPrev, cg.precGrad)
+	}
 	return stepSize
 }
 
@@ -235,6 +290,10 @@ func (fr *FletcherReeves) Beta(grad, _, _ []float64) (beta float64) {
 	return beta
 }
 
+func (fr *FletcherReeves) PreconditionedBeta(grad, precGrad, gradPrev, precGradPrev, _ []float64) float64 {
+	return floats.Dot(grad, precGrad) / floats.Dot(gradPrev, precGradPrev)
+}
+
 // PolakRibierePolyak implements the Polak-Ribiere-Polyak variant of the CG
 // method that computes the scaling parameter β_k according to the formula
 //  β_k = max(0, ∇f_{k+1}·y_k / |∇f_k|^2),
@@ -255,6 +314,11 @@ func (pr *PolakRibierePolyak) Beta(grad, gradPrev, _ []float64) (beta float64) {
 	return math.Max(0, beta)
 }
 
+func (pr *PolakRibierePolyak) PreconditionedBeta(grad, precGrad, gradPrev, precGradPrev, _ []float64) float64 {
+	beta := (floats.Dot(grad, precGrad) - floats.Dot(gradPrev, precGrad)) / floats.Dot(gradPrev, precGradPrev)
+	return math.Max(0, beta)
+}
+
 // HestenesStiefel implements the Hestenes-Stiefel variant of the CG method
 // that computes the scaling parameter β_k according to the formula
 //  β_k = max(0, ∇f_{k+1}·y_k / d_k·y_k),
@@ -273,6 +337,12 @@ func (hs *HestenesStiefel) Beta(grad, gradPrev, dirPrev []float64) (beta float64
 	return math.Max(0, beta)
 }
 
+func (hs *HestenesStiefel) PreconditionedBeta(grad, precGrad, gradPrev, _, dirPrev []float64) float64 {
+	floats.SubTo(hs.y, grad, gradPrev)
+	beta := floats.Dot(precGrad, hs.y) / floats.Dot(dirPrev, hs.y)
+	return math.Max(0, beta)
+}
+
 // DaiYuan implements the Dai-Yuan variant of the CG method that computes the
 // scaling parameter β_k according to the formula
 //  β_k = |∇f_{k+1}|^2 / d_k·y_k,
@@ -291,6 +361,11 @@ func (dy *DaiYuan) Beta(grad, gradPrev, dirPrev []float64) (beta float64) {
 	return norm * norm / floats.Dot(dirPrev, dy.y)
 }
 
+func (dy *DaiYuan) PreconditionedBeta(grad, precGrad, gradPrev, _, dirPrev []float64) float64 {
+	floats.SubTo(dy.y, grad, gradPrev)
+	return floats.Dot(grad, precGrad) / floats.Dot(dirPrev, dy.y)
+}
+
 // HagerZhang implements the Hager-Zhang variant of the CG method that computes the
 // scaling parameter β_k according to the formula
 //  β_k = (y_k - 2 d_k |y_k|^2/(d_k·y_k))·∇f_{k+1} / (d_k·y_k),
@@ -311,3 +386,13 @@ func (hz *HagerZhang) Beta(grad, gradPrev, dirPrev []float64) (beta float64) {
 	yNorm := floats.Norm(hz.y, 2)
 	return (gDotY - 2*gDotDir*yNorm*yNorm/dirDotY) / dirDotY
 }
+
+func (hz *HagerZhang) PreconditionedBeta(grad, precGrad, gradPrev, precGradPrev, dirPrev []float64) float64 {
+	floats.SubTo(hz.y, grad, gradPrev)
+	dirDotY := floats.Dot(dirPrev, hz.y)
+	gDotY := floats.Dot(precGrad, hz.y)
+	gDotDir := floats.Dot(grad, dirPrev)
+	// y_k·M^{-1}y_k, where M^{-1}y_k = precGrad - precGradPrev.
+	yNorm2 := floats.Dot(hz.y, precGrad) - floats.Dot(hz.y, precGradPrev)
+	return (gDotY - 2*gDotDir*yNorm2/dirDotY) / dirDotY
+}
@@ -0,0 +1,440 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultCoordinateStep          = 0.1
+	defaultCoordinateLineTolerance = 1.4901161193847656e-08 // Square root of the machine epsilon.
+)
+
+// CoordinateSelection is the rule by which CoordinateDescent chooses the block
+// of coordinates updated next.
+type CoordinateSelection int
+
+const (
+	// CyclicSelection updates the blocks in turn.
+	CyclicSelection CoordinateSelection = iota
+	// RandomizedSelection updates a block chosen uniformly at random, with
+	// replacement.
+	RandomizedSelection
+	// GaussSouthwellSelection updates the block in which the partial
+	// derivatives have the largest norm. It requires Problem.PartialGrad or
+	// Settings.Evaluator.
+	GaussSouthwellSelection
+)
+
+// coordinateStage is the stage of an iteration of CoordinateDescent.
+type coordinateStage int
+
+const (
+	coordinateLine coordinateStage = iota
+	coordinatePartial
+	coordinateBracket
+	coordinateRoot
+	coordinateDerivLine
+	coordinateMajor
+)
+
+// CoordinateDescent implements block coordinate descent for derivative-free
+// minimization. Every update minimizes the function over one block of the
+// coordinates while the others are kept fixed, so the method is efficient when
+// the coupling between the blocks is weak, for example for nearly separable
+// functions. See
+//
+//  Wright, S.J.: Coordinate descent algorithms. Math. Program. 151 (2015),
+//  3-34.
+//
+// If Problem.PartialGrad and Settings.Evaluator are nil, an update minimizes
+// the function along each of the coordinates of the block in turn by
+// bracketing the minimum and Brent's method, as Powell does along its
+// directions. Otherwise an update minimizes the function along the negative partial
+// gradient of the block by bracketing a zero of the directional derivative and
+// locating it by Brent's method, as BrentRoot does, so the line minimization
+// is exact up to the tolerance and costs only one evaluation of the function.
+// The partial derivatives are requested by PartialGradEvaluations, which are
+// counted as gradient evaluations. An update that does not decrease the
+// function is rejected.
+//
+// The derivative line minimizations assume that the function is
+// differentiable. At a kink, such as the zero of a coordinate of an L1
+// regularization term λ‖x‖₁, the directional derivative jumps and has no zero,
+// so the minimum is not located. Nonsmooth functions must use the
+// derivative-free updates, with Problem.PartialGrad and Settings.Evaluator
+// nil.
+//
+// CoordinateDescent announces a MajorIteration after every len(Blocks)
+// updates, so it should be used with Settings.FunctionConverge.
+type CoordinateDescent struct {
+	// Selection is the rule by which the blocks are chosen. The default is
+	// CyclicSelection.
+	Selection CoordinateSelection
+	// Blocks is the partition of the coordinates into blocks. Every
+	// coordinate must belong to exactly one block.
+	// If Blocks is nil, every coordinate forms its own block.
+	Blocks [][]int
+	// InitialStep is the length of the first trial step of the line
+	// minimizations. The later trial steps have the length of the previous
+	// step along the same coordinate or block.
+	// If InitialStep is 0, it is defaulted to 0.1.
+	InitialStep float64
+	// LineTolerance is the relative tolerance of the location of the minimum
+	// of the line minimizations. If LineTolerance is 0, it is defaulted to the
+	// square root of the machine epsilon.
+	LineTolerance float64
+	// Src is the source of random numbers for RandomizedSelection. If Src is
+	// nil, a source seeded from the global source of the math/rand package
+	// is used.
+	Src *rand.Rand
+
+	stage   coordinateStage
+	src     *rand.Rand
+	blocks  [][]int
+	partial bool  // The partial derivatives are requested.
+	all     []int // All the coordinates.
+
+	x []float64 // Best location.
+	f float64   // Function value at x.
+
+	updates int // Number of block updates in the iteration.
+	blk     int // Index of the current block.
+	coord   int // Index of the current coordinate within the block.
+
+	step      []float64 // Length of the trial steps along the coordinates.
+	blockStep []float64 // Length of the trial steps of the derivative lines.
+	line      brentLine
+
+	grad []float64 // Partial derivatives at x.
+	dir  []float64 // Unit direction of the derivative line within the block.
+	xt   []float64 // Step of the derivative line.
+
+	// Bracketing of the zero of the directional derivative. The directional
+	// derivative at lo is dlo < 0.
+	lo, hi   float64
+	dlo      float64
+	expanded int
+	root     brentRoot
+}
+
+func (c *CoordinateDescent) initProblem(p *Problem, settings *Settings) error {
+	c.partial = p.PartialGrad != nil || settings.Evaluator != nil
+	if c.Selection == GaussSouthwellSelection && !c.partial {
+		return errors.New("coordinatedescent: GaussSouthwellSelection requires Problem.PartialGrad or Settings.Evaluator")
+	}
+	return nil
+}
+
+func (c *CoordinateDescent) Init(loc *Location) (Operation, error) {
+	switch c.Selection {
+	case CyclicSelection, RandomizedSelection, GaussSouthwellSelection:
+	default:
+		panic("coordinatedescent: unknown Selection")
+	}
+	if c.InitialStep == 0 {
+		c.InitialStep = defaultCoordinateStep
+	}
+	if c.InitialStep < 0 {
+		panic("coordinatedescent: negative InitialStep")
+	}
+	if c.LineTolerance == 0 {
+		c.LineTolerance = defaultCoordinateLineTolerance
+	}
+	if c.LineTolerance < 0 {
+		panic("coordinatedescent: negative LineTolerance")
+	}
+
+	dim := len(loc.X)
+	c.blocks = c.Blocks
+	if c.blocks == nil {
+		c.blocks = make([][]int, dim)
+		for i := range c.blocks {
+			c.blocks[i] = []int{i}
+		}
+	}
+	checkBlocks(c.blocks, dim)
+	c.src = c.Src
+	if c.src == nil {
+		c.src = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	c.all = resizeInts(c.all, dim)
+	for i := range c.all {
+		c.all[i] = i
+	}
+	c.x = resize(c.x, dim)
+	c.grad = resize(c.grad, dim)
+	c.xt = resize(c.xt, dim)
+	c.dir = resize(c.dir, dim)
+	c.step = resize(c.step, dim)
+	for i := range c.step {
+		c.step[i] = c.InitialStep
+	}
+	c.blockStep = resize(c.blockStep, len(c.blocks))
+	for i := range c.blockStep {
+		c.blockStep[i] = c.InitialStep
+	}
+
+	copy(c.x, loc.X)
+	c.f = loc.F
+	c.updates = 0
+	return c.startBlock(loc)
+}
+
+func (c *CoordinateDescent) Iterate(loc *Location) (Operation, error) {
+	switch c.stage {
+	case coordinateMajor:
+		c.updates = 0
+		return c.startBlock(loc)
+	case coordinatePartial:
+		for _, i := range loc.Coordinates {
+			c.grad[i] = loc.PartialGradient[i]
+		}
+		if c.Selection == GaussSouthwellSelection {
+			c.southwell()
+		}
+		return c.startDerivLine(loc)
+	case coordinateBracket:
+		return c.bracket(loc, c.slope(loc))
+	case coordinateRoot:
+		c.root.fb = c.slope(loc)
+		return c.rootStep(loc)
+	case coordinateDerivLine:
+		// loc holds the function value at the minimum along the line.
+		if loc.F < c.f {
+			floats.SubTo(c.xt, loc.X, c.x)
+			c.blockStep[c.blk] = floats.Norm(c.xt, 2)
+			copy(c.x, loc.X)
+			c.f = loc.F
+		} else {
+			c.blockStep[c.blk] /= 2
+		}
+		return c.endBlock(loc)
+	}
+
+	// loc holds the function value at a trial point of a line minimization
+	// along a coordinate.
+	i := c.blocks[c.blk][c.coord]
+	t, done := c.line.iterate(loc.F)
+	if !done {
+		copy(loc.X, c.x)
+		loc.X[i] += t
+		return FuncEvaluation, nil
+	}
+	t, c.f = c.line.result()
+	c.x[i] += t
+	if t != 0 {
+		c.step[i] = math.Abs(t)
+	} else {
+		c.step[i] /= 2
+	}
+	c.coord++
+	if c.coord < len(c.blocks[c.blk]) {
+		return c.startLine(loc)
+	}
+	return c.endBlock(loc)
+}
+
+// endBlock finishes the update of a block and starts the next one, or
+// announces a MajorIteration at the end of the iteration.
+func (c *CoordinateDescent) endBlock(loc *Location) (Operation, error) {
+	c.updates++
+	if c.updates == len(c.blocks) {
+		return c.major(loc)
+	}
+	return c.startBlock(loc)
+}
+
+// startBlock chooses the next block and starts its update, or requests the
+// partial derivatives needed to choose and update it.
+func (c *CoordinateDescent) startBlock(loc *Location) (Operation, error) {
+	copy(loc.X, c.x)
+	switch {
+	case !c.partial:
+		c.choose()
+		c.coord = 0
+		return c.startLine(loc)
+	case c.Selection == GaussSouthwellSelection:
+		loc.Coordinates = c.all
+	default:
+		c.choose()
+		loc.Coordinates = c.blocks[c.blk]
+	}
+	c.stage = coordinatePartial
+	return PartialGradEvaluation, nil
+}
+
+// choose sets the index of the next block according to CyclicSelection or
+// RandomizedSelection.
+func (c *CoordinateDescent) choose() {
+	if c.Selection == RandomizedSelection {
+		c.blk = c.src.Intn(len(c.blocks))
+		return
+	}
+	c.blk = c.updates
+}
+
+// southwell sets the index of the block in which the partial derivatives at x
+// have the largest norm.
+func (c *CoordinateDescent) southwell() {
+	c.blk = 0
+	var biggest float64
+	for b, block := range c.blocks {
+		var norm float64
+		for _, i := range block {
+			norm += c.grad[i] * c.grad[i]
+		}
+		if norm > biggest {
+			biggest = norm
+			c.blk = b
+		}
+	}
+}
+
+// startLine starts the line minimization from x along the current coordinate.
+func (c *CoordinateDescent) startLine(loc *Location) (Operation, error) {
+	i := c.blocks[c.blk][c.coord]
+	abs := c.LineTolerance * math.Max(floats.Norm(c.x, math.Inf(1)), c.step[i])
+	t := c.line.init(c.f, c.step[i], c.LineTolerance, abs)
+	copy(loc.X, c.x)
+	loc.X[i] += t
+	c.stage = coordinateLine
+	return FuncEvaluation, nil
+}
+
+// startDerivLine starts the minimization of the function along the negative
+// partial gradient of the current block. Blocks in which all the partial
+// derivatives are zero are skipped.
+func (c *CoordinateDescent) startDerivLine(loc *Location) (Operation, error) {
+	block := c.blocks[c.blk]
+	dir := c.dir[:len(block)]
+	for k, i := range block {
+		dir[k] = -c.grad[i]
+	}
+	norm := floats.Norm(dir, 2)
+	if norm == 0 {
+		c.updates++
+		if c.updates == len(c.blocks) {
+			return c.major(loc)
+		}
+		return c.startBlock(loc)
+	}
+	floats.Scale(1/norm, dir)
+
+	// The directional derivative at t = 0 is -norm. Expand the steps by the
+	// golden ratio until it becomes nonnegative.
+	c.lo, c.dlo = 0, -norm
+	c.hi = c.blockStep[c.blk]
+	c.expanded = 0
+	c.stage = coordinateBracket
+	return c.requestSlope(loc, c.hi)
+}
+
+// bracket takes the directional derivative d at hi and expands the bracket,
+// or starts locating the zero of the directional derivative in [lo, hi].
+func (c *CoordinateDescent) bracket(loc *Location, d float64) (Operation, error) {
+	if d == 0 || c.expanded == maxBracketExpansions {
+		// The minimum is at hi, or the function seems to be unbounded
+		// below along the line.
+		return c.endDerivLine(loc, c.hi)
+	}
+	if d > 0 {
+		abs := c.LineTolerance * math.Max(floats.Norm(c.x, math.Inf(1)), c.hi-c.lo)
+		c.root.init(c.lo, c.hi, c.dlo, d, c.LineTolerance, abs)
+		c.stage = coordinateRoot
+		return c.rootStep(loc)
+	}
+	c.expanded++
+	c.lo, c.hi = c.hi, c.hi+goldenRatio*(c.hi-c.lo)
+	c.dlo = d
+	return c.requestSlope(loc, c.hi)
+}
+
+// rootStep requests the directional derivative at the next trial point of
+// Brent's method, or ends the line minimization at the located zero.
+func (c *CoordinateDescent) rootStep(loc *Location) (Operation, error) {
+	if c.root.converged() {
+		return c.endDerivLine(loc, c.root.b)
+	}
+	return c.requestSlope(loc, c.root.step())
+}
+
+// requestSlope requests the partial derivatives of the current block at
+// x + t*dir.
+func (c *CoordinateDescent) requestSlope(loc *Location, t float64) (Operation, error) {
+	block := c.blocks[c.blk]
+	copy(loc.X, c.x)
+	for k, i := range block {
+		loc.X[i] += t * c.dir[k]
+	}
+	loc.Coordinates = block
+	return PartialGradEvaluation, nil
+}
+
+// slope returns the directional derivative along dir from the partial
+// derivatives in loc.
+func (c *CoordinateDescent) slope(loc *Location) float64 {
+	var d float64
+	for k, i := range c.blocks[c.blk] {
+		d += c.dir[k] * loc.PartialGradient[i]
+	}
+	return d
+}
+
+// endDerivLine requests the function value at x + t*dir, the minimum along
+// the line.
+func (c *CoordinateDescent) endDerivLine(loc *Location, t float64) (Operation, error) {
+	copy(loc.X, c.x)
+	for k, i := range c.blocks[c.blk] {
+		loc.X[i] += t * c.dir[k]
+	}
+	c.stage = coordinateDerivLine
+	return FuncEvaluation, nil
+}
+
+func (c *CoordinateDescent) major(loc *Location) (Operation, error) {
+	c.stage = coordinateMajor
+	copy(loc.X, c.x)
+	loc.F = c.f
+	return MajorIteration, nil
+}
+
+func (*CoordinateDescent) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// checkBlocks panics if blocks is not a partition of the coordinates of a
+// location of dimension dim into nonempty blocks.
+func checkBlocks(blocks [][]int, dim int) {
+	seen := make([]bool, dim)
+	n := 0
+	for _, block := range blocks {
+		if len(block) == 0 {
+			panic("coordinatedescent: empty block")
+		}
+		for _, i := range block {
+			if i < 0 || i >= dim || seen[i] {
+				panic("coordinatedescent: Blocks do not partition the coordinates")
+			}
+			seen[i] = true
+			n++
+		}
+	}
+	if n != dim {
+		panic("coordinatedescent: Blocks do not partition the coordinates")
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// coupledQuadratic is the convex quadratic 1/2 x^T A x - b^T x with a
+// tridiagonal, diagonally dominant A.
+type coupledQuadratic struct {
+	diag, off float64
+	b         []float64
+}
+
+func (q coupledQuadratic) Func(x []float64) float64 {
+	var f float64
+	for i, v := range x {
+		f += 0.5*q.diag*v*v - q.b[i]*v
+		if i > 0 {
+			f += q.off * x[i-1] * v
+		}
+	}
+	return f
+}
+
+func (q coupledQuadratic) partial(i int, x []float64) float64 {
+	d := q.diag*x[i] - q.b[i]
+	if i > 0 {
+		d += q.off * x[i-1]
+	}
+	if i < len(x)-1 {
+		d += q.off * x[i+1]
+	}
+	return d
+}
+
+func (q coupledQuadratic) Grad(grad, x []float64) {
+	for i := range x {
+		grad[i] = q.partial(i, x)
+	}
+}
+
+func (q coupledQuadratic) PartialGrad(grad, x []float64, coords []int) {
+	for _, i := range coords {
+		grad[i] = q.partial(i, x)
+	}
+}
+
+func TestCoordinateDescentQuadratic(t *testing.T) {
+	q := coupledQuadratic{diag: 4, off: 1, b: []float64{1, -2, 3, 0, 2, -1}}
+	dim := len(q.b)
+	for _, blocks := range [][][]int{
+		nil,
+		{{0, 1}, {2, 3}, {4, 5}},
+		{{5, 0, 3}, {1}, {2, 4}},
+	} {
+		for _, selection := range []CoordinateSelection{CyclicSelection, RandomizedSelection, GaussSouthwellSelection} {
+			for _, derivative := range []bool{false, true} {
+				if selection == GaussSouthwellSelection && !derivative {
+					continue
+				}
+				method := &CoordinateDescent{
+					Selection: selection,
+					Blocks:    blocks,
+					Src:       rand.New(rand.NewSource(1)),
+				}
+				p := Problem{Func: q.Func}
+				if derivative {
+					p.PartialGrad = q.PartialGrad
+				}
+				settings := DefaultSettings()
+				settings.Recorder = nil
+				settings.FunctionConverge.Iterations = 20
+				settings.FunctionConverge.Absolute = 1e-14
+				result, err := Local(p, make([]float64, dim), settings, method)
+				if err != nil {
+					t.Errorf("%v, %v, %v: unexpected error: %v", blocks, selection, derivative, err)
+					continue
+				}
+				if result.Status != FunctionConvergence {
+					t.Errorf("%v, %v, %v: unexpected status %v", blocks, selection, derivative, result.Status)
+				}
+				if result.F != q.Func(result.X) {
+					t.Errorf("%v, %v, %v: function value at X not equal to F", blocks, selection, derivative)
+				}
+				grad := make([]float64, dim)
+				q.Grad(grad, result.X)
+				if norm := floats.Norm(grad, math.Inf(1)); norm > 1e-6 {
+					t.Errorf("%v, %v, %v: gradient norm %v at the minimum too large", blocks, selection, derivative, norm)
+				}
+				if derivative {
+					// Every block update costs one evaluation.
+					if limit := (result.MajorIterations + 1) * len(method.blocks); result.FuncEvaluations > limit {
+						t.Errorf("%v, %v: %v evaluations in %v iterations", blocks, selection, result.FuncEvaluations, result.MajorIterations)
+					}
+					if result.GradEvaluations == 0 {
+						t.Errorf("%v, %v: partial derivatives not counted", blocks, selection)
+					}
+				} else if result.GradEvaluations != 0 {
+					t.Errorf("%v, %v: %v gradient evaluations without PartialGrad", blocks, selection, result.GradEvaluations)
+				}
+			}
+		}
+	}
+}
+
+func TestCoordinateDescentL1(t *testing.T) {
+	// Least squares with L1 regularization, whose minimum has some zero
+	// coordinates where the function is not differentiable, so PartialGrad
+	// is nil and the derivative-free updates are used, on single coordinates
+	// and on blocks.
+	a := [][]float64{
+		{2, 0.5, 0, 0.1},
+		{0.3, 1.5, 0.2, 0},
+		{0, 0.4, 1, 0.3},
+		{0.1, 0, 0.2, 2},
+		{1, 1, 1, 1},
+	}
+	b := []float64{1, -0.2, 0.05, 2, 1}
+	const lambda = 0.5
+	r := make([]float64, len(b))
+	residuals := func(x []float64) {
+		for i, row := range a {
+			r[i] = floats.Dot(row, x) - b[i]
+		}
+	}
+	f := func(x []float64) float64 {
+		residuals(x)
+		return 0.5*floats.Dot(r, r) + lambda*floats.Norm(x, 1)
+	}
+
+	for _, test := range []struct {
+		selection CoordinateSelection
+		blocks    [][]int
+	}{
+		{selection: CyclicSelection},
+		{selection: RandomizedSelection},
+		{selection: CyclicSelection, blocks: [][]int{{0, 2}, {1, 3}}},
+	} {
+		selection := test.selection
+		settings := DefaultSettings()
+		settings.Recorder = nil
+		settings.FunctionConverge.Iterations = 20
+		settings.FunctionConverge.Absolute = 1e-14
+		method := &CoordinateDescent{Selection: selection, Blocks: test.blocks, Src: rand.New(rand.NewSource(1))}
+		result, err := Local(Problem{Func: f}, []float64{1, 1, 1, 1}, settings, method)
+		if err != nil {
+			t.Errorf("%v %v: unexpected error: %v", selection, test.blocks, err)
+			continue
+		}
+
+		// Check the optimality conditions: the gradient of the least
+		// squares term is -lambda*sign(x_j) at nonzero x_j and not larger
+		// than lambda in magnitude at zero x_j.
+		residuals(result.X)
+		var zeros int
+		for j, v := range result.X {
+			var g float64
+			for i, row := range a {
+				g += row[j] * r[i]
+			}
+			if math.Abs(v) < 1e-6 {
+				zeros++
+				if math.Abs(g) > lambda+1e-6 {
+					t.Errorf("%v %v: optimality violated at zero coordinate %v: %v", selection, test.blocks, j, g)
+				}
+			} else if math.Abs(g+math.Copysign(lambda, v)) > 1e-6 {
+				t.Errorf("%v %v: optimality violated at coordinate %v: %v", selection, test.blocks, j, g)
+			}
+		}
+		if zeros == 0 {
+			t.Errorf("%v %v: no zero coordinates in %v", selection, test.blocks, result.X)
+		}
+	}
+}
+
+func TestCoordinateDescentPartialGrad(t *testing.T) {
+	q := coupledQuadratic{diag: 4, off: 1, b: []float64{1, -2, 3, 0, 2, -1}}
+	x := make([]float64, len(q.b))
+
+	_, err := Local(Problem{Func: q.Func}, x, nil, &CoordinateDescent{Selection: GaussSouthwellSelection})
+	if err == nil {
+		t.Errorf("no error for GaussSouthwellSelection without PartialGrad")
+	}
+
+	var calls int
+	p := Problem{
+		Func: q.Func,
+		PartialGrad: func(grad, x []float64, coords []int) {
+			calls++
+			q.PartialGrad(grad, x, coords)
+		},
+	}
+	for _, selection := range []CoordinateSelection{CyclicSelection, GaussSouthwellSelection} {
+		calls = 0
+		settings := DefaultSettings()
+		settings.Recorder = nil
+		settings.GradEvaluations = 10
+		result, err := Local(p, x, settings, &CoordinateDescent{Selection: selection})
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", selection, err)
+			continue
+		}
+		if result.Status != GradientEvaluationLimit {
+			t.Errorf("%v: unexpected status %v", selection, result.Status)
+		}
+		if calls != settings.GradEvaluations || result.GradEvaluations != calls {
+			t.Errorf("%v: %v evaluations of PartialGrad, %v counted, limit %v",
+				selection, calls, result.GradEvaluations, settings.GradEvaluations)
+		}
+	}
+}
+
+func TestCoordinateDescentPanics(t *testing.T) {
+	q := coupledQuadratic{diag: 4, off: 1, b: []float64{1, 2, 3}}
+	for _, method := range []*CoordinateDescent{
+		{Blocks: [][]int{{0, 1}}},
+		{Blocks: [][]int{{0, 1}, {1, 2}}},
+		{Blocks: [][]int{{0, 1, 2}, {}}},
+		{Blocks: [][]int{{0, 1, 3}}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic for %+v", method)
+				}
+			}()
+			Local(Problem{Func: q.Func}, make([]float64, 3), nil, method)
+		}()
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	defaultDIRECTEpsilon = 1e-4
+
+	// directMaxLevel is the number of trisections of a side after which
+	// a rectangle is no longer divided. The new centers would then be
+	// indistinguishable from the old one in floating-point arithmetic.
+	directMaxLevel = 30
+)
+
+// DIRECT implements the DIviding RECTangles algorithm for deterministic,
+// derivative-free global optimization over a box as described in
+//
+//  Jones, D.R., Perttunen, C.D., Stuckman, B.E.: Lipschitzian optimization
+//  without the Lipschitz constant. J. Optim. Theory Appl. 79 (1993), 157-181.
+//
+// The box is scaled to the unit hypercube which is successively divided into
+// hyperrectangles with evaluated centers. At every major iteration the
+// potentially optimal rectangles, those that contain the minimum for some
+// value of the unknown Lipschitz constant, are trisected along their longest
+// sides. The centers of the new rectangles are evaluated concurrently by the
+// tasks of Global and the next division starts only when all of them are
+// known, so the sequence of evaluated locations does not depend on the number
+// of tasks.
+//
+// If Local is true, the locally biased variant DIRECT-L described in
+//
+//  Gablonsky, J.M., Kelley, C.T.: A locally-biased form of the DIRECT
+//  algorithm. J. Global Optim. 21 (2001), 27-37.
+//
+// is used instead. It measures the rectangles by their longest side and
+// divides at most one potentially optimal rectangle of each size, which
+// favors the refinement of good regions and works better for problems with
+// few local minima.
+//
+// DIRECT terminates with StepConvergence when no potentially optimal
+// rectangle can be divided any further.
+type DIRECT struct {
+	// Bounds is the box in which the minimum is sought. It must contain one
+	// Bound for every dimension of the problem, and DIRECT will panic if
+	// Bounds has the wrong size or if Min >= Max for any Bound.
+	Bounds []Bound
+	// Local selects the DIRECT-L variant.
+	Local bool
+	// Epsilon is the relative amount by which a potentially optimal
+	// rectangle must be able to improve on the best value found so far. It
+	// keeps the search from becoming too local.
+	// If Epsilon is zero, it will be set to 1e-4.
+	// DIRECT will panic if Epsilon is negative.
+	Epsilon float64
+
+	epsilon float64
+
+	batch     batchEvaluator
+	converged bool
+
+	rects  []directRect
+	splits []directSplit // Divisions waiting for the values at the new centers.
+	queue  []int         // New rectangles whose centers must be evaluated.
+	best   int           // Rectangle with the lowest value.
+}
+
+// directRect is a hyperrectangle in the unit hypercube.
+type directRect struct {
+	center []float64
+	level  []int // Number of trisections of each side.
+	f      float64
+}
+
+// directSplit is the division of a rectangle along its longest sides.
+type directSplit struct {
+	rect  int   // Index of the divided rectangle.
+	dims  []int // Dimensions along which the rectangle is divided.
+	lower []int // Index of the new rectangle centered at c - δ*e_i for each of dims.
+	upper []int // Index of the new rectangle centered at c + δ*e_i for each of dims.
+	w     []float64
+}
+
+func (s directSplit) Len() int {
+	return len(s.dims)
+}
+
+func (s directSplit) Less(i, j int) bool {
+	return s.w[i] < s.w[j]
+}
+
+func (s directSplit) Swap(i, j int) {
+	s.dims[i], s.dims[j] = s.dims[j], s.dims[i]
+	s.lower[i], s.lower[j] = s.lower[j], s.lower[i]
+	s.upper[i], s.upper[j] = s.upper[j], s.upper[i]
+	s.w[i], s.w[j] = s.w[j], s.w[i]
+}
+
+// directGroup is the set of rectangles of the same size with the lowest value.
+type directGroup struct {
+	size  float64
+	level int // Smallest number of trisections of a side.
+	f     float64
+	rects []int
+}
+
+type directGroupSorter []directGroup
+
+func (g directGroupSorter) Len() int {
+	return len(g)
+}
+
+func (g directGroupSorter) Less(i, j int) bool {
+	return g[i].size < g[j].size
+}
+
+func (g directGroupSorter) Swap(i, j int) {
+	g[i], g[j] = g[j], g[i]
+}
+
+func (d *DIRECT) Needs() struct{ Gradient, Hessian bool } {
+	return struct{ Gradient, Hessian bool }{false, false}
+}
+
+func (d *DIRECT) InitGlobal(dim, tasks int) int {
+	if len(d.Bounds) != dim {
+		panic("direct: bounds size mismatch")
+	}
+	for _, b := range d.Bounds {
+		if !(b.Min < b.Max) {
+			panic("direct: invalid bound")
+		}
+	}
+	if d.Epsilon < 0 {
+		panic("direct: negative Epsilon")
+	}
+	d.epsilon = d.Epsilon
+	if d.epsilon == 0 {
+		d.epsilon = defaultDIRECTEpsilon
+	}
+	if tasks < 1 {
+		tasks = 1
+	}
+
+	d.converged = false
+
+	// Start with the whole hypercube and evaluate its center.
+	center := make([]float64, dim)
+	for i := range center {
+		center[i] = 0.5
+	}
+	d.rects = append(d.rects[:0], directRect{
+		center: center,
+		level:  make([]int, dim),
+		f:      math.Inf(1),
+	})
+	d.splits = d.splits[:0]
+	d.best = 0
+	d.batch.init(tasks, []int{0})
+	return tasks
+}
+
+func (d *DIRECT) IterateGlobal(task int, loc *Location) (Operation, error) {
+	return d.batch.iterate(d, task, loc), nil
+}
+
+// Status returns StepConvergence once the potentially optimal rectangles
+// cannot be divided any further.
+func (d *DIRECT) Status() (Status, error) {
+	d.batch.mux.Lock()
+	defer d.batch.mux.Unlock()
+	if d.converged {
+		return StepConvergence, nil
+	}
+	return NotTerminated, nil
+}
+
+func (d *DIRECT) Lockstep() {
+	d.batch.lockstep = true
+}
+
+func (d *DIRECT) Done() {
+	d.batch.finish()
+}
+
+// location stores the center of the rectangle r scaled to Bounds in x.
+func (d *DIRECT) location(r int, x []float64) {
+	for i, c := range d.rects[r].center {
+		b := d.Bounds[i]
+		x[i] = b.Min + c*(b.Max-b.Min)
+	}
+}
+
+func (d *DIRECT) evaluated(r int, loc *Location) {
+	f := loc.F
+	if math.IsNaN(f) {
+		f = math.Inf(1)
+	}
+	d.rects[r].f = f
+}
+
+// nextBatch finishes the divisions of the current iteration and returns the
+// centers of the rectangles created by the next one.
+func (d *DIRECT) nextBatch(loc *Location) (ids []int, major bool) {
+	d.divide()
+	d.selectRects()
+	d.location(d.best, loc.X)
+	loc.F = d.rects[d.best].f
+	return d.queue, true
+}
+
+// divide completes the pending divisions now that the centers of the new
+// rectangles have been evaluated, and updates the best rectangle.
+func (d *DIRECT) divide() {
+	for _, s := range d.splits {
+		// Trisect along the dimensions in the order of increasing
+		// w_i = min(f(c - δ*e_i), f(c + δ*e_i)) so that the best new centers
+		// end up in the largest rectangles.
+		for i := range s.dims {
+			s.w[i] = math.Min(d.rects[s.lower[i]].f, d.rects[s.upper[i]].f)
+		}
+		sort.Stable(s)
+		level := d.rects[s.rect].level
+		for i, dim := range s.dims {
+			level[dim]++
+			copy(d.rects[s.lower[i]].level, level)
+			copy(d.rects[s.upper[i]].level, level)
+		}
+	}
+	d.splits = d.splits[:0]
+
+	for i := range d.rects {
+		if d.rects[i].f < d.rects[d.best].f {
+			d.best = i
+		}
+	}
+}
+
+// selectRects finds the potentially optimal rectangles, and queues for
+// evaluation the centers of the new rectangles that result from their
+// division.
+func (d *DIRECT) selectRects() {
+	dim := len(d.Bounds)
+	d.queue = d.queue[:0]
+
+	// Find the rectangles with the lowest value for every size. In DIRECT the
+	// size is the distance from the center to the vertices, in DIRECT-L it is
+	// half of the longest side. Since only the longest sides are divided, the
+	// sides of a rectangle are trisected either k or k+1 times, and the size
+	// is determined by k and the number of sides trisected k+1 times.
+	index := make(map[[2]int]int)
+	var groups []directGroup
+	for i, r := range d.rects {
+		k, p := directLevels(r.level)
+		var key [2]int
+		var size float64
+		if d.Local {
+			key = [2]int{k, 0}
+			size = 0.5 * math.Pow(3, -float64(k))
+		} else {
+			key = [2]int{k, p}
+			size = 0.5 * math.Pow(3, -float64(k)) * math.Sqrt(float64(dim-p)+float64(p)/9)
+		}
+		j, ok := index[key]
+		if !ok {
+			index[key] = len(groups)
+			groups = append(groups, directGroup{size: size, level: k, f: r.f, rects: []int{i}})
+			continue
+		}
+		g := &groups[j]
+		switch {
+		case r.f < g.f:
+			g.f = r.f
+			g.rects = append(g.rects[:0], i)
+		case r.f == g.f && !d.Local:
+			// DIRECT divides all the rectangles with the lowest value,
+			// DIRECT-L only one of them.
+			g.rects = append(g.rects, i)
+		}
+	}
+	sort.Sort(directGroupSorter(groups))
+
+	// A rectangle j is potentially optimal if there is K > 0 such that
+	//  f_j - K*size_j <= f_i - K*size_i  for all i,
+	//  f_j - K*size_j <= f_min - ε*|f_min|.
+	// The first condition holds if K lies between the slopes to the smaller
+	// and to the larger rectangles, and the second condition is then easiest
+	// to satisfy with the largest such K.
+	fMin := d.rects[d.best].f
+	for j, g := range groups {
+		if math.IsInf(g.f, 1) || g.level >= directMaxLevel {
+			continue
+		}
+		kLow := 0.0
+		for _, h := range groups[:j] {
+			kLow = math.Max(kLow, (g.f-h.f)/(g.size-h.size))
+		}
+		kHigh := math.Inf(1)
+		for _, h := range groups[j+1:] {
+			kHigh = math.Min(kHigh, (h.f-g.f)/(h.size-g.size))
+		}
+		if kHigh <= 0 || kLow > kHigh {
+			continue
+		}
+		if !math.IsInf(kHigh, 1) && g.f-kHigh*g.size > fMin-d.epsilon*math.Abs(fMin) {
+			continue
+		}
+		for _, r := range g.rects {
+			d.split(r)
+		}
+	}
+	if len(d.queue) == 0 {
+		d.converged = true
+	}
+}
+
+// split creates the new rectangles centered at c ± δ*e_i along the longest
+// sides of the rectangle r, and queues their centers for evaluation.
+func (d *DIRECT) split(r int) {
+	k, _ := directLevels(d.rects[r].level)
+	delta := math.Pow(3, -float64(k+1))
+	var s directSplit
+	s.rect = r
+	for i, l := range d.rects[r].level {
+		if l != k {
+			continue
+		}
+		s.dims = append(s.dims, i)
+		for _, sign := range []float64{-1, 1} {
+			c := make([]float64, len(d.rects[r].center))
+			copy(c, d.rects[r].center)
+			c[i] += sign * delta
+			if sign < 0 {
+				s.lower = append(s.lower, len(d.rects))
+			} else {
+				s.upper = append(s.upper, len(d.rects))
+			}
+			d.queue = append(d.queue, len(d.rects))
+			d.rects = append(d.rects, directRect{
+				center: c,
+				level:  make([]int, len(c)),
+				f:      math.Inf(1),
+			})
+		}
+	}
+	s.w = make([]float64, len(s.dims))
+	d.splits = append(d.splits, s)
+}
+
+// directLevels returns the smallest number of trisections of a side of
+// a rectangle and the number of sides trisected once more.
+func directLevels(level []int) (k, p int) {
+	k = level[0]
+	for _, l := range level[1:] {
+		if l < k {
+			k = l
+		}
+	}
+	for _, l := range level {
+		if l != k {
+			p++
+		}
+	}
+	return k, p
+}
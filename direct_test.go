@@ -0,0 +1,105 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+// sixHumpCamel is a two-dimensional function with six local minima, two of
+// which are global with the value of -1.0316284534898774.
+func sixHumpCamel(x []float64) float64 {
+	x2 := x[0] * x[0]
+	return (4-2.1*x2+x2*x2/3)*x2 + x[0]*x[1] + (-4+4*x[1]*x[1])*x[1]*x[1]
+}
+
+func TestDIRECT(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		f      func([]float64) float64
+		bounds []Bound
+		fOpt   float64
+		tol    float64
+	}{
+		{
+			name:   "SixHumpCamel",
+			f:      sixHumpCamel,
+			bounds: []Bound{{-3, 3}, {-2, 2}},
+			fOpt:   -1.0316284534898774,
+			tol:    1e-4,
+		},
+		{
+			name:   "Beale",
+			f:      functions.Beale{}.Func,
+			bounds: []Bound{{-4.5, 4.5}, {-4.5, 4.5}},
+			tol:    1e-3,
+		},
+		{
+			name:   "ExtendedRosenbrock",
+			f:      functions.ExtendedRosenbrock{}.Func,
+			bounds: []Bound{{-5, 5}, {-5, 5}, {-5, 5}},
+			tol:    1e-2,
+		},
+	} {
+		for _, local := range []bool{false, true} {
+			var results []*Result
+			for _, concurrent := range []int{1, 4} {
+				settings := DefaultSettingsGlobal()
+				settings.FunctionConverge = nil
+				settings.MajorIterations = 200
+				settings.Concurrent = concurrent
+				method := &DIRECT{
+					Bounds: test.bounds,
+					Local:  local,
+				}
+				result, err := Global(Problem{Func: test.f}, len(test.bounds), settings, method)
+				if err != nil {
+					t.Errorf("%v, Local=%v, Concurrent=%v: unexpected error: %v", test.name, local, concurrent, err)
+					continue
+				}
+				if result.Status != IterationLimit {
+					t.Errorf("%v, Local=%v, Concurrent=%v: unexpected status %v", test.name, local, concurrent, result.Status)
+				}
+				if math.Abs(result.F-test.fOpt) > test.tol {
+					t.Errorf("%v, Local=%v, Concurrent=%v: minimum not found, want %v, got %v", test.name, local, concurrent, test.fOpt, result.F)
+				}
+				if result.F != test.f(result.X) {
+					t.Errorf("%v, Local=%v, Concurrent=%v: function value at X not equal to F", test.name, local, concurrent)
+				}
+				results = append(results, result)
+			}
+			if len(results) == 2 {
+				// The evaluated locations must not depend on the number of
+				// concurrent tasks.
+				if results[0].F != results[1].F || !floats.Equal(results[0].X, results[1].X) {
+					t.Errorf("%v, Local=%v: different result with concurrent evaluations", test.name, local)
+				}
+			}
+		}
+	}
+}
+
+func TestDIRECTFuncEvaluations(t *testing.T) {
+	// A limit on evaluations must terminate the run even when tasks are
+	// waiting for the current iteration to complete.
+	for _, concurrent := range []int{1, 3, 8} {
+		settings := DefaultSettingsGlobal()
+		settings.FunctionConverge = nil
+		settings.FuncEvaluations = 100
+		settings.Concurrent = concurrent
+		method := &DIRECT{Bounds: []Bound{{-3, 3}, {-2, 2}}}
+		result, err := Global(Problem{Func: sixHumpCamel}, 2, settings, method)
+		if err != nil {
+			t.Fatalf("Concurrent=%v: unexpected error: %v", concurrent, err)
+		}
+		if result.Status != FunctionEvaluationLimit {
+			t.Errorf("Concurrent=%v: unexpected status %v", concurrent, result.Status)
+		}
+	}
+}
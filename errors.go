@@ -31,6 +31,21 @@ var (
 	// ErrLinesearcherBound signifies that a Linesearcher reached a step that
 	// lies out of allowed bounds.
 	ErrLinesearcherBound = errors.New("linesearch: step out of bounds")
+
+	// ErrNoTrustRegionProgress signifies that a trust-region method cannot
+	// make further progress because the trust region has become so small that
+	// the trial location is indistinguishable from the current location due
+	// to floating-point arithmetic.
+	ErrNoTrustRegionProgress = errors.New("trustregion: no change in location after trust-region step")
+
+	// ErrNoBracket signifies that Bracket has not found a bracket of a
+	// minimum because the function keeps decreasing along the search
+	// direction, so it may be unbounded below.
+	ErrNoBracket = errors.New("optimize: no bracket of a minimum found")
+
+	// ErrNoRootBracket signifies that the function values at the ends of the
+	// interval given to a root-finding method do not have opposite signs.
+	ErrNoRootBracket = errors.New("optimize: function values at the interval ends have the same sign")
 )
 
 // ErrFunc is returned when an initial function value is invalid. The error
@@ -0,0 +1,103 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// FiniteSumProblem describes a problem whose objective function is a finite
+// sum of Components component functions,
+//  f(x) = 1/n * Σ_i f_i(x),
+// scaled by the number of components n, for example a regularized
+// regression loss with a component for every data sample. The variance-reduced
+// methods SVRG and SAGA evaluate the gradients of single components at most of
+// their iterations.
+type FiniteSumProblem struct {
+	// Func evaluates the component function f_i at x. Func must not modify x.
+	Func func(x []float64, i int) float64
+
+	// Grad evaluates the gradient of the component function f_i at x and
+	// stores it in grad. Grad must not modify x.
+	Grad func(grad, x []float64, i int)
+
+	// Components is the number of component functions. It must be positive.
+	Components int
+
+	// Status reports the status of the objective function being optimized
+	// and any error, as for Problem.
+	Status func() (Status, error)
+}
+
+// FiniteSum returns the Problem whose objective function and gradient are the
+// full sum f of p and its gradient. Every evaluation of Func and Grad of the
+// returned Problem evaluates all the components of p. Its ComponentGrad and
+// Components are the gradients of the components and their number, which are
+// evaluated by SVRG and SAGA at most of their iterations.
+func FiniteSum(p FiniteSumProblem) Problem {
+	if p.Func == nil {
+		panic("optimize: objective function is undefined")
+	}
+	if p.Components < 1 {
+		panic("optimize: number of components not positive")
+	}
+	n := float64(p.Components)
+	sp := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i := 0; i < p.Components; i++ {
+				f += p.Func(x, i)
+			}
+			return f / n
+		},
+		Status:     p.Status,
+		Components: p.Components,
+	}
+	if p.Grad != nil {
+		sp.ComponentGrad = p.Grad
+		sp.Grad = func(grad, x []float64) {
+			g := make([]float64, len(grad))
+			for j := range grad {
+				grad[j] = 0
+			}
+			for i := 0; i < p.Components; i++ {
+				p.Grad(g, x, i)
+				for j, v := range g {
+					grad[j] += v
+				}
+			}
+			for j := range grad {
+				grad[j] /= n
+			}
+		}
+	}
+	return sp
+}
+
+// finiteSumComponents returns the number of components of p, or an error if p
+// does not provide the gradients of the components of a finite sum. The
+// gradients are not checked if settings.Evaluator evaluates them.
+func finiteSumComponents(p *Problem, settings *Settings) (int, error) {
+	if p.ComponentGrad == nil && settings.Evaluator == nil {
+		return 0, errors.New("optimize: problem does not provide needed ComponentGrad function")
+	}
+	if p.Components < 1 {
+		return 0, errors.New("optimize: number of components not positive")
+	}
+	return p.Components, nil
+}
+
+// checkFiniteSum checks the settings of a variance-reduced method and returns
+// its source of random numbers.
+func checkFiniteSum(stepSize float64, src *rand.Rand) *rand.Rand {
+	if !(stepSize > 0) {
+		panic("optimize: step size not positive")
+	}
+	if src == nil {
+		src = rand.New(rand.NewSource(rand.Int63()))
+	}
+	return src
+}
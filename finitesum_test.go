@@ -0,0 +1,132 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// ridgeRegression returns the FiniteSumProblem of a ridge regression with
+// a component for each of the random samples, and the largest Lipschitz
+// constant of the component gradients.
+func ridgeRegression(dim, samples int, lambda float64, src *rand.Rand) (FiniteSumProblem, float64) {
+	a := make([][]float64, samples)
+	b := make([]float64, samples)
+	var lipschitz float64
+	for i := range a {
+		a[i] = make([]float64, dim)
+		for j := range a[i] {
+			a[i][j] = src.NormFloat64()
+		}
+		b[i] = src.NormFloat64()
+		if l := floats.Dot(a[i], a[i]) + lambda; l > lipschitz {
+			lipschitz = l
+		}
+	}
+	return FiniteSumProblem{
+		Func: func(x []float64, i int) float64 {
+			r := floats.Dot(a[i], x) - b[i]
+			return 0.5*r*r + 0.5*lambda*floats.Dot(x, x)
+		},
+		Grad: func(grad, x []float64, i int) {
+			r := floats.Dot(a[i], x) - b[i]
+			for j := range grad {
+				grad[j] = r*a[i][j] + lambda*x[j]
+			}
+		},
+		Components: samples,
+	}, lipschitz
+}
+
+func TestVarianceReduced(t *testing.T) {
+	const dim = 5
+	p, lipschitz := ridgeRegression(dim, 200, 0.1, rand.New(rand.NewSource(1)))
+	want, err := Local(FiniteSum(p), make([]float64, dim), nil, &BFGS{})
+	if err != nil {
+		t.Fatalf("unexpected error for BFGS: %v", err)
+	}
+
+	for _, test := range []struct {
+		name   string
+		method Method
+	}{
+		{"SVRG", &SVRG{StepSize: 1 / (10 * lipschitz), Src: rand.New(rand.NewSource(1))}},
+		{"SAGA", &SAGA{StepSize: 1 / (3 * lipschitz), Src: rand.New(rand.NewSource(1))}},
+	} {
+		settings := DefaultSettings()
+		settings.GradientThreshold = 1e-8
+		settings.FunctionConverge = nil
+		settings.MajorIterations = 100
+		result, err := Local(FiniteSum(p), make([]float64, dim), settings, test.method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		// Linear convergence reaches the threshold after a few dozen full
+		// gradients.
+		if result.Status != GradientThreshold {
+			t.Errorf("%s: unexpected status %v after %d major iterations", test.name, result.Status, result.MajorIterations)
+		}
+		if !floats.EqualApprox(result.X, want.X, 1e-5) {
+			t.Errorf("%s: unexpected minimum: got %v, want %v", test.name, result.X, want.X)
+		}
+		// The component gradients are counted as gradient evaluations.
+		if result.GradEvaluations <= result.MajorIterations+1 {
+			t.Errorf("%s: component gradients not counted, %d gradient evaluations", test.name, result.GradEvaluations)
+		}
+	}
+}
+
+func TestVarianceReducedLimits(t *testing.T) {
+	const dim = 5
+	var calls int
+	p, lipschitz := ridgeRegression(dim, 200, 0.1, rand.New(rand.NewSource(1)))
+	grad := p.Grad
+	p.Grad = func(g, x []float64, i int) {
+		calls++
+		grad(g, x, i)
+	}
+	for _, test := range []struct {
+		name   string
+		method Method
+	}{
+		{"SVRG", &SVRG{StepSize: 1 / (10 * lipschitz), Src: rand.New(rand.NewSource(1))}},
+		{"SAGA", &SAGA{StepSize: 1 / (3 * lipschitz), Src: rand.New(rand.NewSource(1))}},
+	} {
+		calls = 0
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		settings.GradEvaluations = 50
+		result, err := Local(FiniteSum(p), make([]float64, dim), settings, test.method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != GradientEvaluationLimit {
+			t.Errorf("%s: unexpected status %v", test.name, result.Status)
+		}
+		// The initial full gradient evaluates all the components, and
+		// every other gradient evaluation before the limit is reached
+		// evaluates a single component.
+		if result.GradEvaluations != settings.GradEvaluations || calls != p.Components+settings.GradEvaluations-1 {
+			t.Errorf("%s: %d gradient evaluations with %d component gradients", test.name, result.GradEvaluations, calls)
+		}
+	}
+}
+
+func TestVarianceReducedProblem(t *testing.T) {
+	p, _ := ridgeRegression(2, 10, 0.1, rand.New(rand.NewSource(1)))
+	sum := FiniteSum(p)
+	for _, method := range []Method{&SVRG{StepSize: 0.01}, &SAGA{StepSize: 0.01}} {
+		// A Problem that is not a finite sum cannot be minimized.
+		_, err := Local(Problem{Func: sum.Func, Grad: sum.Grad}, []float64{1, 1}, nil, method)
+		if err == nil {
+			t.Errorf("%T: no error for a problem without components", method)
+		}
+	}
+}
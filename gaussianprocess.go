@@ -0,0 +1,198 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// Bounds on the logarithms of the hyperparameters of a Gaussian process.
+	minLogHyper = -7
+	maxLogHyper = 7
+	minLogNoise = -20
+	maxLogNoise = 0
+
+	// gpJitter is added to the diagonal of the covariance matrix to keep it
+	// numerically positive definite.
+	gpJitter = 1e-10
+)
+
+// Kernel is a covariance function of a Gaussian process. A Kernel describes
+// the correlation between the values of the objective function at two
+// locations and must have unit variance, that is k(x, x) = 1. The signal
+// and noise variances are handled separately by the user of the Kernel.
+type Kernel interface {
+	// NumHyper returns the number of hyperparameters of the Kernel for
+	// locations of the given dimension.
+	NumHyper(dim int) int
+	// Cov returns the covariance between the values at x and y. The
+	// hyperparameters are passed in hyper as logarithms, and are kept
+	// within [-7, 7] when they are fitted.
+	Cov(hyper, x, y []float64) float64
+}
+
+// SquaredExponential is the squared exponential kernel
+//  k(x, y) = exp(-r²/2),  r² = Σ_i (x_i - y_i)² / ℓ_i²
+// with a separate length scale ℓ_i for each dimension. The hyperparameters are
+// log(ℓ_i). The Gaussian process with a SquaredExponential kernel is
+// infinitely differentiable, which may be too smooth for many objective
+// functions.
+type SquaredExponential struct{}
+
+func (SquaredExponential) NumHyper(dim int) int {
+	return dim
+}
+
+func (SquaredExponential) Cov(hyper, x, y []float64) float64 {
+	return math.Exp(-scaledDistance2(hyper, x, y) / 2)
+}
+
+// Matern52 is the Matérn kernel with smoothness ν = 5/2
+//  k(x, y) = (1 + √5 r + 5r²/3) exp(-√5 r),  r² = Σ_i (x_i - y_i)² / ℓ_i²
+// with a separate length scale ℓ_i for each dimension. The hyperparameters are
+// log(ℓ_i). The Gaussian process with a Matern52 kernel is twice
+// differentiable.
+type Matern52 struct{}
+
+func (Matern52) NumHyper(dim int) int {
+	return dim
+}
+
+func (Matern52) Cov(hyper, x, y []float64) float64 {
+	r := math.Sqrt(5 * scaledDistance2(hyper, x, y))
+	return (1 + r + r*r/3) * math.Exp(-r)
+}
+
+// scaledDistance2 returns the squared distance between x and y where each
+// dimension is scaled by the length scale exp(logScale[i]).
+func scaledDistance2(logScale, x, y []float64) float64 {
+	var r2 float64
+	for i, v := range x {
+		d := (v - y[i]) / math.Exp(logScale[i])
+		r2 += d * d
+	}
+	return r2
+}
+
+// gaussianProcess is a Gaussian process regression model with zero mean.
+type gaussianProcess struct {
+	kernel Kernel
+	// hyper contains the logarithms of the kernel hyperparameters, followed
+	// by the logarithms of the signal and noise variances.
+	hyper []float64
+
+	x [][]float64
+	y []float64
+
+	chol  mat64.Cholesky
+	alpha mat64.Vector // K^-1 y
+	kx    []float64
+}
+
+// signal returns the signal variance.
+func (gp *gaussianProcess) signal() float64 {
+	return math.Exp(gp.hyper[len(gp.hyper)-2])
+}
+
+// noise returns the noise variance.
+func (gp *gaussianProcess) noise() float64 {
+	return math.Exp(gp.hyper[len(gp.hyper)-1]) + gpJitter
+}
+
+// clampHyper moves the hyperparameters into their allowed range.
+func clampHyper(hyper []float64) {
+	n := len(hyper)
+	for i := range hyper[:n-1] {
+		hyper[i] = math.Max(minLogHyper, math.Min(hyper[i], maxLogHyper))
+	}
+	hyper[n-1] = math.Max(minLogNoise, math.Min(hyper[n-1], maxLogNoise))
+}
+
+// factorize computes the Cholesky factorization of the covariance matrix for
+// the current data and hyperparameters. It returns false if the matrix is
+// not positive definite.
+func (gp *gaussianProcess) factorize() bool {
+	n := len(gp.x)
+	kHyper := gp.hyper[:len(gp.hyper)-2]
+	signal := gp.signal()
+	k := mat64.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		k.SetSym(i, i, signal+gp.noise())
+		for j := i + 1; j < n; j++ {
+			k.SetSym(i, j, signal*gp.kernel.Cov(kHyper, gp.x[i], gp.x[j]))
+		}
+	}
+	if !gp.chol.Factorize(k) {
+		return false
+	}
+	gp.alpha.Reset()
+	if err := gp.alpha.SolveCholeskyVec(&gp.chol, mat64.NewVector(n, gp.y)); err != nil {
+		return false
+	}
+	return true
+}
+
+// negLogLikelihood returns the negative logarithm of the marginal likelihood
+// of the data for the given hyperparameters, omitting the constant term. It
+// leaves the model factorized with the given hyperparameters.
+func (gp *gaussianProcess) negLogLikelihood(hyper []float64) float64 {
+	copy(gp.hyper, hyper)
+	clampHyper(gp.hyper)
+	if !gp.factorize() {
+		return math.Inf(1)
+	}
+	y := mat64.NewVector(len(gp.y), gp.y)
+	return 0.5*mat64.Dot(y, &gp.alpha) + 0.5*gp.chol.LogDet()
+}
+
+// fit fits the hyperparameters to the data by maximizing the marginal
+// likelihood with Local. The optimization is started from the current
+// hyperparameters and from each of the initial guesses in starts, and the
+// best result is kept. The model is left factorized with the fitted
+// hyperparameters. fit returns false if no positive definite covariance
+// matrix has been found.
+func (gp *gaussianProcess) fit(starts ...[]float64) bool {
+	best := make([]float64, len(gp.hyper))
+	copy(best, gp.hyper)
+	bestF := gp.negLogLikelihood(best)
+	for _, init := range append([][]float64{best}, starts...) {
+		x := make([]float64, len(init))
+		copy(x, init)
+		settings := DefaultSettings()
+		settings.FunctionConverge.Absolute = 1e-6
+		settings.FuncEvaluations = 200 * len(x)
+		result, err := Local(Problem{Func: gp.negLogLikelihood}, x, settings, &NelderMead{SimplexSize: 0.5})
+		if err == nil && result.F < bestF {
+			bestF = result.F
+			copy(best, result.X)
+		}
+	}
+	copy(gp.hyper, best)
+	clampHyper(gp.hyper)
+	return gp.factorize()
+}
+
+// predict returns the mean and the standard deviation of the model at x.
+func (gp *gaussianProcess) predict(x []float64) (mean, std float64) {
+	n := len(gp.x)
+	kHyper := gp.hyper[:len(gp.hyper)-2]
+	signal := gp.signal()
+	gp.kx = resize(gp.kx, n)
+	for i, xi := range gp.x {
+		gp.kx[i] = signal * gp.kernel.Cov(kHyper, x, xi)
+	}
+	kx := mat64.NewVector(n, gp.kx)
+	mean = mat64.Dot(kx, &gp.alpha)
+
+	var v mat64.Vector
+	if err := v.SolveCholeskyVec(&gp.chol, kx); err != nil {
+		return mean, 0
+	}
+	variance := signal - mat64.Dot(kx, &v)
+	return mean, math.Sqrt(math.Max(variance, 0))
+}
@@ -0,0 +1,705 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/stat/distmv"
+)
+
+const (
+	defaultCrossoverRate  = 0.9
+	defaultTournamentSize = 2
+	defaultSBXEta         = 15
+	defaultMutationEta    = 20
+
+	minPopulationSize = 20
+)
+
+// Encoding is the type of the value of a variable, or gene, in
+// GeneticAlgorithm.
+type Encoding int
+
+const (
+	// RealEncoding is a real-valued variable within its Bound.
+	RealEncoding Encoding = iota
+	// IntegerEncoding is an integer-valued variable within its Bound.
+	IntegerEncoding
+	// BinaryEncoding is a variable that is either 0 or 1. Its Bound is
+	// ignored.
+	BinaryEncoding
+)
+
+// Selection chooses the parents of the individuals of a new generation in
+// GeneticAlgorithm.
+type Selection interface {
+	// Select returns the index of a parent chosen among the individuals of
+	// the current generation with the function values in f. Lower values are
+	// better and no value of f is NaN.
+	Select(f []float64, src *rand.Rand) int
+}
+
+// Crossover recombines the genes of two parents in GeneticAlgorithm.
+type Crossover interface {
+	// Crossover replaces the parents a and b in place with two children.
+	// The values of the genes are within bounds and their types are given by
+	// enc. The bounds of BinaryEncoding genes are {0, 1}. The children may
+	// violate the bounds and the encodings, GeneticAlgorithm repairs them
+	// afterwards.
+	Crossover(a, b []float64, bounds []Bound, enc []Encoding, src *rand.Rand)
+}
+
+// Mutation randomly modifies the genes of an individual in GeneticAlgorithm.
+type Mutation interface {
+	// Mutate modifies x in place. The arguments bounds and enc are as for
+	// Crossover.
+	Mutate(x []float64, bounds []Bound, enc []Encoding, src *rand.Rand)
+}
+
+// TournamentSelection selects the best of Size individuals chosen uniformly at
+// random with replacement. Larger values of Size increase the selection
+// pressure.
+// If Size is zero, 2 is used instead.
+type TournamentSelection struct {
+	Size int
+}
+
+func (t TournamentSelection) Select(f []float64, src *rand.Rand) int {
+	size := t.Size
+	if size == 0 {
+		size = defaultTournamentSize
+	}
+	if size < 0 {
+		panic("ga: negative tournament size")
+	}
+	best := src.Intn(len(f))
+	for i := 1; i < size; i++ {
+		j := src.Intn(len(f))
+		if f[j] < f[best] {
+			best = j
+		}
+	}
+	return best
+}
+
+// RouletteSelection selects an individual with a probability proportional to
+// the difference between the largest finite function value of the generation
+// and its own function value. If all the differences are zero, or their sum is
+// infinite, the individual is chosen uniformly at random.
+type RouletteSelection struct{}
+
+func (RouletteSelection) Select(f []float64, src *rand.Rand) int {
+	worst := math.Inf(-1)
+	for _, v := range f {
+		if !math.IsInf(v, 0) {
+			worst = math.Max(worst, v)
+		}
+	}
+	var sum float64
+	for _, v := range f {
+		if v <= worst {
+			sum += worst - v
+		}
+	}
+	if !(sum > 0) || math.IsInf(sum, 1) {
+		return src.Intn(len(f))
+	}
+	r := src.Float64() * sum
+	for i, v := range f {
+		if v > worst {
+			continue
+		}
+		r -= worst - v
+		if r < 0 {
+			return i
+		}
+	}
+	// Rounding may leave r slightly positive, so return the last individual
+	// with a positive weight.
+	for i := len(f) - 1; i > 0; i-- {
+		if f[i] < worst {
+			return i
+		}
+	}
+	return 0
+}
+
+// SimulatedBinaryCrossover is the bounded simulated binary crossover (SBX) of
+//
+//  Deb, K., Agrawal, R.B.: Simulated binary crossover for continuous search
+//  space. Complex Systems 9 (1995), 115-148.
+//
+// Each real or integer gene is recombined with probability 1/2 such that the
+// children are spread around the parents like the children of a one-point
+// crossover of binary strings. Larger values of Eta create children closer to
+// the parents. Binary genes are swapped with probability 1/2.
+// If Eta is zero, 15 is used instead.
+type SimulatedBinaryCrossover struct {
+	Eta float64
+}
+
+func (s SimulatedBinaryCrossover) Crossover(a, b []float64, bounds []Bound, enc []Encoding, src *rand.Rand) {
+	eta := s.Eta
+	if eta == 0 {
+		eta = defaultSBXEta
+	}
+	if eta < 0 {
+		panic("ga: negative SBX Eta")
+	}
+	for i := range a {
+		if src.Float64() >= 0.5 {
+			continue
+		}
+		if enc[i] == BinaryEncoding {
+			a[i], b[i] = b[i], a[i]
+			continue
+		}
+		y1, y2 := math.Min(a[i], b[i]), math.Max(a[i], b[i])
+		if y2-y1 < 1e-14 {
+			continue
+		}
+		lo, hi := bounds[i].Min, bounds[i].Max
+		u := src.Float64()
+		c1 := 0.5 * (y1 + y2 - sbxSpread(u, 1+2*(y1-lo)/(y2-y1), eta)*(y2-y1))
+		c2 := 0.5 * (y1 + y2 + sbxSpread(u, 1+2*(hi-y2)/(y2-y1), eta)*(y2-y1))
+		c1 = math.Max(lo, math.Min(c1, hi))
+		c2 = math.Max(lo, math.Min(c2, hi))
+		if src.Float64() < 0.5 {
+			c1, c2 = c2, c1
+		}
+		a[i], b[i] = c1, c2
+	}
+}
+
+// sbxSpread returns the spread factor of SBX for the uniform random number u
+// such that the child stays within the bound at the distance given by beta.
+func sbxSpread(u, beta, eta float64) float64 {
+	alpha := 2 - math.Pow(beta, -(eta+1))
+	if u <= 1/alpha {
+		return math.Pow(u*alpha, 1/(eta+1))
+	}
+	return math.Pow(1/(2-u*alpha), 1/(eta+1))
+}
+
+// UniformCrossover swaps each gene of the parents with probability 1/2.
+type UniformCrossover struct{}
+
+func (UniformCrossover) Crossover(a, b []float64, _ []Bound, _ []Encoding, src *rand.Rand) {
+	for i := range a {
+		if src.Float64() < 0.5 {
+			a[i], b[i] = b[i], a[i]
+		}
+	}
+}
+
+// OnePointCrossover swaps the genes of the parents after a point chosen
+// uniformly at random.
+type OnePointCrossover struct{}
+
+func (OnePointCrossover) Crossover(a, b []float64, _ []Bound, _ []Encoding, src *rand.Rand) {
+	if len(a) < 2 {
+		return
+	}
+	for i := 1 + src.Intn(len(a)-1); i < len(a); i++ {
+		a[i], b[i] = b[i], a[i]
+	}
+}
+
+// PolynomialMutation is the bounded polynomial mutation of
+//
+//  Deb, K., Goyal, M.: A combined genetic adaptive search (GeneAS) for
+//  engineering design. Computer Science and Informatics 26 (1996), 30-45.
+//
+// Each real or integer gene is mutated with probability Probability by
+// a random perturbation with a polynomial distribution that stays within the
+// bounds. Larger values of Eta result in smaller perturbations. Binary genes
+// are flipped with probability Probability.
+// If Eta is zero, 20 is used instead.
+// If Probability is zero, it will be set to 1/dim.
+type PolynomialMutation struct {
+	Eta         float64
+	Probability float64
+}
+
+func (p PolynomialMutation) Mutate(x []float64, bounds []Bound, enc []Encoding, src *rand.Rand) {
+	eta := p.Eta
+	if eta == 0 {
+		eta = defaultMutationEta
+	}
+	if eta < 0 {
+		panic("ga: negative mutation Eta")
+	}
+	prob := mutationProbability(p.Probability, len(x))
+	for i, v := range x {
+		if src.Float64() >= prob {
+			continue
+		}
+		if enc[i] == BinaryEncoding {
+			x[i] = 1 - v
+			continue
+		}
+		lo, hi := bounds[i].Min, bounds[i].Max
+		if lo == hi {
+			continue
+		}
+		var delta float64
+		u := src.Float64()
+		if u < 0.5 {
+			xy := 1 - (v-lo)/(hi-lo)
+			val := 2*u + (1-2*u)*math.Pow(xy, eta+1)
+			delta = math.Pow(val, 1/(eta+1)) - 1
+		} else {
+			xy := 1 - (hi-v)/(hi-lo)
+			val := 2*(1-u) + 2*(u-0.5)*math.Pow(xy, eta+1)
+			delta = 1 - math.Pow(val, 1/(eta+1))
+		}
+		x[i] = math.Max(lo, math.Min(v+delta*(hi-lo), hi))
+	}
+}
+
+// BitFlipMutation flips each binary gene with probability Probability. The
+// other genes are replaced by a uniformly random value within their bounds
+// with the same probability.
+// If Probability is zero, it will be set to 1/dim.
+type BitFlipMutation struct {
+	Probability float64
+}
+
+func (b BitFlipMutation) Mutate(x []float64, bounds []Bound, enc []Encoding, src *rand.Rand) {
+	prob := mutationProbability(b.Probability, len(x))
+	for i, v := range x {
+		if src.Float64() >= prob {
+			continue
+		}
+		if enc[i] == BinaryEncoding {
+			x[i] = 1 - v
+			continue
+		}
+		x[i] = randomGene(bounds[i], enc[i], src)
+	}
+}
+
+func mutationProbability(p float64, dim int) float64 {
+	if p < 0 || p > 1 {
+		panic("ga: mutation probability out of range")
+	}
+	if p == 0 {
+		return 1 / float64(dim)
+	}
+	return p
+}
+
+// randomGene returns a uniformly random value of a gene.
+func randomGene(b Bound, enc Encoding, src *rand.Rand) float64 {
+	switch enc {
+	case IntegerEncoding:
+		return b.Min + float64(src.Int63n(int64(b.Max-b.Min)+1))
+	case BinaryEncoding:
+		return float64(src.Intn(2))
+	default:
+		return b.Min + src.Float64()*(b.Max-b.Min)
+	}
+}
+
+// GeneticAlgorithm is a global optimizer that evolves a population of
+// candidate locations, or individuals, by selection, crossover and mutation,
+// see for example
+//
+//  Goldberg, D.E.: Genetic Algorithms in Search, Optimization, and Machine
+//  Learning. Addison-Wesley (1989).
+//
+// The variables of the problem may be real, integer or binary as given by
+// Encodings, so that GeneticAlgorithm can optimize mixed configuration
+// vectors. At every major iteration the individuals of a new generation are
+// created from pairs of parents chosen by Selection, which are recombined by
+// Crossover with probability CrossoverRate and modified by Mutation. The
+// children are then repaired to respect the bounds and the encodings: integer
+// genes are rounded to the nearest integer and binary genes to 0 or 1. The
+// Elite best individuals survive unchanged into the new generation.
+//
+// The individuals of a generation are evaluated concurrently by the tasks of
+// Global, and the next generation is created only when all of them are known.
+// The function values of the elite individuals are not evaluated again. NaN
+// function values are treated as +Inf. A MajorIteration announces the best
+// individual found so far after every generation.
+//
+// GeneticAlgorithm also implements AsyncGlobalMethod for use with Async, see
+// InitAsync.
+type GeneticAlgorithm struct {
+	// Bounds is the box in which the minimum is sought. It must contain one
+	// Bound for every dimension of the problem, and GeneticAlgorithm will
+	// panic if Bounds has the wrong size or if Min >= Max for any real Bound.
+	// The Bound of an integer variable must contain at least one integer.
+	Bounds []Bound
+	// Encodings specifies the type of each variable. If Encodings is nil, all
+	// variables are real, otherwise it must have the same length as Bounds.
+	Encodings []Encoding
+	// PopulationSize is the number of individuals in a generation.
+	// If PopulationSize is zero, it will be set to the larger of 20 and
+	// 10*dim.
+	PopulationSize int
+	// Elite is the number of best individuals that survive unchanged into
+	// the next generation. It must be less than the population size.
+	Elite int
+	// CrossoverRate is the probability that the parents are recombined. If
+	// they are not, the children are copies of the parents before mutation.
+	// If CrossoverRate is zero, it will be set to 0.9.
+	CrossoverRate float64
+
+	// Selection chooses the parents.
+	// If Selection is nil, TournamentSelection is used.
+	Selection Selection
+	// Crossover recombines the parents.
+	// If Crossover is nil, SimulatedBinaryCrossover is used.
+	Crossover Crossover
+	// Mutation modifies the children.
+	// If Mutation is nil, PolynomialMutation is used.
+	Mutation Mutation
+	// Sampler generates the individuals of the first generation within
+	// Bounds, for example a LatinHypercube with as many Samples as the
+	// population size. The individuals are repaired to respect the bounds
+	// and the encodings.
+	// If Sampler is nil, the first generation is uniformly random.
+	Sampler distmv.Rander
+
+	// Src is the source of random numbers. If Src is nil, a source seeded
+	// from the global source of the math/rand package is used.
+	Src *rand.Rand
+
+	src       *rand.Rand
+	bounds    []Bound
+	enc       []Encoding
+	size      int
+	elite     int
+	rate      float64
+	selection Selection
+	crossover Crossover
+	mutation  Mutation
+
+	eval batchEvaluator
+
+	pop      [][]float64 // Individuals of the current generation.
+	popF     []float64
+	children [][]float64 // Individuals waiting for evaluation.
+	childF   []float64
+	bestX    []float64
+	bestF    float64
+
+	next     int               // Next individual of the first generation proposed by Propose.
+	nextID   int               // Id of the next child proposed by Propose.
+	inFlight map[int][]float64 // Children proposed by Propose and not yet received.
+}
+
+func (g *GeneticAlgorithm) Needs() struct{ Gradient, Hessian bool } {
+	return struct{ Gradient, Hessian bool }{false, false}
+}
+
+func (g *GeneticAlgorithm) InitGlobal(dim, tasks int) int {
+	if tasks < 1 {
+		tasks = 1
+	}
+	ids := g.init(dim)
+	g.eval.init(tasks, ids)
+	return tasks
+}
+
+// InitAsync initializes GeneticAlgorithm for asynchronous evaluations with
+// Async as a steady-state genetic algorithm. Instead of generations, a single
+// child is created whenever an evaluation completes, and an evaluated child
+// replaces the worst individual of the population if it is better. The
+// population is initialized as with InitGlobal, and Elite is ignored.
+func (g *GeneticAlgorithm) InitAsync(dim, concurrent int) int {
+	if concurrent < 1 {
+		concurrent = 1
+	}
+	g.init(dim)
+	g.next = 0
+	g.nextID = 0
+	g.inFlight = make(map[int][]float64)
+	return concurrent
+}
+
+// init initializes the method and returns the ids of the individuals of the
+// first generation.
+func (g *GeneticAlgorithm) init(dim int) []int {
+	if len(g.Bounds) != dim {
+		panic("ga: bounds size mismatch")
+	}
+	if g.Encodings != nil && len(g.Encodings) != dim {
+		panic("ga: encodings size mismatch")
+	}
+	g.enc = resizeEncodings(g.enc, dim)
+	g.bounds = append(g.bounds[:0], g.Bounds...)
+	for i, b := range g.Bounds {
+		if g.Encodings != nil {
+			g.enc[i] = g.Encodings[i]
+		}
+		switch g.enc[i] {
+		case RealEncoding:
+			if !(b.Min < b.Max) {
+				panic("ga: invalid bound")
+			}
+		case IntegerEncoding:
+			b = Bound{math.Ceil(b.Min), math.Floor(b.Max)}
+			if !(b.Min <= b.Max) {
+				panic("ga: invalid bound")
+			}
+		case BinaryEncoding:
+			b = Bound{0, 1}
+		default:
+			panic("ga: unknown encoding")
+		}
+		g.bounds[i] = b
+	}
+
+	g.size = g.PopulationSize
+	if g.size == 0 {
+		g.size = 10 * dim
+		if g.size < minPopulationSize {
+			g.size = minPopulationSize
+		}
+	}
+	if g.size < 2 {
+		panic("ga: population size less than 2")
+	}
+	if g.Elite < 0 || g.Elite >= g.size {
+		panic("ga: invalid Elite")
+	}
+	g.elite = g.Elite
+	if g.CrossoverRate < 0 || g.CrossoverRate > 1 {
+		panic("ga: CrossoverRate out of range")
+	}
+	g.rate = g.CrossoverRate
+	if g.rate == 0 {
+		g.rate = defaultCrossoverRate
+	}
+	g.selection = g.Selection
+	if g.selection == nil {
+		g.selection = TournamentSelection{}
+	}
+	g.crossover = g.Crossover
+	if g.crossover == nil {
+		g.crossover = SimulatedBinaryCrossover{}
+	}
+	g.mutation = g.Mutation
+	if g.mutation == nil {
+		g.mutation = PolynomialMutation{}
+	}
+	g.src = g.Src
+	if g.src == nil {
+		g.src = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	g.pop = g.pop[:0]
+	g.popF = g.popF[:0]
+	g.bestX = resize(g.bestX, dim)
+	g.bestF = math.Inf(1)
+
+	g.children = g.children[:0]
+	ids := make([]int, g.size)
+	for i := range ids {
+		x := make([]float64, dim)
+		if g.Sampler != nil {
+			g.Sampler.Rand(x)
+			g.repair(x)
+		} else {
+			for j := range x {
+				x[j] = randomGene(g.bounds[j], g.enc[j], g.src)
+			}
+		}
+		g.children = append(g.children, x)
+		ids[i] = i
+	}
+	g.childF = resize(g.childF, g.size)
+	return ids
+}
+
+func (g *GeneticAlgorithm) IterateGlobal(task int, loc *Location) (Operation, error) {
+	return g.eval.iterate(g, task, loc), nil
+}
+
+func (g *GeneticAlgorithm) Lockstep() {
+	g.eval.lockstep = true
+}
+
+func (g *GeneticAlgorithm) Done() {
+	g.eval.finish()
+}
+
+func (g *GeneticAlgorithm) location(id int, x []float64) {
+	copy(x, g.children[id])
+}
+
+func (g *GeneticAlgorithm) evaluated(id int, loc *Location) {
+	f := loc.F
+	if math.IsNaN(f) {
+		f = math.Inf(1)
+	}
+	g.childF[id] = f
+}
+
+// nextBatch forms the new generation from the elite and the evaluated
+// children, and creates the children of the next one.
+func (g *GeneticAlgorithm) nextBatch(loc *Location) (ids []int, major bool) {
+	for i, f := range g.childF {
+		if f < g.bestF {
+			g.bestF = f
+			copy(g.bestX, g.children[i])
+		}
+	}
+
+	if len(g.pop) == 0 {
+		g.pop, g.children = g.children, g.pop
+		g.popF, g.childF = g.childF, g.popF
+	} else {
+		// Keep the elite of the old generation and replace the rest by the
+		// children.
+		sort.Sort(population{g.pop, g.popF})
+		for i := g.elite; i < g.size; i++ {
+			g.pop[i] = g.children[i-g.elite]
+			g.popF[i] = g.childF[i-g.elite]
+		}
+	}
+
+	nChildren := g.size - g.elite
+	g.children = g.children[:0]
+	for len(g.children) < nChildren {
+		a, b := g.mate()
+		for _, x := range [][]float64{a, b} {
+			if len(g.children) == nChildren {
+				break
+			}
+			g.mutation.Mutate(x, g.bounds, g.enc, g.src)
+			g.repair(x)
+			g.children = append(g.children, x)
+		}
+	}
+	g.childF = resize(g.childF, nChildren)
+	ids = make([]int, nChildren)
+	for i := range ids {
+		ids[i] = i
+	}
+
+	if math.IsInf(g.bestF, 1) {
+		return ids, false
+	}
+	copy(loc.X, g.bestX)
+	loc.F = g.bestF
+	return ids, true
+}
+
+// mate returns two children of parents chosen by Selection in the current
+// population and recombined by Crossover with probability CrossoverRate. The
+// children are not mutated.
+func (g *GeneticAlgorithm) mate() (a, b []float64) {
+	a = append([]float64(nil), g.pop[g.selection.Select(g.popF, g.src)]...)
+	b = append([]float64(nil), g.pop[g.selection.Select(g.popF, g.src)]...)
+	if g.src.Float64() < g.rate {
+		g.crossover.Crossover(a, b, g.bounds, g.enc, g.src)
+	}
+	return a, b
+}
+
+func (g *GeneticAlgorithm) Propose(x []float64) (int, error) {
+	var child []float64
+	switch {
+	case g.next < len(g.children):
+		child = g.children[g.next]
+		g.next++
+	case len(g.pop) < 2:
+		// Too few individuals have been evaluated to choose parents.
+		child = make([]float64, len(x))
+		for j := range child {
+			child[j] = randomGene(g.bounds[j], g.enc[j], g.src)
+		}
+	default:
+		child, _ = g.mate()
+		g.mutation.Mutate(child, g.bounds, g.enc, g.src)
+		g.repair(child)
+	}
+	id := g.nextID
+	g.nextID++
+	g.inFlight[id] = child
+	copy(x, child)
+	return id, nil
+}
+
+func (g *GeneticAlgorithm) Receive(id int, loc *Location) (Operation, error) {
+	child := g.inFlight[id]
+	delete(g.inFlight, id)
+	f := loc.F
+	if math.IsNaN(f) {
+		f = math.Inf(1)
+	}
+	if f < g.bestF {
+		g.bestF = f
+		copy(g.bestX, child)
+	}
+	if len(g.pop) < g.size {
+		g.pop = append(g.pop, child)
+		g.popF = append(g.popF, f)
+	} else {
+		worst := 0
+		for i, v := range g.popF {
+			if v > g.popF[worst] {
+				worst = i
+			}
+		}
+		if f < g.popF[worst] {
+			g.pop[worst] = child
+			g.popF[worst] = f
+		}
+	}
+	if math.IsInf(g.bestF, 1) {
+		return NoOperation, nil
+	}
+	copy(loc.X, g.bestX)
+	loc.F = g.bestF
+	return MajorIteration, nil
+}
+
+// repair moves the genes of x within their bounds and rounds them according
+// to their encoding.
+func (g *GeneticAlgorithm) repair(x []float64) {
+	for i, v := range x {
+		if g.enc[i] != RealEncoding {
+			v = math.Floor(v + 0.5)
+		}
+		x[i] = math.Max(g.bounds[i].Min, math.Min(v, g.bounds[i].Max))
+	}
+}
+
+func resizeEncodings(e []Encoding, n int) []Encoding {
+	if cap(e) < n {
+		return make([]Encoding, n)
+	}
+	e = e[:n]
+	for i := range e {
+		e[i] = RealEncoding
+	}
+	return e
+}
+
+// population sorts individuals by increasing function value.
+type population struct {
+	x [][]float64
+	f []float64
+}
+
+func (p population) Len() int {
+	return len(p.f)
+}
+
+func (p population) Less(i, j int) bool {
+	return p.f[i] < p.f[j]
+}
+
+func (p population) Swap(i, j int) {
+	p.x[i], p.x[j] = p.x[j], p.x[i]
+	p.f[i], p.f[j] = p.f[j], p.f[i]
+}
@@ -0,0 +1,143 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGeneticAlgorithm(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		f      func([]float64) float64
+		bounds []Bound
+		enc    []Encoding
+		method GeneticAlgorithm
+		xOpt   []float64
+		fOpt   float64
+		tol    float64
+	}{
+		{
+			name:   "SixHumpCamel",
+			f:      sixHumpCamel,
+			bounds: []Bound{{-3, 3}, {-2, 2}},
+			method: GeneticAlgorithm{Elite: 2},
+			fOpt:   -1.0316284534898774,
+			tol:    1e-4,
+		},
+		{
+			name:   "SixHumpCamel",
+			f:      sixHumpCamel,
+			bounds: []Bound{{-3, 3}, {-2, 2}},
+			method: GeneticAlgorithm{
+				Elite:     1,
+				Selection: RouletteSelection{},
+				Crossover: UniformCrossover{},
+				Mutation:  PolynomialMutation{Probability: 0.5},
+			},
+			fOpt: -1.0316284534898774,
+			tol:  1e-2,
+		},
+		{
+			name: "Integer",
+			f: func(x []float64) float64 {
+				var f float64
+				for _, v := range x {
+					f += (v - 3.3) * (v - 3.3)
+				}
+				return f
+			},
+			bounds: []Bound{{-10, 10}, {-10, 10}, {-10, 10}, {-10.5, 10.5}, {-10, 10}},
+			enc:    []Encoding{IntegerEncoding, IntegerEncoding, IntegerEncoding, IntegerEncoding, IntegerEncoding},
+			method: GeneticAlgorithm{
+				Elite:     2,
+				Crossover: OnePointCrossover{},
+			},
+			xOpt: []float64{3, 3, 3, 3, 3},
+			fOpt: 0.45,
+			tol:  1e-14,
+		},
+		{
+			name: "Mixed",
+			f: func(x []float64) float64 {
+				return (x[0]-1)*(x[0]-1) + (x[1]-7)*(x[1]-7) + (1 - x[2]) + x[3]
+			},
+			bounds: []Bound{{-5, 5}, {0, 10}, {}, {}},
+			enc:    []Encoding{RealEncoding, IntegerEncoding, BinaryEncoding, BinaryEncoding},
+			method: GeneticAlgorithm{Elite: 2},
+			fOpt:   0,
+			tol:    1e-3,
+		},
+		{
+			name: "Binary",
+			f: func(x []float64) float64 {
+				// The number of bits that differ from an alternating pattern.
+				var f float64
+				for i, v := range x {
+					f += math.Abs(v - float64(i%2))
+				}
+				return f
+			},
+			bounds: make([]Bound, 12),
+			enc: []Encoding{
+				BinaryEncoding, BinaryEncoding, BinaryEncoding, BinaryEncoding,
+				BinaryEncoding, BinaryEncoding, BinaryEncoding, BinaryEncoding,
+				BinaryEncoding, BinaryEncoding, BinaryEncoding, BinaryEncoding,
+			},
+			method: GeneticAlgorithm{
+				Elite:     1,
+				Crossover: UniformCrossover{},
+				Mutation:  BitFlipMutation{},
+			},
+			xOpt: []float64{0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1},
+			fOpt: 0,
+		},
+	} {
+		var first *Result
+		for _, concurrent := range []int{1, 4} {
+			method := test.method
+			method.Bounds = test.bounds
+			method.Encodings = test.enc
+			method.Src = rand.New(rand.NewSource(1))
+			settings := DefaultSettingsGlobal()
+			settings.FunctionConverge = nil
+			settings.FuncEvaluations = 2000
+			settings.Concurrent = concurrent
+			result, err := Global(Problem{Func: test.f}, len(test.bounds), settings, &method)
+			if err != nil {
+				t.Errorf("%v, Concurrent=%v: unexpected error: %v", test.name, concurrent, err)
+				continue
+			}
+			if result.Status != FunctionEvaluationLimit {
+				t.Errorf("%v, Concurrent=%v: unexpected status %v", test.name, concurrent, result.Status)
+			}
+			if math.Abs(result.F-test.fOpt) > test.tol {
+				t.Errorf("%v, Concurrent=%v: minimum not found, want %v, got %v", test.name, concurrent, test.fOpt, result.F)
+			}
+			if result.F != test.f(result.X) {
+				t.Errorf("%v, Concurrent=%v: function value at X not equal to F", test.name, concurrent)
+			}
+			for i, v := range result.X {
+				if test.enc != nil && test.enc[i] != RealEncoding && v != math.Floor(v) {
+					t.Errorf("%v, Concurrent=%v: non-integer value %v of variable %v", test.name, concurrent, v, i)
+				}
+				if test.xOpt != nil && v != test.xOpt[i] {
+					t.Errorf("%v, Concurrent=%v: unexpected minimizer, want %v, got %v", test.name, concurrent, test.xOpt, result.X)
+					break
+				}
+			}
+			// The generations do not depend on the number of tasks.
+			if first == nil {
+				first = result
+				continue
+			}
+			if result.F != first.F {
+				t.Errorf("%v: result differs with Concurrent=%v, want %v, got %v", test.name, concurrent, first.F, result.F)
+			}
+		}
+	}
+}
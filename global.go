@@ -5,6 +5,7 @@
 package optimize
 
 import (
+	"errors"
 	"math"
 	"sync"
 	"time"
@@ -20,10 +21,25 @@ type GlobalMethod interface {
 	IterateGlobal(task int, loc *Location) (Operation, error)
 	Needser
 	// Done communicates to the optimization method that the optimization has
-	// concluded to allow for shutdown.
+	// concluded to allow for shutdown. Done is called once, as soon as the
+	// termination status is known, and tasks may still be executing inside
+	// IterateGlobal at that time. A method that blocks a task waiting for the
+	// results of other tasks must release it when Done is called, and
+	// IterateGlobal should return NoOperation promptly afterwards.
 	Done()
 }
 
+// Lockstepper is implemented by global methods that support the deterministic
+// mode of Global, see Settings.Deterministic.
+type Lockstepper interface {
+	// Lockstep is called after InitGlobal when the tasks run in lockstep.
+	// IterateGlobal is then called for one task at a time in a fixed order,
+	// so a task that would wait for the results of other tasks must return
+	// NoOperation instead. It is called again in the next round, after the
+	// evaluations of the other tasks have been carried out.
+	Lockstep()
+}
+
 // Global uses a global optimizer to search for the gloabl minimum of a
 // function. A maximization problem can be transformed into a
 // minimization problem by multiplying the function by -1.
@@ -66,8 +82,21 @@ type GlobalMethod interface {
 // maximum runtime or maximum function evaluations, modify the Settings
 // input struct.
 //
-// Something about Global cannot guarantee strict bounds on function evaluations,
-// iterations, etc. in the precense of concurrency.
+// The limits on the number of function, gradient and Hessian evaluations in
+// settings are never exceeded. Global reserves the evaluations within the
+// limits before they are carried out, and refuses the evaluations requested by
+// the concurrent tasks that would exceed a limit or that are requested after
+// the optimization has terminated. Evaluations already in progress are not
+// interrupted; they are completed before Global returns. The number of refused
+// evaluations is reported in Stats.RefusedEvaluations. Global cannot
+// guarantee strict bounds on major iterations and runtime in the presence of
+// concurrency.
+//
+// If settings.Deterministic is true, the tasks run in lockstep and the result
+// does not depend on the scheduling of the goroutines. The method must then
+// implement Lockstepper. The optimization terminates when no task requests an
+// evaluation in a round, with the status of the method if it implements
+// Statuser and MethodConverge otherwise.
 func Global(p Problem, dim int, settings *Settings, method GlobalMethod) (*Result, error) {
 	startTime := time.Now()
 	if method == nil {
@@ -77,7 +106,10 @@ func Global(p Problem, dim int, settings *Settings, method GlobalMethod) (*Resul
 		settings = DefaultSettingsGlobal()
 	}
 	stats := &Stats{}
-	err := checkOptimization(p, dim, method, settings.Recorder)
+	if _, ok := method.(Lockstepper); settings.Deterministic && !ok {
+		return nil, errors.New("optimize: method does not support deterministic mode")
+	}
+	err := checkOptimization(p, dim, method, settings)
 	if err != nil {
 		return nil, err
 	}
@@ -126,12 +158,20 @@ func minimizeGlobal(p *Problem, method GlobalMethod, settings *Settings, stats *
 		startTime: startTime,
 		optLoc:    optLoc,
 		settings:  settings,
+		method:    method,
 		statuser:  statuser,
 	}
 
 	nTasks := settings.Concurrent
 	nTasks = method.InitGlobal(dim, nTasks)
 
+	if settings.Deterministic {
+		method.(Lockstepper).Lockstep()
+		lockstepGlobal(method, gs, nTasks)
+		gs.done.Do(method.Done)
+		return gs.status, gs.err
+	}
+
 	// Launch optimization workers
 	var wg sync.WaitGroup
 	for task := 0; task < nTasks; task++ {
@@ -144,7 +184,7 @@ func minimizeGlobal(p *Problem, method GlobalMethod, settings *Settings, stats *
 		}(task)
 	}
 	wg.Wait()
-	method.Done()
+	gs.done.Do(method.Done)
 	return gs.status, gs.err
 }
 
@@ -159,6 +199,8 @@ type globalStatus struct {
 	method    GlobalMethod
 	statuser  Statuser
 	err       error
+	done      sync.Once
+	reserved  Stats // Evaluations carried out or in progress.
 }
 
 func globalWorker(task int, m GlobalMethod, g *globalStatus, loc *Location, x []float64) {
@@ -172,6 +214,7 @@ func globalWorker(task int, m GlobalMethod, g *globalStatus, loc *Location, x []
 			g.err = err
 			g.status = Failure
 			g.mux.Unlock()
+			g.done.Do(m.Done)
 			break
 		}
 
@@ -193,6 +236,11 @@ func (g *globalStatus) globalOperation(op Operation, loc *Location, x []float64)
 	status = g.status
 	g.mux.RUnlock()
 	if status != NotTerminated {
+		if op.isEvaluation() {
+			g.mux.Lock()
+			g.stats.RefusedEvaluations++
+			g.mux.Unlock()
+		}
 		return status
 	}
 	switch op {
@@ -211,13 +259,53 @@ func (g *globalStatus) globalOperation(op Operation, loc *Location, x []float64)
 		status = checkConvergence(g.optLoc, g.settings, false)
 		g.mux.RUnlock()
 	default: // Any of the Evaluation operations.
-		status, err = evaluate(g.p, loc, op, x)
-		g.mux.Lock()
-		updateStats(g.stats, op)
-		g.mux.Unlock()
+		if status = g.reserve(op); status != NotTerminated {
+			return status
+		}
+		status, err = evaluate(g.p, g.settings.Evaluator, loc, op, x)
+	}
+	return g.commit(op, loc, status, err)
+}
+
+// reserve reserves the evaluations requested by op within the limits given
+// by the settings before they are carried out. If a limit would be exceeded,
+// or if the optimization has already terminated, the evaluations are
+// refused and reserve returns the corresponding status. The optimization
+// terminates once the reserved evaluations have been carried out.
+func (g *globalStatus) reserve(op Operation) Status {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.status != NotTerminated {
+		g.stats.RefusedEvaluations++
+		return g.status
+	}
+	r := g.reserved
+	updateStats(&r, op)
+	s := g.settings
+	status := NotTerminated
+	switch {
+	case s.FuncEvaluations > 0 && r.FuncEvaluations > s.FuncEvaluations:
+		status = FunctionEvaluationLimit
+	case s.GradEvaluations > 0 && r.GradEvaluations > s.GradEvaluations:
+		status = GradientEvaluationLimit
+	case s.HessEvaluations > 0 && r.HessEvaluations > s.HessEvaluations:
+		status = HessianEvaluationLimit
+	}
+	if status != NotTerminated {
+		g.stats.RefusedEvaluations++
+		return status
 	}
+	g.reserved = r
+	return NotTerminated
+}
 
+// commit updates the statistics after an evaluation and the termination status
+// of the optimization.
+func (g *globalStatus) commit(op Operation, loc *Location, status Status, err error) Status {
 	g.mux.Lock()
+	if op.isEvaluation() {
+		updateStats(g.stats, op)
+	}
 	status, err = iterCleanup(status, err, g.stats, g.settings, g.statuser, g.startTime, loc, op)
 	// Update the termination status if it hasn't already terminated.
 	if g.status == NotTerminated {
@@ -226,9 +314,96 @@ func (g *globalStatus) globalOperation(op Operation, loc *Location, x []float64)
 	}
 	g.mux.Unlock()
 
+	if status != NotTerminated {
+		// Let the method release any tasks waiting on this one.
+		g.done.Do(g.method.Done)
+	}
 	return status
 }
 
+// lockstepGlobal runs the tasks of method in rounds. In every round the tasks
+// call IterateGlobal one after another in the order of their ids, the
+// requested evaluations are carried out concurrently, and their results are
+// committed in the order of the task ids. Evaluations that complete after the
+// optimization has terminated in the same round are discarded. A round in which
+// no task requests an evaluation ends the optimization.
+func lockstepGlobal(method GlobalMethod, g *globalStatus, nTasks int) {
+	dim := len(g.optLoc.X)
+	locs := make([]*Location, nTasks)
+	xs := make([][]float64, nTasks)
+	for task := range locs {
+		locs[task] = newLocation(dim, method)
+		xs[task] = make([]float64, dim)
+	}
+	ops := make([]Operation, nTasks)
+	statuses := make([]Status, nTasks)
+	errs := make([]error, nTasks)
+
+	for {
+		var evaluations int
+		for task, loc := range locs {
+			op, err := method.IterateGlobal(task, loc)
+			for err == nil && op == MajorIteration {
+				if g.globalOperation(op, loc, nil) != NotTerminated {
+					return
+				}
+				op, err = method.IterateGlobal(task, loc)
+			}
+			if err != nil {
+				g.status = Failure
+				g.err = err
+				return
+			}
+			ops[task] = op
+			if op.isEvaluation() {
+				evaluations++
+			}
+		}
+		if evaluations == 0 {
+			// The method has nothing left to evaluate, so the optimization
+			// has finished.
+			g.status = MethodConverge
+			if g.statuser != nil {
+				status, err := g.statuser.Status()
+				if err != nil && status == NotTerminated {
+					status = Failure
+				}
+				if status != NotTerminated {
+					g.status = status
+					g.err = err
+				}
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		for task, op := range ops {
+			if !op.isEvaluation() {
+				continue
+			}
+			if g.reserve(op) != NotTerminated {
+				ops[task] = NoOperation
+				continue
+			}
+			wg.Add(1)
+			go func(task int, op Operation) {
+				defer wg.Done()
+				statuses[task], errs[task] = evaluate(g.p, g.settings.Evaluator, locs[task], op, xs[task])
+			}(task, op)
+		}
+		wg.Wait()
+
+		for task, op := range ops {
+			if !op.isEvaluation() {
+				continue
+			}
+			if g.commit(op, locs[task], statuses[task], errs[task]) != NotTerminated {
+				return
+			}
+		}
+	}
+}
+
 func DefaultSettingsGlobal() *Settings {
 	return &Settings{
 		FunctionThreshold: math.Inf(-1),
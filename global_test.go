@@ -0,0 +1,188 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGlobalDeterministic(t *testing.T) {
+	bounds := []Bound{{-3, 3}, {-2, 2}}
+	for _, test := range []struct {
+		name   string
+		method func() GlobalMethod
+	}{
+		{"GuessAndCheck", func() GlobalMethod {
+			return &GuessAndCheck{Rander: &LatinHypercube{Bounds: bounds, Samples: 50, Src: rand.New(rand.NewSource(1))}}
+		}},
+		{"DIRECT", func() GlobalMethod { return &DIRECT{Bounds: bounds} }},
+		{"GeneticAlgorithm", func() GlobalMethod {
+			return &GeneticAlgorithm{Bounds: bounds, Elite: 2, Src: rand.New(rand.NewSource(1))}
+		}},
+	} {
+		for _, concurrent := range []int{1, 3, 7} {
+			var results [2]*Result
+			for i := range results {
+				settings := DefaultSettingsGlobal()
+				settings.FunctionConverge = nil
+				settings.FuncEvaluations = 300
+				settings.Concurrent = concurrent
+				settings.Deterministic = true
+				result, err := Global(Problem{Func: sixHumpCamel}, len(bounds), settings, test.method())
+				if err != nil {
+					t.Fatalf("%v, Concurrent=%v: unexpected error: %v", test.name, concurrent, err)
+				}
+				if result.Status != FunctionEvaluationLimit {
+					t.Errorf("%v, Concurrent=%v: unexpected status %v", test.name, concurrent, result.Status)
+				}
+				results[i] = result
+			}
+			a, b := *results[0], *results[1]
+			a.Runtime, b.Runtime = 0, 0
+			if !reflect.DeepEqual(a, b) {
+				t.Errorf("%v, Concurrent=%v: result not reproducible:\n%+v\n%+v", test.name, concurrent, a, b)
+			}
+		}
+	}
+}
+
+func TestGlobalStrictBudget(t *testing.T) {
+	bounds := []Bound{{-3, 3}, {-2, 2}}
+	for _, deterministic := range []bool{false, true} {
+		for _, test := range []struct {
+			name   string
+			method GlobalMethod
+		}{
+			{"GuessAndCheck", &GuessAndCheck{Rander: &Halton{Bounds: bounds}}},
+			{"GeneticAlgorithm", &GeneticAlgorithm{Bounds: bounds, PopulationSize: 7}},
+		} {
+			// The budget runs out in the middle of a round of evaluations
+			// of both methods, so some evaluations are refused.
+			const budget = 38
+			var calls int64
+			f := func(x []float64) float64 {
+				atomic.AddInt64(&calls, 1)
+				time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+				return sixHumpCamel(x)
+			}
+			settings := DefaultSettingsGlobal()
+			settings.FunctionConverge = nil
+			settings.FuncEvaluations = budget
+			settings.Concurrent = 8
+			settings.Deterministic = deterministic
+			result, err := Global(Problem{Func: f}, len(bounds), settings, test.method)
+			if err != nil {
+				t.Errorf("%v, Deterministic=%v: unexpected error: %v", test.name, deterministic, err)
+				continue
+			}
+			if result.Status != FunctionEvaluationLimit {
+				t.Errorf("%v, Deterministic=%v: unexpected status %v", test.name, deterministic, result.Status)
+			}
+			if calls != budget || result.FuncEvaluations != budget {
+				t.Errorf("%v, Deterministic=%v: budget not respected, want %v evaluations, got %v calls and %v in Stats",
+					test.name, deterministic, budget, calls, result.FuncEvaluations)
+			}
+			if deterministic && result.RefusedEvaluations == 0 {
+				t.Errorf("%v, Deterministic=%v: no refused evaluations", test.name, deterministic)
+			}
+		}
+	}
+}
+
+func TestGlobalDeterministicExhausted(t *testing.T) {
+	xs := [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+	for _, test := range []struct {
+		name   string
+		method GlobalMethod
+		status Status
+	}{
+		{"finiteGlobal", &finiteGlobal{xs: xs}, MethodConverge},
+		{"finiteStatuser", finiteStatuser{&finiteGlobal{xs: xs}}, StepConvergence},
+	} {
+		settings := DefaultSettingsGlobal()
+		settings.FunctionConverge = nil
+		settings.Concurrent = 2
+		settings.Deterministic = true
+		result, err := Global(Problem{Func: sixHumpCamel}, 2, settings, test.method)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != test.status {
+			t.Errorf("%v: unexpected status %v, want %v", test.name, result.Status, test.status)
+		}
+		if result.FuncEvaluations != len(xs) {
+			t.Errorf("%v: %v evaluations, want %v", test.name, result.FuncEvaluations, len(xs))
+		}
+	}
+}
+
+// finiteGlobal is a GlobalMethod that evaluates the locations xs and then has
+// nothing left to evaluate.
+type finiteGlobal struct {
+	xs        [][]float64
+	mux       sync.Mutex
+	next      int
+	exhausted bool
+}
+
+func (*finiteGlobal) Needs() struct{ Gradient, Hessian bool } {
+	return struct{ Gradient, Hessian bool }{false, false}
+}
+func (f *finiteGlobal) InitGlobal(dim, tasks int) int { return tasks }
+func (f *finiteGlobal) IterateGlobal(task int, loc *Location) (Operation, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.next == len(f.xs) {
+		f.exhausted = true
+		return NoOperation, nil
+	}
+	copy(loc.X, f.xs[f.next])
+	f.next++
+	return FuncEvaluation, nil
+}
+func (*finiteGlobal) Lockstep() {}
+func (*finiteGlobal) Done()     {}
+
+// finiteStatuser reports StepConvergence once finiteGlobal has nothing left
+// to evaluate.
+type finiteStatuser struct {
+	*finiteGlobal
+}
+
+func (f finiteStatuser) Status() (Status, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.exhausted {
+		return StepConvergence, nil
+	}
+	return NotTerminated, nil
+}
+
+func TestGlobalDeterministicUnsupported(t *testing.T) {
+	settings := DefaultSettingsGlobal()
+	settings.Deterministic = true
+	_, err := Global(Problem{Func: sixHumpCamel}, 2, settings, unsupportedGlobal{})
+	if err == nil {
+		t.Errorf("expected error for a method without Lockstep")
+	}
+}
+
+// unsupportedGlobal is a GlobalMethod that does not implement Lockstepper.
+type unsupportedGlobal struct{}
+
+func (unsupportedGlobal) Needs() struct{ Gradient, Hessian bool } {
+	return struct{ Gradient, Hessian bool }{false, false}
+}
+func (unsupportedGlobal) InitGlobal(dim, tasks int) int { return tasks }
+func (unsupportedGlobal) IterateGlobal(task int, loc *Location) (Operation, error) {
+	return NoOperation, nil
+}
+func (unsupportedGlobal) Done() {}
@@ -8,6 +8,7 @@ import "github.com/gonum/floats"
 
 // GradientDescent implements the steepest descent optimization method that
 // performs successive steps along the direction of the negative gradient.
+// With a Preconditioner, the direction is -M^{-1} ∇f_k instead.
 type GradientDescent struct {
 	// Linesearcher selects suitable steps along the descent direction.
 	// If Linesearcher is nil, a reasonable default will be chosen.
@@ -15,6 +16,8 @@ type GradientDescent struct {
 	// StepSizer determines the initial step size along each direction.
 	// If StepSizer is nil, a reasonable default will be chosen.
 	StepSizer StepSizer
+	// Preconditioner, if not nil, is applied to the gradient.
+	Preconditioner Preconditioner
 
 	ls *LinesearchMethod
 }
@@ -41,17 +44,30 @@ func (g *GradientDescent) Iterate(loc *Location) (Operation, error) {
 }
 
 func (g *GradientDescent) InitDirection(loc *Location, dir []float64) (stepSize float64) {
-	copy(dir, loc.Gradient)
-	floats.Scale(-1, dir)
+	if g.Preconditioner != nil {
+		g.Preconditioner.Init(loc)
+	}
+	g.direction(loc, dir)
 	return g.StepSizer.Init(loc, dir)
 }
 
 func (g *GradientDescent) NextDirection(loc *Location, dir []float64) (stepSize float64) {
-	copy(dir, loc.Gradient)
-	floats.Scale(-1, dir)
+	if g.Preconditioner != nil {
+		g.Preconditioner.Update(loc)
+	}
+	g.direction(loc, dir)
 	return g.StepSizer.StepSize(loc, dir)
 }
 
+func (g *GradientDescent) direction(loc *Location, dir []float64) {
+	if g.Preconditioner != nil {
+		g.Preconditioner.Apply(dir, loc.Gradient)
+	} else {
+		copy(dir, loc.Gradient)
+	}
+	floats.Scale(-1, dir)
+}
+
 func (*GradientDescent) Needs() struct {
 	Gradient bool
 	Hessian  bool
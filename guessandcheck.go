@@ -14,6 +14,11 @@ import (
 // GuessAndCheck is a global optimizer that evaluates the function at random
 // locations. Not a good optimizer, but useful for comparison and debugging.
 type GuessAndCheck struct {
+	// Rander generates the locations. It may be a distribution, or
+	// a space-filling sampler such as LatinHypercube, Halton or Sobol.
+	// All the tasks draw from Rander. In the deterministic mode of Global
+	// they call it one at a time in the order of the tasks, so a seeded
+	// Rander gives reproducible locations.
 	Rander distmv.Rander
 
 	eval []bool
@@ -31,6 +36,9 @@ func (g *GuessAndCheck) Done() {
 	// No cleanup needed
 }
 
+// Lockstep does nothing, the tasks of GuessAndCheck never wait for each other.
+func (g *GuessAndCheck) Lockstep() {}
+
 func (g *GuessAndCheck) InitGlobal(dim, tasks int) int {
 	g.eval = make([]bool, tasks)
 	g.bestF = math.Inf(1)
@@ -0,0 +1,348 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const (
+	defaultHZDecrease  = 0.1
+	defaultHZCurvature = 0.9
+	defaultHZEpsilon   = 1e-6
+	defaultHZShrink    = 0.66
+	defaultHZExpansion = 5
+	hzBisection        = 0.5
+	hzSafeguard        = 0.01
+	hzMaxStep          = 1e20
+)
+
+// HagerZhangLinesearch is a Linesearcher that finds steps that satisfy either
+// the weak Wolfe conditions or the approximate Wolfe conditions
+//  (2*DecreaseFactor - 1) * φ'(0) >= φ'(step) >= CurvatureFactor * φ'(0),
+//  φ(step) <= φ(0) + Epsilon * |φ(0)|,
+// of the CG_DESCENT line search of
+//
+//  Hager, W.W., Zhang, H.: A new conjugate gradient method with guaranteed
+//  descent and an efficient line search. SIAM J. Optim. 16 (2005), 170-192.
+//  Hager, W.W., Zhang, H.: Algorithm 851: CG_DESCENT, a conjugate gradient
+//  method with guaranteed descent. ACM Trans. Math. Softw. 32 (2006),
+//  113-137.
+//
+// Near a minimum, the decrease of the function value is in the order of the
+// rounding errors of the function value, so the sufficient decrease condition
+// cannot be tested reliably. The approximate Wolfe conditions replace it by
+// a condition on the derivative, which can be evaluated accurately. The line
+// search brackets a step that satisfies the conditions by expanding the
+// initial step, and shrinks the bracket by double secant steps and bisection.
+// Every trial step evaluates both the function value and the derivative.
+//
+// HagerZhangLinesearch is the line search designed for the HagerZhang variant
+// of CG.
+type HagerZhangLinesearch struct {
+	// DecreaseFactor is the constant factor in the sufficient decrease
+	// condition. It must be in the interval (0, 0.5). If it is zero, it will
+	// be defaulted to 0.1.
+	DecreaseFactor float64
+	// CurvatureFactor is the constant factor in the curvature condition. It
+	// must be in the interval [DecreaseFactor, 1). If it is zero, it will be
+	// defaulted to 0.9.
+	CurvatureFactor float64
+	// Epsilon is the relative error in the function value allowed by the
+	// approximate Wolfe conditions. It must not be negative. If it is zero,
+	// it will be defaulted to 1e-6.
+	Epsilon float64
+	// ShrinkFactor is the factor by which the bracket must shrink at each
+	// iteration, otherwise it is bisected. It must be in the interval (0, 1).
+	// If it is zero, it will be defaulted to 0.66.
+	ShrinkFactor float64
+	// ExpansionFactor multiplies the step while bracketing. It must be
+	// greater than one. If it is zero, it will be defaulted to 5.
+	ExpansionFactor float64
+
+	origin hzPoint // Data at step = 0.
+	fTol   float64 // Upper bound φ(0) + Epsilon * |φ(0)| on good function values.
+
+	state hzState
+	cont  hzState // State that continues after the bisection of update.
+	step  float64 // Step being evaluated.
+
+	last  hzPoint // Last step of the bracketing phase with a good function value.
+	a, b  hzPoint // Current bracket.
+	width float64 // Width of the bracket at the start of the iteration.
+	c     hzPoint // Step of the first secant.
+	ua    hzPoint // Bracket of the current stage of secant², or of the bisection of update.
+	ub    hzPoint
+}
+
+// hzPoint is a step with its function value and derivative.
+type hzPoint struct {
+	x, f, g float64
+}
+
+// hzState is the stage of HagerZhangLinesearch in which the last step was
+// evaluated.
+type hzState int
+
+const (
+	hzNone hzState = iota
+	hzBracket
+	hzUpdate // Bisection within update, continued by cont.
+	hzSecant
+	hzSecant2
+	hzBisect
+	hzLoop // Start of a new iteration, only used as a continuation.
+)
+
+func (hz *HagerZhangLinesearch) Init(f, g float64, step float64) Operation {
+	if step <= 0 {
+		panic("hagerzhang: bad step size")
+	}
+	if g >= 0 {
+		panic("hagerzhang: initial derivative is non-negative")
+	}
+
+	if hz.DecreaseFactor == 0 {
+		hz.DecreaseFactor = defaultHZDecrease
+	}
+	if hz.CurvatureFactor == 0 {
+		hz.CurvatureFactor = defaultHZCurvature
+	}
+	if hz.Epsilon == 0 {
+		hz.Epsilon = defaultHZEpsilon
+	}
+	if hz.ShrinkFactor == 0 {
+		hz.ShrinkFactor = defaultHZShrink
+	}
+	if hz.ExpansionFactor == 0 {
+		hz.ExpansionFactor = defaultHZExpansion
+	}
+	if hz.DecreaseFactor <= 0 || hz.DecreaseFactor >= 0.5 {
+		panic("hagerzhang: DecreaseFactor not between 0 and 0.5")
+	}
+	if hz.CurvatureFactor < hz.DecreaseFactor || hz.CurvatureFactor >= 1 {
+		panic("hagerzhang: CurvatureFactor not between DecreaseFactor and 1")
+	}
+	if hz.Epsilon < 0 {
+		panic("hagerzhang: negative Epsilon")
+	}
+	if hz.ShrinkFactor <= 0 || hz.ShrinkFactor >= 1 {
+		panic("hagerzhang: ShrinkFactor not between 0 and 1")
+	}
+	if hz.ExpansionFactor <= 1 {
+		panic("hagerzhang: ExpansionFactor not greater than 1")
+	}
+
+	hz.origin = hzPoint{0, f, g}
+	hz.fTol = f + hz.Epsilon*math.Abs(f)
+	hz.last = hz.origin
+	hz.state = hzBracket
+	hz.step = step
+	return FuncEvaluation | GradEvaluation
+}
+
+func (hz *HagerZhangLinesearch) Iterate(f, g float64) (Operation, float64, error) {
+	if hz.state == hzNone {
+		panic("hagerzhang: Init has not been called")
+	}
+	p := hzPoint{hz.step, f, g}
+	if hz.accept(p) {
+		hz.state = hzNone
+		return MajorIteration, p.x, nil
+	}
+
+	switch hz.state {
+	case hzBracket:
+		if hz.upper(p) {
+			return hz.iterate(hz.last, p)
+		}
+		if p.f > hz.fTol {
+			// The derivative is negative, but the function value has
+			// increased too much. Bisect back towards the origin.
+			return hz.bisectUpdate(hz.origin, p, hzLoop)
+		}
+		hz.last = p
+		if p.x*hz.ExpansionFactor > hzMaxStep {
+			hz.state = hzNone
+			return NoOperation, p.x, ErrLinesearcherBound
+		}
+		return hz.evaluate(hzBracket, p.x*hz.ExpansionFactor)
+
+	case hzUpdate:
+		if hz.upper(p) {
+			return hz.continueWith(hz.ua, p)
+		}
+		if p.f <= hz.fTol {
+			hz.ua = p
+		} else {
+			hz.ub = p
+		}
+		return hz.bisectStep()
+
+	case hzSecant:
+		// The first secant step of secant² from the bracket [a, b].
+		hz.c = p
+		a, b, done := hz.update(hz.a, hz.b, p)
+		if !done {
+			return hz.bisectUpdate(a, b, hzSecant)
+		}
+		return hz.secant2(a, b)
+
+	case hzSecant2:
+		a, b, done := hz.update(hz.ua, hz.ub, p)
+		if !done {
+			return hz.bisectUpdate(a, b, hzSecant2)
+		}
+		return hz.shrink(a, b)
+
+	case hzBisect:
+		a, b, done := hz.update(hz.ua, hz.ub, p)
+		if !done {
+			return hz.bisectUpdate(a, b, hzBisect)
+		}
+		return hz.iterate(a, b)
+	}
+	panic("hagerzhang: invalid state")
+}
+
+// accept returns whether p satisfies the Wolfe or the approximate Wolfe
+// conditions.
+func (hz *HagerZhangLinesearch) accept(p hzPoint) bool {
+	o := hz.origin
+	if p.g < hz.CurvatureFactor*o.g || math.IsNaN(p.f) || math.IsNaN(p.g) {
+		return false
+	}
+	if WeakWolfeConditionsMet(p.f, p.g, o.f, o.g, p.x, hz.DecreaseFactor, hz.CurvatureFactor) {
+		return true
+	}
+	return (2*hz.DecreaseFactor-1)*o.g >= p.g && p.f <= hz.fTol
+}
+
+// upper returns whether p is an upper end of a bracket, at which the
+// derivative is not negative. Steps with invalid values are treated as upper
+// ends so that the line search moves away from them.
+func (hz *HagerZhangLinesearch) upper(p hzPoint) bool {
+	return p.g >= 0 || math.IsNaN(p.g) || math.IsNaN(p.f) || math.IsInf(p.f, 1)
+}
+
+// update returns the bracket [a, b] updated with the evaluated step c that
+// lies within it. If done is false, the bracket must be shrunk by the
+// bisection of update.
+func (hz *HagerZhangLinesearch) update(a, b, c hzPoint) (hzPoint, hzPoint, bool) {
+	if hz.upper(c) {
+		return a, c, true
+	}
+	if c.f <= hz.fTol {
+		return c, b, true
+	}
+	return a, c, false
+}
+
+// bisectUpdate starts the bisection of update within [a, b], where b has
+// a negative derivative but a too large function value. The state cont
+// continues when the bisection has found a new bracket.
+func (hz *HagerZhangLinesearch) bisectUpdate(a, b hzPoint, cont hzState) (Operation, float64, error) {
+	hz.ua, hz.ub = a, b
+	hz.cont = cont
+	return hz.bisectStep()
+}
+
+func (hz *HagerZhangLinesearch) bisectStep() (Operation, float64, error) {
+	d := (1-hzBisection)*hz.ua.x + hzBisection*hz.ub.x
+	if !hz.within(d, hz.ua, hz.ub) {
+		hz.state = hzNone
+		return NoOperation, d, ErrLinesearcherFailure
+	}
+	return hz.evaluate(hzUpdate, d)
+}
+
+// continueWith continues the stage that called update with the new bracket
+// [a, b] found by its bisection.
+func (hz *HagerZhangLinesearch) continueWith(a, b hzPoint) (Operation, float64, error) {
+	switch hz.cont {
+	case hzSecant:
+		return hz.secant2(a, b)
+	case hzSecant2:
+		return hz.shrink(a, b)
+	case hzBisect, hzLoop:
+		return hz.iterate(a, b)
+	}
+	panic("hagerzhang: invalid state")
+}
+
+// iterate starts a new iteration from the bracket [a, b] with the first secant
+// step of secant².
+func (hz *HagerZhangLinesearch) iterate(a, b hzPoint) (Operation, float64, error) {
+	hz.a, hz.b = a, b
+	hz.width = b.x - a.x
+	if !hz.within((a.x+b.x)/2, a, b) {
+		hz.state = hzNone
+		return NoOperation, b.x, ErrLinesearcherFailure
+	}
+	c, ok := hz.secant(a, b, a, b)
+	if !ok {
+		return hz.shrink(a, b)
+	}
+	return hz.evaluate(hzSecant, c)
+}
+
+// secant2 carries out the second secant step of secant² from the bracket
+// [A, B] given by the first secant step hz.c.
+func (hz *HagerZhangLinesearch) secant2(A, B hzPoint) (Operation, float64, error) {
+	var (
+		c  float64
+		ok bool
+	)
+	switch hz.c.x {
+	case B.x:
+		c, ok = hz.secant(hz.b, B, A, B)
+	case A.x:
+		c, ok = hz.secant(hz.a, A, A, B)
+	}
+	if !ok {
+		return hz.shrink(A, B)
+	}
+	hz.ua, hz.ub = A, B
+	return hz.evaluate(hzSecant2, c)
+}
+
+// shrink bisects the bracket [a, b] found by secant² if it has not shrunk
+// sufficiently, or starts a new iteration.
+func (hz *HagerZhangLinesearch) shrink(a, b hzPoint) (Operation, float64, error) {
+	if b.x-a.x <= hz.ShrinkFactor*hz.width {
+		return hz.iterate(a, b)
+	}
+	c := (a.x + b.x) / 2
+	if !hz.within(c, a, b) {
+		hz.state = hzNone
+		return NoOperation, c, ErrLinesearcherFailure
+	}
+	hz.ua, hz.ub = a, b
+	return hz.evaluate(hzBisect, c)
+}
+
+func (hz *HagerZhangLinesearch) evaluate(state hzState, step float64) (Operation, float64, error) {
+	hz.state = state
+	hz.step = step
+	return FuncEvaluation | GradEvaluation, step, nil
+}
+
+// within returns whether x lies strictly between the steps of a and b.
+func (hz *HagerZhangLinesearch) within(x float64, a, b hzPoint) bool {
+	return math.Min(a.x, b.x) < x && x < math.Max(a.x, b.x)
+}
+
+// secant returns the step at which the secant of the derivative through p
+// and q is zero, and whether it lies within the bracket [a, b]. On badly
+// scaled functions the secant step may fall extremely close to an end of the
+// bracket, so it is kept a small fraction of the width of the bracket away
+// from the ends.
+func (hz *HagerZhangLinesearch) secant(p, q, a, b hzPoint) (float64, bool) {
+	c := (p.x*q.g - q.x*p.g) / (q.g - p.g)
+	if !hz.within(c, a, b) {
+		return c, false
+	}
+	lo, hi := math.Min(a.x, b.x), math.Max(a.x, b.x)
+	margin := hzSafeguard * (hi - lo)
+	return math.Max(lo+margin, math.Min(c, hi-margin)), true
+}
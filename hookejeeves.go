@@ -0,0 +1,185 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+// hjStage is the kind of the current batch of HookeJeeves.
+type hjStage int
+
+const (
+	hjExplore hjStage = iota // Exploratory move along a coordinate.
+	hjPattern                // Evaluation of the pattern location.
+)
+
+// HookeJeeves implements the pattern search method of Hooke and Jeeves for
+// derivative-free minimization described in
+//
+//  Hooke, R., Jeeves, T.A.: "Direct search" solution of numerical and
+//  statistical problems. J. ACM 8 (1961), 212-229.
+//
+// An exploratory move tries the steps of length h in the positive and negative
+// direction of every coordinate in turn and keeps each one that decreases the
+// function value. If the exploration around the base location b finds a
+// better location b', the pattern move jumps to 2*b' - b, explores around it,
+// and keeps following the pattern as long as it leads to better locations.
+// Otherwise h is multiplied by Contraction, and HookeJeeves terminates with
+// MeshConvergence when h is smaller than MeshTolerance.
+//
+// The two steps along each coordinate are evaluated concurrently when
+// HookeJeeves is used with Global, in which case the search starts at InitX.
+// Every move of the base location and every contraction of h is a major
+// iteration.
+type HookeJeeves struct {
+	// InitX is the initial location of the search with Global. It is not
+	// used with Local.
+	InitX []float64
+	// InitialStep is the initial step length h.
+	// If InitialStep is zero, it will be set to 1.
+	InitialStep float64
+	// MeshTolerance is the step length below which the search terminates.
+	// If MeshTolerance is zero, it will be set to 1e-8.
+	MeshTolerance float64
+	// Contraction is the factor by which h shrinks when the exploration
+	// around the base location fails. It must be between zero and one.
+	// If Contraction is zero, it will be set to 0.5.
+	Contraction float64
+
+	step, tol, contraction float64
+
+	ds      directSearch
+	stage   hjStage
+	pattern bool      // The exploration is around a pattern location.
+	coord   int       // Coordinate of the current exploratory move.
+	y       []float64 // Current location of the exploration.
+	fy      float64   // Function value at y.
+	prev    []float64 // Previous base location.
+}
+
+func (hj *HookeJeeves) Init(loc *Location) (Operation, error) {
+	hj.init(len(loc.X))
+	return hj.ds.initLocal(hj, loc), nil
+}
+
+func (hj *HookeJeeves) Iterate(loc *Location) (Operation, error) {
+	return hj.ds.iterate(0, loc), nil
+}
+
+func (hj *HookeJeeves) InitGlobal(dim, tasks int) int {
+	hj.init(dim)
+	return hj.ds.initGlobal(hj, hj.InitX, tasks, "hookejeeves")
+}
+
+func (hj *HookeJeeves) IterateGlobal(task int, loc *Location) (Operation, error) {
+	return hj.ds.iterate(task, loc), nil
+}
+
+// Status returns MeshConvergence once the step length is smaller than
+// MeshTolerance.
+func (hj *HookeJeeves) Status() (Status, error) {
+	return hj.ds.status(), nil
+}
+
+func (hj *HookeJeeves) Lockstep() {
+	hj.ds.batch.lockstep = true
+}
+
+func (hj *HookeJeeves) Done() {
+	hj.ds.batch.finish()
+}
+
+func (*HookeJeeves) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+func (hj *HookeJeeves) init(dim int) {
+	hj.step, hj.tol = meshSizes(hj.InitialStep, hj.MeshTolerance, "hookejeeves")
+	hj.contraction = hj.Contraction
+	if hj.contraction == 0 {
+		hj.contraction = defaultMeshContraction
+	}
+	if hj.contraction <= 0 || hj.contraction >= 1 {
+		panic("hookejeeves: Contraction not between zero and one")
+	}
+	hj.y = resize(hj.y, dim)
+	hj.prev = resize(hj.prev, dim)
+}
+
+func (hj *HookeJeeves) start(ds *directSearch) {
+	hj.pattern = false
+	hj.explore(ds, ds.x, ds.f)
+}
+
+func (hj *HookeJeeves) update(ds *directSearch) bool {
+	if hj.stage == hjPattern {
+		hj.explore(ds, ds.trials[0], ds.values[0])
+		return false
+	}
+
+	if k, _ := ds.best(); ds.values[k] < hj.fy {
+		copy(hj.y, ds.trials[k])
+		hj.fy = ds.values[k]
+	}
+	hj.coord++
+	if hj.coord < len(hj.y) {
+		ds.reset()
+		hj.move(ds)
+		return false
+	}
+
+	// The exploration is complete.
+	switch {
+	case hj.fy < ds.f:
+		// Move the base location to y and evaluate the pattern location.
+		copy(hj.prev, ds.x)
+		copy(ds.x, hj.y)
+		ds.f = hj.fy
+		ds.reset()
+		p := ds.addTrial()
+		for i, v := range ds.x {
+			p[i] = 2*v - hj.prev[i]
+		}
+		hj.stage = hjPattern
+		hj.pattern = true
+	case hj.pattern:
+		// The exploration around the pattern location has failed, so
+		// explore around the base location.
+		hj.start(ds)
+		return false
+	default:
+		hj.step *= hj.contraction
+		if hj.step < hj.tol {
+			ds.reset()
+			ds.converged = true
+			return true
+		}
+		hj.start(ds)
+	}
+	return true
+}
+
+// explore starts an exploration around x with the function value f.
+func (hj *HookeJeeves) explore(ds *directSearch, x []float64, f float64) {
+	copy(hj.y, x)
+	hj.fy = f
+	hj.coord = 0
+	hj.stage = hjExplore
+	ds.reset()
+	hj.move(ds)
+}
+
+// move adds the trial locations of the exploratory move along the current
+// coordinate.
+func (hj *HookeJeeves) move(ds *directSearch) {
+	for _, sign := range []float64{1, -1} {
+		trial := ds.addTrial()
+		copy(trial, hj.y)
+		trial[hj.coord] += sign * hj.step
+	}
+}
@@ -47,6 +47,16 @@ type Method interface {
 	Needser
 }
 
+// problemIniter is implemented by methods that use the optional routines of
+// Problem. Local calls initProblem before Init. It returns an error if p does
+// not provide what the method needs. If settings.Evaluator is not nil, the
+// routines of p are not checked, the Evaluator reports the routines it cannot
+// evaluate. initProblem must not call the routines of p, they are evaluated by
+// Local when the method requests them.
+type problemIniter interface {
+	initProblem(p *Problem, settings *Settings) error
+}
+
 type Needser interface {
 	// Needs specifies information about the objective function needed by the
 	// optimizer beyond just the function value. The information is used
@@ -98,6 +108,19 @@ type Linesearcher interface {
 	Iterate(value, derivative float64) (op Operation, step float64, err error)
 }
 
+// NonmonotoneLinesearcher is a Linesearcher whose acceptance of a step
+// depends on the function values at the starting points of the previous line
+// searches, which it receives one after another in the value argument of
+// Init. LinesearchMethod calls ResetHistory at the start of every
+// optimization run, so that the values of a previous run are forgotten.
+type NonmonotoneLinesearcher interface {
+	Linesearcher
+
+	// ResetHistory discards the function values of the previous line
+	// searches.
+	ResetHistory()
+}
+
 // NextDirectioner implements a strategy for computing a new line search
 // direction at each major iteration. Typically, a NextDirectioner will be
 // used in conjuction with LinesearchMethod for performing gradient-based
@@ -122,6 +145,19 @@ type StepSizer interface {
 	StepSize(loc *Location, dir []float64) float64
 }
 
+// Evaluator evaluates the objective function and its derivatives. It is
+// used through Settings to carry out the evaluations requested by the
+// optimization method elsewhere than in the routines of Problem, for example
+// in other processes.
+type Evaluator interface {
+	// Evaluate evaluates the quantities specified by the evaluation
+	// operation op at x, and stores them in the corresponding fields of loc.
+	// Evaluate must not modify x or the other fields of loc. It may be called
+	// concurrently by Global. A non-nil error terminates the optimization
+	// with Failure.
+	Evaluate(op Operation, x []float64, loc *Location) error
+}
+
 // A Recorder can record the progress of the optimization, for example to print
 // the progress to StdOut or to a log file. A Recorder must not modify any data.
 type Recorder interface {
@@ -18,20 +18,40 @@ import (
 // O(Store * dim) while BFGS scales as O(dim^2). The "forgetful" nature of
 // LBFGS may also make it perform better than BFGS for functions with Hessians
 // that vary rapidly spatially.
+//
+// If Damped is true, the stored changes of the gradient are damped as in BFGS,
+// so LBFGS stays robust with line searches that do not enforce the curvature
+// condition.
 type LBFGS struct {
 	// Linesearcher selects suitable steps along the descent direction.
-	// Accepted steps should satisfy the strong Wolfe conditions.
+	// Accepted steps should satisfy the strong Wolfe conditions unless
+	// Damped is true.
 	// If Linesearcher is nil, a reasonable default will be chosen.
 	Linesearcher Linesearcher
 	// Store is the size of the limited-memory storage.
 	// If Store is 0, it will be defaulted to 15.
 	Store int
+	// Scaling is the strategy for the initial estimate of the inverse Hessian,
+	// which is chosen anew at every iteration from the newest pair.
+	// The default is ShannoPhuaScaling.
+	Scaling HessianScaling
+	// Damped specifies whether the updates are damped.
+	Damped bool
+	// Preconditioner, if not nil, replaces the identity matrix in the initial
+	// estimate of the inverse Hessian: H_0 = γ M^{-1} with
+	// γ = s·y / y·M^{-1}y for ShannoPhuaScaling, and H_0 = M^{-1} for
+	// IdentityScaling. It cannot be used with DiagonalScaling.
+	Preconditioner Preconditioner
 
 	ls *LinesearchMethod
 
 	dim  int       // Dimension of the problem
 	x    []float64 // Location at the last major iteration
 	grad []float64 // Gradient at the last major iteration
+	dir  []float64 // Direction of the last line search
+	bs   []float64 // Estimate of the Hessian times s for the damping
+	diag []float64 // Diagonal initial estimate of the inverse Hessian
+	py   []float64 // Preconditioned y
 
 	// History
 	oldest int         // Index of the oldest element of the history
@@ -48,6 +68,12 @@ func (l *LBFGS) Init(loc *Location) (Operation, error) {
 	if l.Store == 0 {
 		l.Store = 15
 	}
+	if l.Scaling < ShannoPhuaScaling || l.Scaling > DiagonalScaling {
+		panic("lbfgs: unknown Scaling")
+	}
+	if l.Preconditioner != nil && l.Scaling == DiagonalScaling {
+		panic("lbfgs: Preconditioner with DiagonalScaling")
+	}
 
 	if l.ls == nil {
 		l.ls = &LinesearchMethod{}
@@ -78,8 +104,22 @@ func (l *LBFGS) InitDirection(loc *Location, dir []float64) (stepSize float64) {
 	l.grad = resize(l.grad, dim)
 	copy(l.grad, loc.Gradient)
 
-	copy(dir, loc.Gradient)
+	l.bs = resize(l.bs, dim)
+	l.diag = resize(l.diag, dim)
+	for i := range l.diag {
+		l.diag[i] = 1
+	}
+
+	if l.Preconditioner != nil {
+		l.Preconditioner.Init(loc)
+		l.py = resize(l.py, dim)
+		l.Preconditioner.Apply(dir, loc.Gradient)
+	} else {
+		copy(dir, loc.Gradient)
+	}
 	floats.Scale(-1, dir)
+	l.dir = resize(l.dir, dim)
+	copy(l.dir, dir)
 	return 1 / floats.Norm(dir, 2)
 }
 
@@ -118,6 +158,17 @@ func (l *LBFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	s := l.s[l.oldest]
 	floats.SubTo(s, loc.X, l.x)
 	sDotY := floats.Dot(s, y)
+	if l.Damped {
+		// The last direction is -H ∇f_k and s is a multiple t of it, so
+		// B s = -t ∇f_k.
+		t := floats.Dot(s, l.dir) / floats.Dot(l.dir, l.dir)
+		floats.ScaleTo(l.bs, -t, l.grad)
+		if theta := dampingTheta(sDotY, floats.Dot(s, l.bs)); theta < 1 {
+			floats.Scale(theta, y)
+			floats.AddScaled(y, 1-theta, l.bs)
+			sDotY = floats.Dot(s, y)
+		}
+	}
 	l.rho[l.oldest] = 1 / sDotY
 
 	l.oldest = (l.oldest + 1) % l.Store
@@ -137,8 +188,26 @@ func (l *LBFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 	}
 
 	// Scale the initial Hessian.
-	gamma := sDotY / floats.Dot(y, y)
-	floats.Scale(gamma, dir)
+	if l.Preconditioner != nil {
+		l.Preconditioner.Update(loc)
+		l.Preconditioner.Apply(dir, dir)
+		if l.Scaling == ShannoPhuaScaling {
+			l.Preconditioner.Apply(l.py, y)
+			gamma := sDotY / floats.Dot(y, l.py)
+			floats.Scale(gamma, dir)
+		}
+	} else {
+		switch l.Scaling {
+		case ShannoPhuaScaling:
+			gamma := sDotY / floats.Dot(y, y)
+			floats.Scale(gamma, dir)
+		case DiagonalScaling:
+			if sDotY > 0 {
+				updateDiagonal(l.diag, s, y)
+			}
+			floats.Mul(dir, l.diag)
+		}
+	}
 
 	// Start with the oldest element and go forward.
 	for i := 0; i < l.Store; i++ {
@@ -152,6 +221,7 @@ func (l *LBFGS) NextDirection(loc *Location, dir []float64) (stepSize float64) {
 
 	// dir contains H^{-1} * g, so flip the direction for minimization.
 	floats.Scale(-1, dir)
+	copy(l.dir, dir)
 
 	return 1
 }
@@ -50,6 +50,10 @@ func (ls *LinesearchMethod) Init(loc *Location) (Operation, error) {
 	ls.lastStep = math.NaN()
 	ls.lastOp = NoOperation
 
+	if nm, ok := ls.Linesearcher.(NonmonotoneLinesearcher); ok {
+		nm.ResetHistory()
+	}
+
 	return ls.initNextLinesearch(loc)
 }
 
@@ -39,6 +39,44 @@ func TestBacktracking(t *testing.T) {
 	testLinesearcher(t, ls, d, 0, false)
 }
 
+func TestHagerZhangLinesearch(t *testing.T) {
+	// The approximate Wolfe conditions do not imply sufficient decrease, so
+	// only the curvature condition is tested.
+	testLinesearcher(t, &HagerZhangLinesearch{Epsilon: 1e-14}, 0, 0.9, false)
+}
+
+func TestNonmonotone(t *testing.T) {
+	d := 0.001
+	testLinesearcher(t, &Nonmonotone{DecreaseFactor: d}, d, 0, false)
+	testLinesearcher(t, &Nonmonotone{DecreaseFactor: d, Averaging: 0.85}, d, 0, false)
+
+	// The reference value is the maximum of the last two function values.
+	ls := &Nonmonotone{DecreaseFactor: d, Memory: 2}
+	for _, f := range []float64{3, 2, 1} {
+		ls.Init(f, -1, 1)
+	}
+	if op, _, _ := ls.Iterate(1.99, 0); op != MajorIteration {
+		t.Errorf("maximum rule rejected a step below the reference value")
+	}
+	ls.Init(0, -1, 1)
+	if op, _, _ := ls.Iterate(1.01, 0); op == MajorIteration {
+		t.Errorf("maximum rule accepted a step above the reference value")
+	}
+
+	// The reference value is (0.5 * 1 * 3 + 1) / 1.5 after two values.
+	ls = &Nonmonotone{DecreaseFactor: d, Averaging: 0.5}
+	ls.Init(3, -1, 1)
+	ls.Init(1, -1, 1)
+	if op, _, _ := ls.Iterate(5.0/3-0.01, 0); op != MajorIteration {
+		t.Errorf("average rule rejected a step below the reference value")
+	}
+	ls.ResetHistory()
+	ls.Init(1, -1, 1)
+	if op, _, _ := ls.Iterate(1, 0); op == MajorIteration {
+		t.Errorf("history not reset")
+	}
+}
+
 type funcGrader interface {
 	Func([]float64) float64
 	Grad([]float64, []float64)
@@ -82,6 +120,9 @@ func testLinesearcher(t *testing.T, ls Linesearcher, decrease, curvature float64
 				panic("bad test function")
 			}
 
+			if nm, ok := ls.(NonmonotoneLinesearcher); ok {
+				nm.ResetHistory()
+			}
 			op := ls.Init(f0, g0, initStep)
 			if !op.isEvaluation() {
 				t.Errorf("%v: Linesearcher.Init returned non-evaluating operation %v", op)
@@ -92,7 +133,7 @@ func testLinesearcher(t *testing.T, ls Linesearcher, decrease, curvature float64
 				err  error
 				k    int
 				f, g float64
-				step float64
+				step = initStep
 			)
 		loop:
 			for {
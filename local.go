@@ -70,10 +70,15 @@ func Local(p Problem, initX []float64, settings *Settings, method Method) (*Resu
 
 	stats := &Stats{}
 
-	err := checkOptimization(p, dim, method, settings.Recorder)
+	err := checkOptimization(p, dim, method, settings)
 	if err != nil {
 		return nil, err
 	}
+	if pi, ok := method.(problemIniter); ok {
+		if err := pi.initProblem(&p, settings); err != nil {
+			return nil, err
+		}
+	}
 
 	optLoc, err := getStartingLocation(&p, method, initX, stats, settings)
 	if err != nil {
@@ -147,7 +152,7 @@ func minimize(p *Problem, method Method, settings *Settings, stats *Stats, optLo
 			stats.MajorIterations++
 			status = checkConvergence(optLoc, settings, true)
 		default: // Any of the Evaluation operations.
-			status, err = evaluate(p, loc, op, x)
+			status, err = evaluate(p, settings.Evaluator, loc, op, x)
 			updateStats(stats, op)
 		}
 
@@ -209,8 +214,11 @@ func getStartingLocation(p *Problem, method Method, initX []float64, stats *Stat
 			eval |= HessEvaluation
 		}
 		x := make([]float64, len(loc.X))
-		evaluate(p, loc, eval, x)
+		_, err := evaluate(p, settings.Evaluator, loc, eval, x)
 		updateStats(stats, eval)
+		if err != nil {
+			return loc, err
+		}
 	}
 
 	if math.IsInf(loc.F, 1) || math.IsNaN(loc.F) {
@@ -0,0 +1,234 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+// MADS implements the mesh adaptive direct search method for derivative-free
+// minimization with the orthogonal poll directions of OrthoMADS described in
+//
+//  Audet, C., Dennis, J.E.: Mesh adaptive direct search algorithms for
+//  constrained optimization. SIAM J. Optim. 17 (2006), 188-217.
+//  Abramson, M.A., Audet, C., Dennis, J.E., Le Digabel, S.: OrthoMADS: A
+//  deterministic MADS instance with orthogonal directions. SIAM J. Optim. 20
+//  (2009), 948-966.
+//
+// Like PatternSearch, every iteration consists of a search step and a poll
+// step on the mesh of locations x + Δm*z, where x is the best location found
+// so far and z is an integer vector. Unlike PatternSearch, the poll locations
+// lie at the distance Δp, the poll size, which shrinks more slowly than the
+// mesh size Δm: for the mesh index l, Δp = InitialStep * 2^-l and
+// Δm = InitialStep * 4^-l. The 2*dim poll directions are the positive and
+// negative columns of the Householder matrix of a Halton vector rounded to the
+// mesh, so they differ in every poll step and become dense in the unit sphere
+// as the mesh is refined. This makes MADS converge to stationary points of
+// nonsmooth functions where the fixed directions of pattern search can stall.
+// The directions are deterministic, so the results are reproducible.
+//
+// After a successful iteration l is decreased by one, otherwise it is
+// increased by one, and MADS terminates with MeshConvergence when Δp is
+// smaller than MeshTolerance. The locations of a search or poll step are
+// evaluated concurrently when MADS is used with Global, in which case the
+// search starts at InitX. Every iteration is a major iteration.
+type MADS struct {
+	// InitX is the initial location of the search with Global. It is not
+	// used with Local.
+	InitX []float64
+	// Search returns locations near x, the best location found so far, that
+	// are evaluated by the search step. They are rounded to the mesh of size
+	// mesh. Search may be nil.
+	Search func(x []float64, mesh float64) [][]float64
+	// InitialStep is the initial poll and mesh size.
+	// If InitialStep is zero, it will be set to 1.
+	InitialStep float64
+	// MeshTolerance is the poll size below which the search terminates.
+	// If MeshTolerance is zero, it will be set to 1e-8.
+	MeshTolerance float64
+
+	step, tol float64
+
+	ds      directSearch
+	level   int  // Mesh index l.
+	halton  int  // Index of the Halton vector of the next poll step.
+	polling bool // The current batch is a poll step.
+	success bool // The last iteration was successful.
+	last    []float64
+	primes  []int
+	q, w    []float64
+}
+
+func (m *MADS) Init(loc *Location) (Operation, error) {
+	m.init(len(loc.X))
+	return m.ds.initLocal(m, loc), nil
+}
+
+func (m *MADS) Iterate(loc *Location) (Operation, error) {
+	return m.ds.iterate(0, loc), nil
+}
+
+func (m *MADS) InitGlobal(dim, tasks int) int {
+	m.init(dim)
+	return m.ds.initGlobal(m, m.InitX, tasks, "mads")
+}
+
+func (m *MADS) IterateGlobal(task int, loc *Location) (Operation, error) {
+	return m.ds.iterate(task, loc), nil
+}
+
+// Status returns MeshConvergence once the poll size is smaller than
+// MeshTolerance.
+func (m *MADS) Status() (Status, error) {
+	return m.ds.status(), nil
+}
+
+func (m *MADS) Lockstep() {
+	m.ds.batch.lockstep = true
+}
+
+func (m *MADS) Done() {
+	m.ds.batch.finish()
+}
+
+func (*MADS) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+func (m *MADS) init(dim int) {
+	m.step, m.tol = meshSizes(m.InitialStep, m.MeshTolerance, "mads")
+	m.level = 0
+	m.success = false
+	m.last = resize(m.last, dim)
+	m.q = resize(m.q, dim)
+	m.w = resize(m.w, dim)
+	if len(m.primes) != dim {
+		m.primes = primes(dim)
+	}
+	// OrthoMADS starts with the Halton vector whose index is the dim-th
+	// prime, which avoids the degenerate first vectors of the sequence.
+	m.halton = m.primes[dim-1]
+}
+
+// pollSize returns Δp.
+func (m *MADS) pollSize() float64 {
+	return m.step * math.Pow(2, -float64(m.level))
+}
+
+// meshSize returns Δm, which equals Δp while the mesh is coarser than
+// initially.
+func (m *MADS) meshSize() float64 {
+	if m.level <= 0 {
+		return m.pollSize()
+	}
+	return m.step * math.Pow(4, -float64(m.level))
+}
+
+func (m *MADS) start(ds *directSearch) {
+	ds.reset()
+	m.polling = false
+	if m.success {
+		ds.search(m.last, m.Search, m.meshSize())
+	} else {
+		ds.search(nil, m.Search, m.meshSize())
+	}
+	if ds.n == 0 {
+		m.poll(ds)
+	}
+}
+
+func (m *MADS) update(ds *directSearch) bool {
+	if k, ok := ds.best(); ok {
+		ds.move(k, m.last)
+		m.success = true
+		m.level--
+	} else if !m.polling {
+		// The search step has failed, so poll.
+		ds.reset()
+		m.poll(ds)
+		return false
+	} else {
+		m.success = false
+		m.level++
+		if m.pollSize() < m.tol {
+			ds.reset()
+			ds.converged = true
+			return true
+		}
+	}
+	m.start(ds)
+	return true
+}
+
+// poll adds the poll locations x ± Δm*h_j, where h_j are the columns of the
+// Householder matrix H = |q|^2 I - 2 q q^T of the integer vector q whose
+// norm is at most sqrt(Δp/Δm), so that |Δm*h_j| = Δm*|q|^2 ≈ Δp.
+func (m *MADS) poll(ds *directSearch) {
+	m.polling = true
+	m.direction()
+	mesh := m.meshSize()
+	qq := floats.Dot(m.q, m.q)
+	for _, sign := range []float64{1, -1} {
+		for j, qj := range m.q {
+			trial := ds.addTrial()
+			for i, qi := range m.q {
+				h := -2 * qi * qj
+				if i == j {
+					h += qq
+				}
+				trial[i] = ds.x[i] + sign*mesh*h
+			}
+		}
+	}
+	m.halton++
+}
+
+// direction stores in m.q the current Halton vector mapped to [-1, 1]^dim and
+// scaled and rounded to the largest integer vector with a norm not larger than
+// sqrt(Δp/Δm).
+func (m *MADS) direction() {
+	for i, p := range m.primes {
+		m.w[i] = 2*radicalInverse(m.halton, p) - 1
+	}
+	floats.Scale(1/floats.Norm(m.w, 2), m.w)
+	bound := math.Sqrt(m.pollSize() / m.meshSize())
+
+	round := func(alpha float64) float64 {
+		for i, v := range m.w {
+			m.q[i] = math.Floor(alpha*v + 0.5)
+		}
+		return floats.Norm(m.q, 2)
+	}
+	// The norm of the rounded vector is nondecreasing in alpha, so the
+	// largest alpha is found by bisection.
+	lo, hi := 0.0, bound
+	for round(hi) <= bound {
+		hi *= 2
+	}
+	for k := 0; k < 60; k++ {
+		mid := 0.5 * (lo + hi)
+		if round(mid) <= bound {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if round(lo) == 0 {
+		i := floats.MaxIdx(m.w)
+		if j := floats.MinIdx(m.w); -m.w[j] > m.w[i] {
+			m.q[j] = -1
+		} else {
+			m.q[i] = 1
+		}
+	}
+}
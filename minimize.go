@@ -46,17 +46,32 @@ func copyLocation(dst, src *Location) {
 		}
 		dst.Hessian.CopySym(src.Hessian)
 	}
+
+	dst.Objectives = resize(dst.Objectives, len(src.Objectives))
+	copy(dst.Objectives, src.Objectives)
+
+	if src.Jacobian != nil {
+		r, c := src.Jacobian.Dims()
+		if dst.Jacobian == nil {
+			dst.Jacobian = mat64.NewDense(r, c, nil)
+		} else if dr, dc := dst.Jacobian.Dims(); dr != r || dc != c {
+			dst.Jacobian = mat64.NewDense(r, c, nil)
+		}
+		dst.Jacobian.Copy(src.Jacobian)
+	}
 }
 
-func checkOptimization(p Problem, dim int, method Needser, recorder Recorder) error {
-	if p.Func == nil {
+func checkOptimization(p Problem, dim int, method Needser, settings *Settings) error {
+	if p.Func == nil && settings.Evaluator == nil {
 		panic("optimize: objective function is undefined")
 	}
 	if dim <= 0 {
 		panic("optimize: impossible problem dimension")
 	}
-	if err := p.satisfies(method); err != nil {
-		return err
+	if settings.Evaluator == nil {
+		if err := p.satisfies(method); err != nil {
+			return err
+		}
 	}
 	if p.Status != nil {
 		_, err := p.Status()
@@ -64,8 +79,8 @@ func checkOptimization(p Problem, dim int, method Needser, recorder Recorder) er
 			return err
 		}
 	}
-	if recorder != nil {
-		err := recorder.Init()
+	if settings.Recorder != nil {
+		err := settings.Recorder.Init()
 		if err != nil {
 			return err
 		}
@@ -76,8 +91,10 @@ func checkOptimization(p Problem, dim int, method Needser, recorder Recorder) er
 // evaluate evaluates the routines specified by the Operation at loc.X, and stores
 // the answer into loc. loc.X is copied into x before
 // evaluating in order to prevent the routines from modifying it.
-func evaluate(p *Problem, loc *Location, op Operation, x []float64) (Status, error) {
-	if !op.isEvaluation() {
+// If e is not nil, the evaluation is carried out by e instead of the routines
+// of p.
+func evaluate(p *Problem, e Evaluator, loc *Location, op Operation, x []float64) (Status, error) {
+	if !op.isEvaluation() || op&GradEvaluation != 0 && op&ComponentGradEvaluation != 0 {
 		panic(fmt.Sprintf("optimize: invalid evaluation %v", op))
 	}
 	if p.Status != nil {
@@ -87,6 +104,18 @@ func evaluate(p *Problem, loc *Location, op Operation, x []float64) (Status, err
 		}
 	}
 	copy(x, loc.X)
+	if op&HessVecEvaluation != 0 {
+		loc.HessVec = resize(loc.HessVec, len(x))
+	}
+	if op&PartialGradEvaluation != 0 {
+		loc.PartialGradient = resize(loc.PartialGradient, len(x))
+	}
+	if e != nil {
+		if err := e.Evaluate(op, x, loc); err != nil {
+			return Failure, err
+		}
+		return NotTerminated, nil
+	}
 	if op&FuncEvaluation != 0 {
 		loc.F = p.Func(x)
 	}
@@ -96,6 +125,15 @@ func evaluate(p *Problem, loc *Location, op Operation, x []float64) (Status, err
 	if op&HessEvaluation != 0 {
 		p.Hess(loc.Hessian, x)
 	}
+	if op&ComponentGradEvaluation != 0 {
+		p.ComponentGrad(loc.Gradient, x, loc.Component)
+	}
+	if op&HessVecEvaluation != 0 {
+		p.HessVec(loc.HessVec, x, loc.Direction)
+	}
+	if op&PartialGradEvaluation != 0 {
+		p.PartialGrad(loc.PartialGradient, x, loc.Coordinates)
+	}
 	return NotTerminated, nil
 }
 
@@ -126,10 +164,10 @@ func updateStats(stats *Stats, op Operation) {
 	if op&FuncEvaluation != 0 {
 		stats.FuncEvaluations++
 	}
-	if op&GradEvaluation != 0 {
+	if op&(GradEvaluation|ComponentGradEvaluation|PartialGradEvaluation) != 0 {
 		stats.GradEvaluations++
 	}
-	if op&HessEvaluation != 0 {
+	if op&(HessEvaluation|HessVecEvaluation) != 0 {
 		stats.HessEvaluations++
 	}
 }
@@ -0,0 +1,222 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// MultiObjectiveProblem describes a problem with several objective functions
+// to be minimized simultaneously. The objectives generally conflict, so there
+// is no single minimum but a Pareto front of locations that are not dominated
+// by any other location. A location dominates another one if none of its
+// objectives is larger and at least one is smaller.
+type MultiObjectiveProblem struct {
+	// Func evaluates the objective functions at x and stores them in f,
+	// which has length Objectives. Func must not modify x.
+	Func func(f, x []float64)
+
+	// Jacobian evaluates the derivatives of the objective functions at x
+	// and stores them in jac, which has a row for every objective and a
+	// column for every dimension. Jacobian is optional and is used by the
+	// scalarizations of the problem, see Scalarize. Jacobian must not
+	// modify x.
+	Jacobian func(jac *mat64.Dense, x []float64)
+
+	// Objectives is the number of objective functions. It must be positive.
+	Objectives int
+
+	// Status reports the status of the objective functions being optimized
+	// and any error, as for Problem.
+	Status func() (Status, error)
+}
+
+// MultiObjectiveMethod is a GlobalMethod that searches for the Pareto front
+// of a MultiObjectiveProblem. The objectives at the evaluated locations are
+// received in Location.Objectives.
+type MultiObjectiveMethod interface {
+	GlobalMethod
+	// ParetoFront returns the non-dominated locations among the locations
+	// retained by the method, which may be a subset of all the evaluated
+	// locations. It is called after Done.
+	ParetoFront() []Location
+}
+
+// ParetoResult represents the answer of a multi-objective optimization run.
+// It contains the Pareto front found as well as the Status at termination and
+// the Statistics taken during the run.
+type ParetoResult struct {
+	// Front holds the non-dominated locations found, in increasing order of
+	// their first objective. X and Objectives are set, and F is the value of
+	// the first objective.
+	Front []Location
+	Stats
+	Status Status
+}
+
+// MultiObjective searches for the Pareto front of the multi-objective problem
+// p of dimension dim with method.
+//
+// The optimization runs in Global with the given settings, or with the default
+// global settings if settings is nil, so the limits of settings apply and
+// Concurrent sets the number of concurrent evaluations. As there is no single
+// function value to converge, FunctionThreshold and FunctionConverge are
+// ignored. The evaluations of p.Func are carried out by an Evaluator, and the
+// Evaluator of settings must be nil. The Location of the MajorIterations
+// passed to the Recorder is a member of the current front chosen by method.
+func MultiObjective(p MultiObjectiveProblem, dim int, settings *Settings, method MultiObjectiveMethod) (*ParetoResult, error) {
+	if p.Func == nil {
+		panic("optimize: objective function is undefined")
+	}
+	if p.Objectives < 1 {
+		panic("optimize: number of objectives not positive")
+	}
+	if method == nil {
+		panic("optimize: multi-objective method is undefined")
+	}
+	if settings == nil {
+		settings = DefaultSettingsGlobal()
+	}
+	if settings.Evaluator != nil {
+		return nil, errors.New("optimize: Evaluator not supported for multi-objective problems")
+	}
+	s := *settings
+	s.FunctionThreshold = math.Inf(-1)
+	s.FunctionConverge = nil
+	s.Evaluator = objectiveEvaluator{p}
+
+	result, err := Global(Problem{Status: p.Status}, dim, &s, method)
+	if result == nil {
+		return nil, err
+	}
+	return &ParetoResult{
+		Front:  method.ParetoFront(),
+		Stats:  result.Stats,
+		Status: result.Status,
+	}, err
+}
+
+// objectiveEvaluator evaluates the objectives of a MultiObjectiveProblem.
+type objectiveEvaluator struct {
+	p MultiObjectiveProblem
+}
+
+func (e objectiveEvaluator) Evaluate(op Operation, x []float64, loc *Location) error {
+	if op != FuncEvaluation {
+		return errors.New("optimize: multi-objective problems only support function evaluations")
+	}
+	loc.Objectives = resize(loc.Objectives, e.p.Objectives)
+	e.p.Func(loc.Objectives, x)
+	loc.F = loc.Objectives[0]
+	return nil
+}
+
+// dominates returns whether the objectives a dominate the objectives b.
+func dominates(a, b []float64) bool {
+	var better bool
+	for i, v := range a {
+		if v > b[i] {
+			return false
+		}
+		if v < b[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+// nondominatedSort sorts the objectives f into fronts by the fast
+// non-dominated sorting of NSGA-II. The first front holds the indices of the
+// non-dominated objectives, and every following front holds the indices of the
+// objectives dominated only by those in the previous fronts.
+func nondominatedSort(f [][]float64) [][]int {
+	n := len(f)
+	dominated := make([][]int, n) // Indices dominated by each objective.
+	count := make([]int, n)       // Number of objectives dominating each one.
+	var front []int
+	for i := range f {
+		for j := i + 1; j < n; j++ {
+			switch {
+			case dominates(f[i], f[j]):
+				dominated[i] = append(dominated[i], j)
+				count[j]++
+			case dominates(f[j], f[i]):
+				dominated[j] = append(dominated[j], i)
+				count[i]++
+			}
+		}
+		// All the pairs including i have been compared.
+		if count[i] == 0 {
+			front = append(front, i)
+		}
+	}
+
+	var fronts [][]int
+	for len(front) > 0 {
+		fronts = append(fronts, front)
+		var next []int
+		for _, i := range front {
+			for _, j := range dominated[i] {
+				count[j]--
+				if count[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		front = next
+	}
+	return fronts
+}
+
+// crowdingDistance stores in dist the crowding distances of the objectives f
+// of the members of a front given by their indices. The crowding distance is
+// the sum over the objectives of the distance between the two neighbours,
+// normalized by the range of the front. The extreme members have an infinite
+// distance.
+func crowdingDistance(dist []float64, f [][]float64, front []int) {
+	for _, i := range front {
+		dist[i] = 0
+	}
+	if len(front) == 0 {
+		return
+	}
+	idx := append([]int(nil), front...)
+	for k := range f[front[0]] {
+		sort.Stable(objectiveSorter{idx, f, k})
+		lo, hi := f[idx[0]][k], f[idx[len(idx)-1]][k]
+		dist[idx[0]] = math.Inf(1)
+		dist[idx[len(idx)-1]] = math.Inf(1)
+		if !(hi > lo) || math.IsInf(hi-lo, 0) {
+			continue
+		}
+		for m := 1; m < len(idx)-1; m++ {
+			dist[idx[m]] += (f[idx[m+1]][k] - f[idx[m-1]][k]) / (hi - lo)
+		}
+	}
+}
+
+// objectiveSorter sorts indices of objectives by increasing value of the
+// objective k.
+type objectiveSorter struct {
+	idx []int
+	f   [][]float64
+	k   int
+}
+
+func (s objectiveSorter) Len() int {
+	return len(s.idx)
+}
+
+func (s objectiveSorter) Less(i, j int) bool {
+	return s.f[s.idx[i]][s.k] < s.f[s.idx[j]][s.k]
+}
+
+func (s objectiveSorter) Swap(i, j int) {
+	s.idx[i], s.idx[j] = s.idx[j], s.idx[i]
+}
@@ -0,0 +1,68 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+// NewtonRoot implements Newton's method with a line search for solving
+// systems of nonlinear equations with Root. It generates a sequence of
+// locations x_k by means of
+//  solve J_k d_k = -F_k for d_k,
+//  x_{k+1} = x_k + α_k d_k,
+// where J_k is the Jacobian of F at x_k and the step size α_k is found by a
+// line search on the merit function 1/2 |F(x)|^2, along which the Newton
+// direction is a descent direction. Far from a solution the line search makes
+// the method converge from starting locations where the pure Newton's method
+// diverges, and close to a solution it takes the full steps α_k = 1 and
+// converges quadratically. If J_k is singular, the steepest descent direction
+// -J_k^T F_k of the merit function is used instead. See
+//
+//  Dennis, J.E., Schnabel, R.B.: Numerical Methods for Unconstrained
+//  Optimization and Nonlinear Equations. SIAM (1996), Chapter 6.
+//
+// The method may stall at a local minimum of the merit function that is not a
+// solution, where the line search fails.
+type NewtonRoot struct {
+	// Linesearcher is used for selecting suitable steps along the Newton
+	// direction. It receives only the values of the merit function.
+	// If Linesearcher == nil, Backtracking is used.
+	Linesearcher Linesearcher
+
+	ls *LinesearchMethod
+}
+
+func (n *NewtonRoot) Init(loc *Location) (Operation, error) {
+	if n.Linesearcher == nil {
+		n.Linesearcher = &Backtracking{}
+	}
+	if n.ls == nil {
+		n.ls = &LinesearchMethod{}
+	}
+	n.ls.Linesearcher = n.Linesearcher
+	n.ls.NextDirectioner = n
+
+	return n.ls.Init(loc)
+}
+
+func (n *NewtonRoot) Iterate(loc *Location) (Operation, error) {
+	return n.ls.Iterate(loc)
+}
+
+func (n *NewtonRoot) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	return n.NextDirection(loc, dir)
+}
+
+func (n *NewtonRoot) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	newtonRootStep(dir, loc.Jacobian, loc.Objectives)
+	return 1
+}
+
+func (*NewtonRoot) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
@@ -0,0 +1,801 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultRhoBegin = 0.5
+	defaultRhoEnd   = 1e-8
+
+	// uoaStepTolerance is the relative residual of the model gradient at
+	// which the CG iterations for the trust-region step stop.
+	uoaStepTolerance = 1e-2
+)
+
+// NEWUOA is a model-based derivative-free method for unconstrained
+// minimization of smooth functions that are expensive to evaluate. It
+// follows
+//
+//  Powell, M.J.D.: The NEWUOA software for unconstrained optimization without
+//  derivatives. In: Large-Scale Nonlinear Optimization, Springer (2006),
+//  255-297.
+//
+// NEWUOA minimizes a quadratic model of the function within a trust region.
+// The model interpolates the function values at NumInterpolation points, and
+// of all such quadratics the one is chosen whose Hessian changes least in the
+// Frobenius norm, so that the model needs only O(dim) instead of O(dim^2)
+// function values. Every iteration evaluates the function once and replaces
+// one interpolation point either by the minimizer of the model or, when the
+// points are badly placed, by a point that improves their geometry.
+//
+// The trust-region radius is bounded below by a resolution ρ that decreases
+// from RhoBegin to RhoEnd, and NEWUOA terminates with StepConvergence when
+// the model cannot make progress at the resolution RhoEnd. Unlike Powell's
+// implementation, which updates the inverse of the interpolation system in
+// O(NumInterpolation^2) operations, the system is solved anew at every
+// iteration in O((NumInterpolation+dim)^3) operations, which is negligible
+// compared to expensive function evaluations for dim up to a few hundred.
+//
+// Every trust-region step and every other evaluation that finds a better
+// point is a major iteration, and the location is the best point found so
+// far.
+type NEWUOA struct {
+	// RhoBegin is the initial trust-region radius and the spacing of the
+	// initial interpolation points. It should be about one tenth of the
+	// expected distance to the minimum.
+	// If RhoBegin is zero, it will be set to 0.5.
+	RhoBegin float64
+	// RhoEnd is the final resolution of the trust region, which is about the
+	// accuracy of the returned location.
+	// If RhoEnd is zero, it will be set to 1e-8.
+	// NEWUOA will panic if RhoEnd is negative or larger than RhoBegin.
+	RhoEnd float64
+	// NumInterpolation is the number of interpolation points. It must be
+	// between dim+2 and 2*dim+1.
+	// If NumInterpolation is zero, it will be set to 2*dim+1.
+	NumInterpolation int
+
+	model interpTrustRegion
+}
+
+func (n *NEWUOA) Init(loc *Location) (Operation, error) {
+	rhoBegin, rhoEnd := uoaRadii(n.RhoBegin, n.RhoEnd, "newuoa")
+	npt := uoaNumInterpolation(n.NumInterpolation, len(loc.X), "newuoa")
+	return n.model.init(loc, nil, nil, rhoBegin, rhoEnd, npt)
+}
+
+func (n *NEWUOA) Iterate(loc *Location) (Operation, error) {
+	return n.model.iterate(loc)
+}
+
+// Status returns StepConvergence once the trust region has reached RhoEnd
+// and no further progress is possible.
+func (n *NEWUOA) Status() (Status, error) {
+	if n.model.converged {
+		return StepConvergence, nil
+	}
+	return NotTerminated, nil
+}
+
+func (*NEWUOA) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// BOBYQA is the variant of NEWUOA for bound-constrained minimization described
+// in
+//
+//  Powell, M.J.D.: The BOBYQA algorithm for bound constrained optimization
+//  without derivatives. Report DAMTP 2009/NA06, University of Cambridge (2009).
+//
+// All interpolation points and trial locations lie in the box given by
+// Bounds, so the function is never evaluated outside it. The trust-region
+// step minimizes the model within the box by the truncated CG method, fixing
+// the variables that reach a bound. Apart from that, BOBYQA works as NEWUOA.
+type BOBYQA struct {
+	// Bounds is the box in which the minimum is sought. It must contain one
+	// Bound for every dimension of the problem, and the initial location
+	// must lie in it. BOBYQA will panic if Bounds has the wrong size or if
+	// Min >= Max for any Bound.
+	Bounds []Bound
+	// RhoBegin is the initial trust-region radius and the spacing of the
+	// initial interpolation points. It is reduced to a third of the smallest
+	// width of the box if necessary.
+	// If RhoBegin is zero, it will be set to 0.5.
+	RhoBegin float64
+	// RhoEnd is the final resolution of the trust region.
+	// If RhoEnd is zero, it will be set to 1e-8.
+	// BOBYQA will panic if RhoEnd is negative or larger than RhoBegin.
+	RhoEnd float64
+	// NumInterpolation is the number of interpolation points. It must be
+	// between dim+2 and 2*dim+1.
+	// If NumInterpolation is zero, it will be set to 2*dim+1.
+	NumInterpolation int
+
+	lower, upper []float64
+	model        interpTrustRegion
+}
+
+func (b *BOBYQA) Init(loc *Location) (Operation, error) {
+	rhoBegin, rhoEnd := uoaRadii(b.RhoBegin, b.RhoEnd, "bobyqa")
+	dim := len(loc.X)
+	npt := uoaNumInterpolation(b.NumInterpolation, dim, "bobyqa")
+	if len(b.Bounds) != dim {
+		panic("bobyqa: bounds size mismatch")
+	}
+	b.lower = resize(b.lower, dim)
+	b.upper = resize(b.upper, dim)
+	for i, bound := range b.Bounds {
+		if bound.Min >= bound.Max {
+			panic("bobyqa: invalid bound")
+		}
+		if loc.X[i] < bound.Min || loc.X[i] > bound.Max {
+			return NoOperation, errors.New("bobyqa: initial location outside bounds")
+		}
+		b.lower[i] = bound.Min
+		b.upper[i] = bound.Max
+		// The initial interpolation points must fit into the box.
+		rhoBegin = math.Min(rhoBegin, (bound.Max-bound.Min)/3)
+	}
+	rhoEnd = math.Min(rhoEnd, rhoBegin)
+	return b.model.init(loc, b.lower, b.upper, rhoBegin, rhoEnd, npt)
+}
+
+func (b *BOBYQA) Iterate(loc *Location) (Operation, error) {
+	return b.model.iterate(loc)
+}
+
+// Status returns StepConvergence once the trust region has reached RhoEnd
+// and no further progress is possible.
+func (b *BOBYQA) Status() (Status, error) {
+	if b.model.converged {
+		return StepConvergence, nil
+	}
+	return NotTerminated, nil
+}
+
+func (*BOBYQA) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+// uoaRadii returns RhoBegin and RhoEnd with their defaults applied.
+func uoaRadii(rhoBegin, rhoEnd float64, name string) (float64, float64) {
+	if rhoBegin == 0 {
+		rhoBegin = defaultRhoBegin
+	}
+	if rhoEnd == 0 {
+		rhoEnd = math.Min(defaultRhoEnd, rhoBegin)
+	}
+	if !(rhoBegin > 0) {
+		panic(name + ": negative RhoBegin")
+	}
+	if rhoEnd < 0 || rhoEnd > rhoBegin {
+		panic(name + ": invalid RhoEnd")
+	}
+	return rhoBegin, rhoEnd
+}
+
+// uoaNumInterpolation returns NumInterpolation with its default applied.
+func uoaNumInterpolation(npt, dim int, name string) int {
+	if npt == 0 {
+		return 2*dim + 1
+	}
+	if npt < dim+2 || npt > 2*dim+1 {
+		panic(name + ": invalid NumInterpolation")
+	}
+	return npt
+}
+
+// uoaStage is the kind of the last evaluation of interpTrustRegion.
+type uoaStage int
+
+const (
+	uoaInit     uoaStage = iota // Evaluation of an initial interpolation point.
+	uoaTrust                    // Evaluation of a trust-region step.
+	uoaGeometry                 // Evaluation of a point that improves the geometry.
+	uoaMajor                    // MajorIteration has been announced.
+)
+
+// interpTrustRegion implements the iterations of NEWUOA and BOBYQA. It keeps
+// the quadratic model
+//  q(x_opt + d) = c + g·d + 1/2 d·H d
+// around the best interpolation point x_opt. The model interpolates the
+// function values at the points y_i, and every update minimizes the Frobenius
+// norm of the change of H subject to the interpolation conditions. The
+// change is Σ λ_i (y_i-x_opt)(y_i-x_opt)^T where λ together with the changes
+// of c and g solves the symmetric system
+//  [ A   X^T ] [ λ  ]   [ r ]
+//  [ X   0   ] [ δc ] = [ 0 ]
+//               [ δg ]
+// with A_ij = 1/2 ((y_i-x_opt)·(y_j-x_opt))^2, the columns [1; y_i-x_opt] of
+// X, and the residuals r_i = f(y_i) - q(y_i). The same system with the
+// right-hand side w(x) = [1/2 ((y_i-x_opt)·(x-x_opt))^2; 1; x-x_opt] yields
+// the values at x of the Lagrange functions of the interpolation, which
+// measure how well the points determine the model if one of them is replaced
+// by x.
+type interpTrustRegion struct {
+	lower, upper []float64 // Bounds of the variables, nil if unconstrained.
+	rhoEnd       float64
+
+	stage     uoaStage
+	rho       float64 // Resolution, the lower bound of the trust-region radius.
+	delta     float64 // Trust-region radius.
+	converged bool
+
+	pts  [][]float64 // Interpolation points.
+	fval []float64   // Function values at pts.
+	opt  int         // Index of the interpolation point with the lowest value.
+	k    int         // Index of the evaluated point during initialization or a geometry step.
+
+	// The check flag requests a test of the geometry of the interpolation
+	// points before the next trust-region step, and reduce requests a
+	// reduction of rho if the geometry is good.
+	check, reduce bool
+
+	c    float64
+	g    []float64
+	hess *mat64.SymDense
+
+	s     []float64 // Trial step from pts[opt].
+	snorm float64   // Length of s.
+	pred  float64   // Reduction of f predicted by the model.
+
+	// Storage for the interpolation system. p holds the scaled interpolation
+	// points relative to pts[opt].
+	p     [][]float64
+	sigma float64
+	kkt   *mat64.Dense
+	rhs   *mat64.Vector
+	sol   mat64.Vector
+
+	// Storage for the truncated CG method.
+	r, d, hd []float64
+	free     []bool
+
+	gOld []float64
+}
+
+func (u *interpTrustRegion) init(loc *Location, lower, upper []float64, rhoBegin, rhoEnd float64, npt int) (Operation, error) {
+	dim := len(loc.X)
+	u.lower, u.upper = lower, upper
+	u.rhoEnd = rhoEnd
+	u.rho = rhoBegin
+	u.delta = rhoBegin
+	u.converged = false
+	u.check, u.reduce = false, false
+
+	if cap(u.pts) < npt {
+		u.pts = make([][]float64, npt)
+		u.p = make([][]float64, npt)
+	}
+	u.pts = u.pts[:npt]
+	u.p = u.p[:npt]
+	for i := range u.pts {
+		u.pts[i] = resize(u.pts[i], dim)
+		u.p[i] = resize(u.p[i], dim)
+	}
+	u.fval = resize(u.fval, npt)
+	u.g = resize(u.g, dim)
+	u.gOld = resize(u.gOld, dim)
+	u.hess = resizeSymDense(u.hess, dim)
+	u.s = resize(u.s, dim)
+	u.r = resize(u.r, dim)
+	u.d = resize(u.d, dim)
+	u.hd = resize(u.hd, dim)
+	if cap(u.free) < dim {
+		u.free = make([]bool, dim)
+	}
+	u.free = u.free[:dim]
+	size := npt + dim + 1
+	u.kkt = mat64.NewDense(size, size, nil)
+	u.rhs = mat64.NewVector(size, nil)
+	u.sol.Reset()
+
+	// The initial points are x_0 and x_0 + a_i e_i for every dimension, and
+	// x_0 + b_i e_i for the first npt-dim-1 dimensions, with a_i = ρ and
+	// b_i = -ρ unless a bound is closer than ρ, in which case both points lie
+	// on the side away from it.
+	copy(u.pts[0], loc.X)
+	u.fval[0] = loc.F
+	for i := 0; i < dim; i++ {
+		a, b := rhoBegin, -rhoBegin
+		if upper != nil {
+			switch {
+			case upper[i]-loc.X[i] < rhoBegin:
+				a, b = -rhoBegin, -2*rhoBegin
+			case loc.X[i]-lower[i] < rhoBegin:
+				a, b = rhoBegin, 2*rhoBegin
+			}
+		}
+		copy(u.pts[i+1], loc.X)
+		u.pts[i+1][i] += a
+		if j := dim + 1 + i; j < npt {
+			copy(u.pts[j], loc.X)
+			u.pts[j][i] += b
+		}
+	}
+	u.k = 1
+	u.stage = uoaInit
+	copy(loc.X, u.pts[1])
+	return FuncEvaluation, nil
+}
+
+func (u *interpTrustRegion) iterate(loc *Location) (Operation, error) {
+	switch u.stage {
+	case uoaInit:
+		u.fval[u.k] = loc.F
+		u.k++
+		if u.k < len(u.pts) {
+			copy(loc.X, u.pts[u.k])
+			return FuncEvaluation, nil
+		}
+		u.opt = floats.MinIdx(u.fval)
+		u.c = 0
+		for i := range u.g {
+			u.g[i] = 0
+		}
+		u.hess.ScaleSym(0, u.hess)
+		if !u.fit() {
+			return NoOperation, errors.New("optimize: degenerate initial interpolation points")
+		}
+	case uoaTrust:
+		u.trustEvaluated(loc.X, loc.F)
+	case uoaGeometry:
+		// Geometry steps only maintain the model, so they are major
+		// iterations only if they find a better point.
+		fopt := u.fval[u.opt]
+		u.replace(u.k, loc.X, loc.F)
+		if !(u.fval[u.opt] < fopt) {
+			return u.next(loc), nil
+		}
+	case uoaMajor:
+		return u.next(loc), nil
+	}
+	u.stage = uoaMajor
+	copy(loc.X, u.pts[u.opt])
+	loc.F = u.fval[u.opt]
+	return MajorIteration, nil
+}
+
+// next computes the next location to be evaluated and stores it in loc.X. It
+// returns NoOperation if the method has converged.
+func (u *interpTrustRegion) next(loc *Location) Operation {
+	for {
+		if u.check {
+			u.check = false
+			k, dist := u.farthest()
+			if dist > 2*u.delta {
+				u.geometryStep(loc.X, k, dist)
+				u.k = k
+				u.stage = uoaGeometry
+				return FuncEvaluation
+			}
+			if u.reduce && !u.reduceRho() {
+				u.converged = true
+				return NoOperation
+			}
+		}
+
+		u.step()
+		u.snorm = floats.Norm(u.s, 2)
+		u.pred = -floats.Dot(u.g, u.s) - 0.5*u.quad(u.s)
+		if u.snorm < 0.5*u.rho || !(u.pred > 0) {
+			// The model predicts no progress at the current resolution, so
+			// either its geometry or the resolution is improved.
+			u.delta = math.Max(0.1*u.delta, u.rho)
+			if u.delta <= 1.5*u.rho {
+				u.delta = u.rho
+			}
+			u.check, u.reduce = true, true
+			continue
+		}
+		floats.AddTo(loc.X, u.pts[u.opt], u.s)
+		u.clip(loc.X)
+		u.stage = uoaTrust
+		return FuncEvaluation
+	}
+}
+
+// trustEvaluated updates the trust-region radius and the interpolation with
+// the value f at the trial location x = pts[opt] + s.
+func (u *interpTrustRegion) trustEvaluated(x []float64, f float64) {
+	ratio := (u.fval[u.opt] - f) / u.pred
+	switch {
+	case !(ratio > 0.1):
+		u.delta = 0.5 * u.snorm
+	case ratio <= 0.7:
+		u.delta = math.Max(0.5*u.delta, u.snorm)
+	default:
+		u.delta = math.Max(0.5*u.delta, 2*u.snorm)
+	}
+	if u.delta <= 1.5*u.rho {
+		u.delta = u.rho
+	}
+	if !math.IsNaN(f) && !math.IsInf(f, 1) {
+		if k := u.replacement(x, f); k >= 0 {
+			u.replace(k, x, f)
+		}
+	}
+	if !(ratio > 0.1) {
+		u.check = true
+		u.reduce = !(ratio > 0) && math.Max(u.delta, u.snorm) <= u.rho
+	}
+}
+
+// replacement returns the index of the interpolation point that is replaced
+// by the new point x with the value f, or -1 if no point can be replaced.
+// Points with large Lagrange functions at x, whose replacement keeps the
+// interpolation system well conditioned, and points far from the best point
+// are preferred.
+func (u *interpTrustRegion) replacement(x []float64, f float64) int {
+	u.scalePoints()
+	u.weights(x)
+	if !u.solve() {
+		return -1
+	}
+	ref := u.pts[u.opt]
+	if f < u.fval[u.opt] {
+		ref = x
+	}
+	r := math.Max(0.1*u.delta, u.rho)
+	k := -1
+	var best float64
+	for i, y := range u.pts {
+		if i == u.opt && f >= u.fval[u.opt] {
+			continue
+		}
+		w := math.Max(1, floats.Distance(y, ref, 2)/r)
+		score := math.Abs(u.sol.At(i, 0)) * w * w * w * w
+		if score > best {
+			k, best = i, score
+		}
+	}
+	return k
+}
+
+// replace replaces the interpolation point k by x with the value f and
+// updates the model. The replacement is undone if the new interpolation
+// system is singular.
+func (u *interpTrustRegion) replace(k int, x []float64, f float64) {
+	oldOpt, oldC, oldF := u.opt, u.c, u.fval[k]
+	copy(u.gOld, u.g)
+	copy(u.s, x)
+	u.pts[k], u.s = u.s, u.pts[k]
+	u.fval[k] = f
+	if f < u.fval[u.opt] {
+		// Move the center of the model to x. If the best point is replaced,
+		// its old location is in u.s.
+		if k == u.opt {
+			floats.SubTo(u.d, x, u.s)
+		} else {
+			floats.SubTo(u.d, x, u.pts[u.opt])
+		}
+		u.hessVec(u.hd, u.d)
+		u.c += floats.Dot(u.g, u.d) + 0.5*floats.Dot(u.d, u.hd)
+		floats.Add(u.g, u.hd)
+		u.opt = k
+	}
+	if u.fit() {
+		return
+	}
+	u.pts[k], u.s = u.s, u.pts[k]
+	u.fval[k] = oldF
+	u.opt, u.c = oldOpt, oldC
+	copy(u.g, u.gOld)
+}
+
+// fit updates the model with the least Frobenius norm change of its Hessian
+// so that it interpolates the function values at all points. It returns
+// false if the interpolation system is singular.
+func (u *interpTrustRegion) fit() bool {
+	u.scalePoints()
+	npt := len(u.pts)
+	for i, p := range u.p {
+		// The points are scaled by sigma.
+		copy(u.d, p)
+		floats.Scale(u.sigma, u.d)
+		u.rhs.SetVec(i, u.fval[i]-u.c-floats.Dot(u.g, u.d)-0.5*u.quad(u.d))
+	}
+	for i := npt; i < u.rhs.Len(); i++ {
+		u.rhs.SetVec(i, 0)
+	}
+	if !u.solve() {
+		return false
+	}
+	u.c += u.sol.At(npt, 0)
+	for i := range u.g {
+		u.g[i] += u.sol.At(npt+1+i, 0) / u.sigma
+	}
+	for i, p := range u.p {
+		u.hess.SymRankOne(u.hess, u.sol.At(i, 0)/(u.sigma*u.sigma), mat64.NewVector(len(p), p))
+	}
+	return true
+}
+
+// scalePoints stores the interpolation points relative to the best point and
+// divided by their largest distance from it in u.p, and builds the
+// interpolation system.
+func (u *interpTrustRegion) scalePoints() {
+	u.sigma = 0
+	for i, y := range u.pts {
+		floats.SubTo(u.p[i], y, u.pts[u.opt])
+		u.sigma = math.Max(u.sigma, floats.Norm(u.p[i], 2))
+	}
+	npt := len(u.p)
+	for _, p := range u.p {
+		floats.Scale(1/u.sigma, p)
+	}
+	for i, p := range u.p {
+		for j := 0; j <= i; j++ {
+			v := floats.Dot(p, u.p[j])
+			v *= 0.5 * v
+			u.kkt.Set(i, j, v)
+			u.kkt.Set(j, i, v)
+		}
+		u.kkt.Set(i, npt, 1)
+		u.kkt.Set(npt, i, 1)
+		for j, v := range p {
+			u.kkt.Set(i, npt+1+j, v)
+			u.kkt.Set(npt+1+j, i, v)
+		}
+	}
+	for i := npt; i < len(u.p[0])+npt+1; i++ {
+		for j := npt; j < len(u.p[0])+npt+1; j++ {
+			u.kkt.Set(i, j, 0)
+		}
+	}
+}
+
+// weights stores the right-hand side w(x) of the interpolation system whose
+// solution holds the values of the Lagrange functions at x.
+func (u *interpTrustRegion) weights(x []float64) {
+	floats.SubTo(u.d, x, u.pts[u.opt])
+	floats.Scale(1/u.sigma, u.d)
+	npt := len(u.p)
+	for i, p := range u.p {
+		v := floats.Dot(p, u.d)
+		u.rhs.SetVec(i, 0.5*v*v)
+	}
+	u.rhs.SetVec(npt, 1)
+	for i, v := range u.d {
+		u.rhs.SetVec(npt+1+i, v)
+	}
+}
+
+func (u *interpTrustRegion) solve() bool {
+	if err := u.sol.SolveVec(u.kkt, u.rhs); err != nil {
+		return false
+	}
+	for i := 0; i < u.sol.Len(); i++ {
+		if v := u.sol.At(i, 0); math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// farthest returns the index of the interpolation point farthest from the
+// best point and its distance.
+func (u *interpTrustRegion) farthest() (int, float64) {
+	var k int
+	var dist float64
+	for i, y := range u.pts {
+		if d := floats.Distance(y, u.pts[u.opt], 2); d > dist {
+			k, dist = i, d
+		}
+	}
+	return k, dist
+}
+
+// geometryStep stores in x the point within a distance of about
+// max(min(0.1*dist, 0.5*Δ), ρ) from the best point that approximately
+// maximizes the modulus of the Lagrange function of the interpolation point
+// k, which lies at distance dist. Replacing the point k by x improves the
+// conditioning of the interpolation.
+func (u *interpTrustRegion) geometryStep(x []float64, k int, dist float64) {
+	radius := math.Max(math.Min(0.1*dist, 0.5*u.delta), u.rho)
+	// The coefficients of the Lagrange function ℓ_k in the scaled variables
+	// solve the interpolation system with the k-th unit vector as the
+	// right-hand side.
+	u.scalePoints()
+	for i := 0; i < u.rhs.Len(); i++ {
+		u.rhs.SetVec(i, 0)
+	}
+	u.rhs.SetVec(k, 1)
+	if !u.solve() {
+		// Move the point toward the best point.
+		floats.SubTo(x, u.pts[k], u.pts[u.opt])
+		floats.Scale(radius/dist, x)
+		floats.Add(x, u.pts[u.opt])
+		u.clip(x)
+		return
+	}
+	npt := len(u.p)
+	lagrange := func(x []float64) float64 {
+		floats.SubTo(u.d, x, u.pts[u.opt])
+		floats.Scale(1/u.sigma, u.d)
+		l := u.sol.At(npt, 0)
+		for i, v := range u.d {
+			l += u.sol.At(npt+1+i, 0) * v
+		}
+		for i, p := range u.p {
+			v := floats.Dot(p, u.d)
+			l += 0.5 * u.sol.At(i, 0) * v * v
+		}
+		return math.Abs(l)
+	}
+
+	// The candidates are the points at the distance radius along the
+	// gradient of ℓ_k at the best point and along the direction to the
+	// point k.
+	for i := range u.r {
+		u.r[i] = u.sol.At(npt+1+i, 0)
+	}
+	floats.SubTo(u.hd, u.pts[k], u.pts[u.opt])
+	var best float64
+	for _, dir := range [][]float64{u.r, u.hd} {
+		norm := floats.Norm(dir, 2)
+		if norm == 0 {
+			continue
+		}
+		for _, sign := range []float64{1, -1} {
+			floats.AddScaledTo(u.s, u.pts[u.opt], sign*radius/norm, dir)
+			u.clip(u.s)
+			if l := lagrange(u.s); l > best || best == 0 {
+				best = l
+				copy(x, u.s)
+			}
+		}
+	}
+}
+
+// reduceRho reduces the resolution and the trust-region radius. It returns
+// false if the resolution has already reached rhoEnd.
+func (u *interpTrustRegion) reduceRho() bool {
+	if u.rho <= u.rhoEnd {
+		return false
+	}
+	old := u.rho
+	switch ratio := u.rho / u.rhoEnd; {
+	case ratio <= 16:
+		u.rho = u.rhoEnd
+	case ratio <= 250:
+		u.rho = math.Sqrt(u.rho * u.rhoEnd)
+	default:
+		u.rho *= 0.1
+	}
+	u.delta = math.Max(0.5*old, u.rho)
+	return true
+}
+
+// step approximately minimizes the model within the trust region and the
+// bounds by the truncated CG method and stores the step in u.s. A variable
+// that reaches a bound is fixed there and the CG iterations are restarted
+// for the remaining ones. The iterations stop at the boundary of the trust
+// region, or when the residual is reduced by the factor uoaStepTolerance.
+func (u *interpTrustRegion) step() {
+	x := u.pts[u.opt]
+	for i := range u.s {
+		u.s[i] = 0
+		u.free[i] = u.lower == nil ||
+			!(x[i] <= u.lower[i] && u.g[i] >= 0 || x[i] >= u.upper[i] && u.g[i] <= 0)
+	}
+	copy(u.r, u.g)
+	u.mask(u.r)
+	tol := uoaStepTolerance * floats.Norm(u.r, 2)
+
+	for restart := 0; restart <= len(u.s); restart++ {
+		u.mask(u.r)
+		copy(u.d, u.r)
+		floats.Scale(-1, u.d)
+		rr := floats.Dot(u.r, u.r)
+		if math.Sqrt(rr) <= tol {
+			return
+		}
+		hit := false
+		for k := 0; k < len(u.s); k++ {
+			u.hessVec(u.hd, u.d)
+			dhd := floats.Dot(u.d, u.hd)
+			tau := boundaryStep(u.s, u.d, u.delta)
+			alpha := tau
+			if dhd > 0 {
+				alpha = math.Min(rr/dhd, tau)
+			}
+			// Limit the step by the bounds.
+			bound := -1
+			if u.lower != nil {
+				for i, v := range u.d {
+					var lim float64
+					switch {
+					case !u.free[i] || v == 0:
+						continue
+					case v > 0:
+						lim = (u.upper[i] - x[i] - u.s[i]) / v
+					default:
+						lim = (u.lower[i] - x[i] - u.s[i]) / v
+					}
+					lim = math.Max(lim, 0)
+					if lim < alpha {
+						alpha, bound = lim, i
+					}
+				}
+			}
+			floats.AddScaled(u.s, alpha, u.d)
+			floats.AddScaled(u.r, alpha, u.hd)
+			if bound >= 0 {
+				if u.d[bound] > 0 {
+					u.s[bound] = u.upper[bound] - x[bound]
+				} else {
+					u.s[bound] = u.lower[bound] - x[bound]
+				}
+				u.free[bound] = false
+				hit = true
+				break
+			}
+			if alpha == tau {
+				return
+			}
+			u.mask(u.r)
+			rrNew := floats.Dot(u.r, u.r)
+			if math.Sqrt(rrNew) <= tol {
+				return
+			}
+			floats.Scale(rrNew/rr, u.d)
+			floats.AddScaled(u.d, -1, u.r)
+			rr = rrNew
+		}
+		if !hit {
+			return
+		}
+	}
+}
+
+// mask sets the elements of v for fixed variables to zero.
+func (u *interpTrustRegion) mask(v []float64) {
+	for i, free := range u.free {
+		if !free {
+			v[i] = 0
+		}
+	}
+}
+
+// clip moves x into the bounds.
+func (u *interpTrustRegion) clip(x []float64) {
+	if u.lower == nil {
+		return
+	}
+	for i := range x {
+		x[i] = math.Max(u.lower[i], math.Min(x[i], u.upper[i]))
+	}
+}
+
+// hessVec stores H*v in dst.
+func (u *interpTrustRegion) hessVec(dst, v []float64) {
+	mat64.NewVector(len(dst), dst).MulVec(u.hess, mat64.NewVector(len(v), v))
+}
+
+// quad returns v·H v.
+func (u *interpTrustRegion) quad(v []float64) float64 {
+	u.hessVec(u.hd, v)
+	return floats.Dot(v, u.hd)
+}
@@ -0,0 +1,119 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestNEWUOANumInterpolation(t *testing.T) {
+	for _, test := range gradFreeTests {
+		testLocalConverged(t, []unconstrainedTest{test}, &NEWUOA{NumInterpolation: len(test.x) + 2}, StepConvergence)
+	}
+}
+
+func TestNEWUOAEvaluations(t *testing.T) {
+	// A convex quadratic in 20 dimensions whose minimum is
+	// 400 / (1 + Σ 1/i).
+	const dim = 20
+	f := func(x []float64) float64 {
+		var f, sum float64
+		for i, v := range x {
+			f += float64(i+1) * (v - 1) * (v - 1)
+			sum += v
+		}
+		return f + sum*sum
+	}
+	var harmonic float64
+	for i := 1; i <= dim; i++ {
+		harmonic += 1 / float64(i)
+	}
+	want := 400 / (1 + harmonic)
+
+	p := Problem{Func: f}
+	x := make([]float64, dim)
+	result, err := Local(p, x, nil, &NEWUOA{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(result.F-want) > 1e-6 {
+		t.Errorf("unexpected minimum: got %v, want %v", result.F, want)
+	}
+	nm, err := Local(p, x, nil, &NelderMead{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.FuncEvaluations >= nm.FuncEvaluations || result.F > nm.F {
+		t.Errorf("NEWUOA not better than NelderMead: %v evaluations to %v, NelderMead %v evaluations to %v",
+			result.FuncEvaluations, result.F, nm.FuncEvaluations, nm.F)
+	}
+}
+
+func TestBOBYQA(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradFreeTests...)
+	tests = append(tests, gradientDescentTests...)
+	for _, test := range tests {
+		// The bounds are inactive at the minimum.
+		bounds := make([]Bound, len(test.x))
+		for i := range bounds {
+			bounds[i] = Bound{-1000, 1000}
+		}
+		testLocalConverged(t, []unconstrainedTest{test}, &BOBYQA{Bounds: bounds}, StepConvergence)
+	}
+}
+
+func TestBOBYQABounds(t *testing.T) {
+	// A quadratic whose unconstrained minimum lies outside the box in some
+	// dimensions.
+	c := []float64{1, 10, 100, 1000, 1e4}
+	target := []float64{0.5, -1, 2, 0.25, 3}
+	bounds := []Bound{{0, 1}, {0, 1}, {0, 1}, {0, 1}, {-1, 1}}
+	var outside bool
+	p := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i, v := range x {
+				if v < bounds[i].Min || v > bounds[i].Max {
+					outside = true
+				}
+				f += c[i] * (v - target[i]) * (v - target[i])
+			}
+			return f
+		},
+	}
+	want := make([]float64, len(target))
+	for i, v := range target {
+		want[i] = math.Max(bounds[i].Min, math.Min(v, bounds[i].Max))
+	}
+
+	for _, x := range [][]float64{
+		{0.5, 0.5, 0.5, 0.5, 0},
+		{0, 1, 0.9, 0, 1}, // On the bounds.
+	} {
+		result, err := Local(p, x, nil, &BOBYQA{Bounds: bounds})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result.Status != StepConvergence {
+			t.Errorf("unexpected status: %v", result.Status)
+		}
+		if !floats.EqualApprox(result.X, want, 1e-6) {
+			t.Errorf("unexpected minimum: got %v, want %v", result.X, want)
+		}
+		if outside {
+			t.Errorf("function evaluated outside bounds")
+		}
+	}
+
+	_, err := Local(p, []float64{0.5, 0.5, 0.5, 2, 0}, nil, &BOBYQA{Bounds: bounds})
+	if err == nil {
+		t.Errorf("no error for initial location outside bounds")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const (
+	defaultNonmonotoneMemory = 10
+)
+
+// Nonmonotone is a NonmonotoneLinesearcher that uses backtracking to find
+// a point that satisfies the nonmonotone Armijo condition
+//  φ(step) <= ref + DecreaseFactor * step * φ'(0),
+// where ref is a reference value computed from the function values at the
+// starting points of the current and the previous line searches. If the
+// condition has not been met, the step size is decreased by
+// ContractionFactor.
+//
+// If Averaging is zero, ref is the maximum of the last Memory function values
+// as in
+//
+//  Grippo, L., Lampariello, F., Lucidi, S.: A nonmonotone line search
+//  technique for Newton's method. SIAM J. Numer. Anal. 23 (1986), 707-716.
+//
+// Otherwise, ref is the weighted average C_k of all the function values f_k
+// of
+//
+//  Zhang, H., Hager, W.W.: A nonmonotone line search technique and its
+//  application to unconstrained optimization. SIAM J. Optim. 14 (2004),
+//  1043-1056.
+//
+// updated as
+//  Q_k = Averaging * Q_{k-1} + 1,
+//  C_k = (Averaging * Q_{k-1} * C_{k-1} + f_k) / Q_k,
+// with Q_0 = 1 and C_0 = f_0.
+//
+// The objective function may increase at some iterations, which helps methods
+// such as GradientDescent to follow curved valleys with longer steps, while
+// the reference values decrease over the iterations. Like Backtracking,
+// Nonmonotone only requires the gradient at the beginning of each major
+// iteration and is not appropriate for optimizers that require the Wolfe
+// conditions to be met.
+//
+// DecreaseFactor and ContractionFactor must be between zero and one, and if
+// either is zero, it will be set to the default of Backtracking. Memory must
+// not be negative, and if it is zero, it will be set to 10. Averaging must be
+// in [0, 1]. With Memory equal to one and Averaging equal to zero, Nonmonotone
+// is equivalent to Backtracking.
+type Nonmonotone struct {
+	DecreaseFactor    float64 // Constant factor in the sufficient decrease condition.
+	ContractionFactor float64 // Step size multiplier at each iteration (step *= ContractionFactor).
+	Memory            int     // Number of function values of the maximum rule.
+	Averaging         float64 // Weight of the previous values in the average rule.
+
+	history []float64 // Last Memory function values, oldest first.
+	q, c    float64   // Q_k and C_k of the average rule.
+
+	stepSize float64
+	ref      float64
+	initG    float64
+
+	lastOp Operation
+}
+
+// ResetHistory discards the function values of the previous line searches.
+func (n *Nonmonotone) ResetHistory() {
+	n.history = n.history[:0]
+	n.q = 0
+}
+
+func (n *Nonmonotone) Init(f, g float64, step float64) Operation {
+	if step <= 0 {
+		panic("nonmonotone: bad step size")
+	}
+	if g >= 0 {
+		panic("nonmonotone: initial derivative is non-negative")
+	}
+
+	if n.ContractionFactor == 0 {
+		n.ContractionFactor = defaultBacktrackingContraction
+	}
+	if n.DecreaseFactor == 0 {
+		n.DecreaseFactor = defaultBacktrackingDecrease
+	}
+	if n.Memory == 0 {
+		n.Memory = defaultNonmonotoneMemory
+	}
+	if n.ContractionFactor <= 0 || n.ContractionFactor >= 1 {
+		panic("nonmonotone: ContractionFactor must be between 0 and 1")
+	}
+	if n.DecreaseFactor <= 0 || n.DecreaseFactor >= 1 {
+		panic("nonmonotone: DecreaseFactor must be between 0 and 1")
+	}
+	if n.Memory < 0 {
+		panic("nonmonotone: negative Memory")
+	}
+	if n.Averaging < 0 || n.Averaging > 1 {
+		panic("nonmonotone: Averaging must be between 0 and 1")
+	}
+
+	if n.Averaging == 0 {
+		if len(n.history) == n.Memory {
+			n.history = append(n.history[:0], n.history[1:]...)
+		}
+		n.history = append(n.history, f)
+		n.ref = math.Inf(-1)
+		for _, v := range n.history {
+			n.ref = math.Max(n.ref, v)
+		}
+	} else {
+		if n.q == 0 {
+			n.q = 1
+			n.c = f
+		} else {
+			q := n.Averaging*n.q + 1
+			n.c = (n.Averaging*n.q*n.c + f) / q
+			n.q = q
+		}
+		n.ref = n.c
+	}
+
+	n.stepSize = step
+	n.initG = g
+
+	n.lastOp = FuncEvaluation
+	return n.lastOp
+}
+
+func (n *Nonmonotone) Iterate(f, _ float64) (Operation, float64, error) {
+	if n.lastOp != FuncEvaluation {
+		panic("nonmonotone: Init has not been called")
+	}
+
+	if ArmijoConditionMet(f, n.ref, n.initG, n.stepSize, n.DecreaseFactor) {
+		n.lastOp = MajorIteration
+		return n.lastOp, n.stepSize, nil
+	}
+	n.stepSize *= n.ContractionFactor
+	if n.stepSize < minimumBacktrackingStepSize {
+		n.lastOp = NoOperation
+		return n.lastOp, n.stepSize, ErrLinesearcherFailure
+	}
+	n.lastOp = FuncEvaluation
+	return n.lastOp, n.stepSize, nil
+}
@@ -0,0 +1,365 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/stat/distmv"
+)
+
+// NSGAII is the elitist non-dominated sorting genetic algorithm of
+//
+//  Deb, K., Pratap, A., Agarwal, S., Meyarivan, T.: A fast and elitist
+//  multiobjective genetic algorithm: NSGA-II. IEEE Trans. Evol. Comput. 6
+//  (2002), 182-197.
+//
+// NSGAII is a MultiObjectiveMethod for real-valued variables within Bounds.
+// The individuals are ranked by their non-dominated front, and individuals of
+// the same front by their crowding distance, which measures how isolated they
+// are along the front. The parents of every generation are chosen by binary
+// tournaments on the rank, recombined by Crossover with probability
+// CrossoverRate and modified by Mutation. The next generation consists of the
+// best ranked individuals among the parents and the children together.
+//
+// The children of a generation are evaluated concurrently by the tasks of
+// Global, and the next generation is created only when all of them are known.
+// NaN objectives are treated as +Inf. A MajorIteration announces the
+// individual of the first front with the smallest first objective after every
+// generation. If NSGAII is used with Global on a Problem instead of with
+// MultiObjective, F is the single objective.
+type NSGAII struct {
+	// Bounds is the box in which the Pareto front is sought. It must contain
+	// one Bound for every dimension of the problem, and NSGAII will panic if
+	// Bounds has the wrong size or if Min >= Max for any Bound.
+	Bounds []Bound
+	// PopulationSize is the number of individuals in a generation.
+	// If PopulationSize is zero, it will be set to the larger of 20 and
+	// 10*dim.
+	PopulationSize int
+	// CrossoverRate is the probability that the parents are recombined. If
+	// they are not, the children are copies of the parents before mutation.
+	// If CrossoverRate is zero, it will be set to 0.9.
+	CrossoverRate float64
+
+	// Crossover recombines the parents.
+	// If Crossover is nil, SimulatedBinaryCrossover is used.
+	Crossover Crossover
+	// Mutation modifies the children.
+	// If Mutation is nil, PolynomialMutation is used.
+	Mutation Mutation
+	// Sampler generates the individuals of the first generation within
+	// Bounds. The individuals are moved within the bounds if necessary.
+	// If Sampler is nil, the first generation is uniformly random.
+	Sampler distmv.Rander
+
+	// Src is the source of random numbers. If Src is nil, a source seeded
+	// from the global source of the math/rand package is used.
+	Src *rand.Rand
+
+	src       *rand.Rand
+	enc       []Encoding
+	size      int
+	rate      float64
+	crossover Crossover
+	mutation  Mutation
+
+	// objectives is the number of Objectives of the evaluated locations,
+	// 0 for a Problem, or -1 before the first evaluation.
+	objectives int
+
+	eval batchEvaluator
+
+	pop       [][]float64 // Individuals of the current generation.
+	popF      [][]float64
+	rank      []int
+	crowding  []float64
+	children  [][]float64 // Individuals waiting for evaluation.
+	childF    [][]float64
+	childDone []bool // Whether each child has been evaluated.
+}
+
+func (n *NSGAII) Needs() struct{ Gradient, Hessian bool } {
+	return struct{ Gradient, Hessian bool }{false, false}
+}
+
+func (n *NSGAII) InitGlobal(dim, tasks int) int {
+	if tasks < 1 {
+		tasks = 1
+	}
+	if len(n.Bounds) != dim {
+		panic("nsga2: bounds size mismatch")
+	}
+	for _, b := range n.Bounds {
+		if !(b.Min < b.Max) {
+			panic("nsga2: invalid bound")
+		}
+	}
+	n.enc = resizeEncodings(n.enc, dim)
+
+	n.size = n.PopulationSize
+	if n.size == 0 {
+		n.size = 10 * dim
+		if n.size < minPopulationSize {
+			n.size = minPopulationSize
+		}
+	}
+	if n.size < 2 {
+		panic("nsga2: population size less than 2")
+	}
+	if n.CrossoverRate < 0 || n.CrossoverRate > 1 {
+		panic("nsga2: CrossoverRate out of range")
+	}
+	n.rate = n.CrossoverRate
+	if n.rate == 0 {
+		n.rate = defaultCrossoverRate
+	}
+	n.crossover = n.Crossover
+	if n.crossover == nil {
+		n.crossover = SimulatedBinaryCrossover{}
+	}
+	n.mutation = n.Mutation
+	if n.mutation == nil {
+		n.mutation = PolynomialMutation{}
+	}
+	n.src = n.Src
+	if n.src == nil {
+		n.src = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	n.objectives = -1
+	n.pop = n.pop[:0]
+	n.popF = n.popF[:0]
+	n.children = n.children[:0]
+	for i := 0; i < n.size; i++ {
+		x := make([]float64, dim)
+		if n.Sampler != nil {
+			n.Sampler.Rand(x)
+			n.repair(x)
+		} else {
+			for j := range x {
+				x[j] = randomGene(n.Bounds[j], RealEncoding, n.src)
+			}
+		}
+		n.children = append(n.children, x)
+	}
+	n.eval.init(tasks, n.newBatch())
+	return tasks
+}
+
+func (n *NSGAII) IterateGlobal(task int, loc *Location) (Operation, error) {
+	return n.eval.iterate(n, task, loc), nil
+}
+
+func (n *NSGAII) Lockstep() {
+	n.eval.lockstep = true
+}
+
+func (n *NSGAII) Done() {
+	n.eval.finish()
+}
+
+func (n *NSGAII) location(id int, x []float64) {
+	copy(x, n.children[id])
+}
+
+func (n *NSGAII) evaluated(id int, loc *Location) {
+	if n.objectives < 0 {
+		n.objectives = len(loc.Objectives)
+	}
+	obj := loc.Objectives
+	if n.objectives == 0 {
+		// The objective function of a Problem is the single objective.
+		obj = []float64{loc.F}
+	}
+	f := make([]float64, len(obj))
+	for i, v := range obj {
+		if math.IsNaN(v) {
+			v = math.Inf(1)
+		}
+		f[i] = v
+	}
+	n.childF[id] = f
+	n.childDone[id] = true
+}
+
+// nextBatch selects the new generation among the current generation and the
+// evaluated children, and creates the children of the next one.
+func (n *NSGAII) nextBatch(loc *Location) (ids []int, major bool) {
+	n.pop = append(n.pop, n.children...)
+	n.popF = append(n.popF, n.childF...)
+	n.survive()
+
+	n.children = n.children[:0]
+	for len(n.children) < n.size {
+		a := append([]float64(nil), n.pop[n.tournament()]...)
+		b := append([]float64(nil), n.pop[n.tournament()]...)
+		if n.src.Float64() < n.rate {
+			n.crossover.Crossover(a, b, n.Bounds, n.enc, n.src)
+		}
+		for _, x := range [][]float64{a, b} {
+			if len(n.children) == n.size {
+				break
+			}
+			n.mutation.Mutate(x, n.Bounds, n.enc, n.src)
+			n.repair(x)
+			n.children = append(n.children, x)
+		}
+	}
+	ids = n.newBatch()
+
+	best := -1
+	for i, r := range n.rank {
+		if r == 0 && (best < 0 || n.popF[i][0] < n.popF[best][0]) {
+			best = i
+		}
+	}
+	copy(loc.X, n.pop[best])
+	loc.F = n.popF[best][0]
+	if n.objectives > 0 {
+		loc.Objectives = append(loc.Objectives[:0], n.popF[best]...)
+	}
+	return ids, true
+}
+
+// newBatch prepares the evaluation of the children and returns their ids.
+func (n *NSGAII) newBatch() []int {
+	n.childF = make([][]float64, len(n.children))
+	n.childDone = make([]bool, len(n.children))
+	ids := make([]int, len(n.children))
+	for i := range ids {
+		ids[i] = i
+	}
+	return ids
+}
+
+// survive reduces the population to the PopulationSize best individuals by
+// rank and crowding distance, and stores their ranks and crowding distances.
+func (n *NSGAII) survive() {
+	all := len(n.pop)
+	rank := make([]int, all)
+	crowding := make([]float64, all)
+	keep := make([]int, 0, n.size)
+	for r, front := range nondominatedSort(n.popF) {
+		crowdingDistance(crowding, n.popF, front)
+		for _, i := range front {
+			rank[i] = r
+		}
+		if len(keep)+len(front) > n.size {
+			// Keep the most isolated individuals of the last front.
+			sort.Stable(crowdingSorter{front, crowding})
+			front = front[:n.size-len(keep)]
+		}
+		keep = append(keep, front...)
+		if len(keep) == n.size {
+			break
+		}
+	}
+
+	pop := make([][]float64, len(keep))
+	popF := make([][]float64, len(keep))
+	n.rank = resizeInts(n.rank, len(keep))
+	n.crowding = resize(n.crowding, len(keep))
+	for k, i := range keep {
+		pop[k] = n.pop[i]
+		popF[k] = n.popF[i]
+		n.rank[k] = rank[i]
+		n.crowding[k] = crowding[i]
+	}
+	n.pop, n.popF = pop, popF
+}
+
+// tournament returns the better of two individuals of the population chosen
+// uniformly at random, the one with the lower rank or, for equal ranks, the
+// one with the larger crowding distance.
+func (n *NSGAII) tournament() int {
+	i := n.src.Intn(len(n.pop))
+	j := n.src.Intn(len(n.pop))
+	if n.rank[j] < n.rank[i] || (n.rank[j] == n.rank[i] && n.crowding[j] > n.crowding[i]) {
+		return j
+	}
+	return i
+}
+
+// repair moves the genes of x within their bounds.
+func (n *NSGAII) repair(x []float64) {
+	for i, v := range x {
+		x[i] = math.Max(n.Bounds[i].Min, math.Min(v, n.Bounds[i].Max))
+	}
+}
+
+// ParetoFront returns the non-dominated individuals among the current
+// generation and the children evaluated since it was created. Non-dominated
+// individuals of earlier generations that did not survive because of their
+// small crowding distance are not included.
+func (n *NSGAII) ParetoFront() []Location {
+	x := append([][]float64(nil), n.pop...)
+	f := append([][]float64(nil), n.popF...)
+	for i, done := range n.childDone {
+		if done {
+			x = append(x, n.children[i])
+			f = append(f, n.childF[i])
+		}
+	}
+	if len(f) == 0 {
+		return nil
+	}
+	front := nondominatedSort(f)[0]
+	sort.Stable(objectiveSorter{front, f, 0})
+	locs := make([]Location, 0, len(front))
+	for _, i := range front {
+		if containsX(locs, x[i], f[i][0]) {
+			// Skip the copies of an individual.
+			continue
+		}
+		locs = append(locs, Location{
+			X:          append([]float64(nil), x[i]...),
+			F:          f[i][0],
+			Objectives: append([]float64(nil), f[i]...),
+		})
+	}
+	return locs
+}
+
+// crowdingSorter sorts indices by decreasing crowding distance.
+type crowdingSorter struct {
+	idx  []int
+	dist []float64
+}
+
+func (s crowdingSorter) Len() int {
+	return len(s.idx)
+}
+
+func (s crowdingSorter) Less(i, j int) bool {
+	return s.dist[s.idx[i]] > s.dist[s.idx[j]]
+}
+
+func (s crowdingSorter) Swap(i, j int) {
+	s.idx[i], s.idx[j] = s.idx[j], s.idx[i]
+}
+
+func resizeInts(x []int, n int) []int {
+	if cap(x) < n {
+		return make([]int, n)
+	}
+	return x[:n]
+}
+
+// containsX returns whether the locations of a front sorted by the first
+// objective include x, whose first objective is f. The members of a front
+// with equal first objectives have equal objectives, so only the last
+// locations need to be compared.
+func containsX(locs []Location, x []float64, f float64) bool {
+	for i := len(locs) - 1; i >= 0 && locs[i].F == f; i-- {
+		if floats.Equal(locs[i].X, x) {
+			return true
+		}
+	}
+	return false
+}
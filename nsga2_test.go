@@ -0,0 +1,154 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestNondominatedSort(t *testing.T) {
+	f := [][]float64{
+		{1, 5},
+		{2, 2},
+		{3, 3},
+		{5, 1},
+		{4, 4},
+		{2, 2},
+		{6, 6},
+	}
+	want := [][]int{{0, 1, 3, 5}, {2}, {4}, {6}}
+	got := nondominatedSort(f)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected fronts: got %v, want %v", got, want)
+	}
+
+	dist := make([]float64, len(f))
+	crowdingDistance(dist, f, got[0])
+	// The sorted values of both objectives are 1, 2, 2, 5, so the distance
+	// of the middle members sums to (2-1)/4 + (5-2)/4 for each objective.
+	if !math.IsInf(dist[0], 1) || !math.IsInf(dist[3], 1) {
+		t.Errorf("extreme members not infinitely distant: %v", dist)
+	}
+	if dist[1]+dist[5] != 2 {
+		t.Errorf("unexpected crowding distances: %v", dist)
+	}
+}
+
+func TestNSGAII(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		p          MultiObjectiveProblem
+		bounds     []Bound
+		onFront    func(x, f []float64) bool
+		concurrent int
+	}{
+		{
+			// Schaffer's problem has the Pareto front x in [0, 2].
+			name: "Schaffer",
+			p: MultiObjectiveProblem{
+				Func: func(f, x []float64) {
+					f[0] = x[0] * x[0]
+					f[1] = (x[0] - 2) * (x[0] - 2)
+				},
+				Objectives: 2,
+			},
+			bounds: []Bound{{-10, 10}},
+			onFront: func(x, f []float64) bool {
+				return x[0] >= -1e-3 && x[0] <= 2+1e-3
+			},
+			concurrent: 1,
+		},
+		{
+			// ZDT1 has the Pareto front f1 = 1 - sqrt(f0) with x[i] = 0 for
+			// i > 0.
+			name: "ZDT1",
+			p: MultiObjectiveProblem{
+				Func: func(f, x []float64) {
+					var g float64
+					for _, v := range x[1:] {
+						g += v
+					}
+					g = 1 + 9*g/float64(len(x)-1)
+					f[0] = x[0]
+					f[1] = g * (1 - math.Sqrt(x[0]/g))
+				},
+				Objectives: 2,
+			},
+			bounds: []Bound{{0, 1}, {0, 1}, {0, 1}, {0, 1}},
+			onFront: func(x, f []float64) bool {
+				return math.Abs(f[1]-(1-math.Sqrt(f[0]))) < 0.05
+			},
+			concurrent: 4,
+		},
+	} {
+		method := &NSGAII{
+			Bounds:         test.bounds,
+			PopulationSize: 40,
+			Src:            rand.New(rand.NewSource(1)),
+		}
+		settings := DefaultSettingsGlobal()
+		settings.FuncEvaluations = 8000
+		settings.Concurrent = test.concurrent
+		result, err := MultiObjective(test.p, len(test.bounds), settings, method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != FunctionEvaluationLimit {
+			t.Errorf("%s: unexpected status: %v", test.name, result.Status)
+		}
+		if result.FuncEvaluations != settings.FuncEvaluations {
+			t.Errorf("%s: unexpected number of evaluations: %d", test.name, result.FuncEvaluations)
+		}
+		if len(result.Front) < 20 {
+			t.Errorf("%s: too few locations on the front: %d", test.name, len(result.Front))
+		}
+		for i, loc := range result.Front {
+			if !test.onFront(loc.X, loc.Objectives) {
+				t.Errorf("%s: location %v with objectives %v not on the Pareto front", test.name, loc.X, loc.Objectives)
+			}
+			if loc.F != loc.Objectives[0] {
+				t.Errorf("%s: F does not hold the first objective", test.name)
+			}
+			if i > 0 && (loc.F < result.Front[i-1].F || dominates(result.Front[i-1].Objectives, loc.Objectives)) {
+				t.Errorf("%s: front not sorted or dominated", test.name)
+			}
+		}
+	}
+}
+
+func TestNSGAIISingleObjective(t *testing.T) {
+	// Global passes no Objectives for a Problem, whose F is then the single
+	// objective.
+	p := Problem{
+		Func: func(x []float64) float64 {
+			return (x[0]-1)*(x[0]-1) + x[1]*x[1]
+		},
+	}
+	method := &NSGAII{
+		Bounds: []Bound{{-5, 5}, {-5, 5}},
+		Src:    rand.New(rand.NewSource(1)),
+	}
+	settings := DefaultSettingsGlobal()
+	settings.FunctionConverge = nil
+	settings.FuncEvaluations = 2000
+	result, err := Global(p, 2, settings, method)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != FunctionEvaluationLimit {
+		t.Errorf("unexpected status %v", result.Status)
+	}
+	if result.F > 1e-3 {
+		t.Errorf("minimum not found, got %v at %v", result.F, result.X)
+	}
+	front := method.ParetoFront()
+	if len(front) == 0 || front[0].F != result.F {
+		t.Errorf("unexpected front %v", front)
+	}
+}
@@ -0,0 +1,402 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultMeshStep        = 1
+	defaultMeshTolerance   = 1e-8
+	defaultMeshExpansion   = 2
+	defaultMeshContraction = 0.5
+)
+
+// meshMethod is a direct search method whose trial locations are evaluated
+// in batches by directSearch.
+type meshMethod interface {
+	// start adds the trial locations of the first batch around ds.x.
+	start(ds *directSearch)
+	// update receives the function values at the trial locations of the
+	// last batch, updates ds.x and ds.f and adds the trial locations of the
+	// next batch. It returns whether an iteration has been completed, which
+	// is announced as a MajorIteration. If the method has converged, it sets
+	// ds.converged and adds no trial locations.
+	update(ds *directSearch) bool
+}
+
+// directSearch evaluates the trial locations of the direct search methods
+// HookeJeeves, PatternSearch and MADS in batches. Local evaluates the batches
+// sequentially, and the tasks of Global evaluate the locations of a batch
+// concurrently. Because a new batch is formed only after the previous one
+// has been evaluated, the sequence of evaluated locations does not depend on
+// the number of tasks.
+type directSearch struct {
+	batch     batchEvaluator
+	method    meshMethod
+	initial   bool // The function value at x is not known yet.
+	converged bool
+
+	x      []float64   // Best location found so far.
+	f      float64     // Function value at x.
+	trials [][]float64 // Trial locations of the current batch.
+	values []float64   // Function values at trials.
+	n      int         // Number of trial locations in the current batch.
+	ids    []int
+}
+
+// initLocal starts the search from loc for Local.
+func (ds *directSearch) initLocal(m meshMethod, loc *Location) Operation {
+	ds.method = m
+	ds.initial = false
+	ds.converged = false
+	ds.x = resize(ds.x, len(loc.X))
+	copy(ds.x, loc.X)
+	ds.f = loc.F
+	ds.n = 0
+	m.start(ds)
+	ds.batch.init(1, ds.batchIDs())
+	// Local has a single task that must not wait for other ones.
+	ds.batch.lockstep = true
+	return ds.batch.iterate(ds, 0, loc)
+}
+
+// initGlobal starts the search from x for Global, which evaluates x first.
+func (ds *directSearch) initGlobal(m meshMethod, x []float64, tasks int, name string) int {
+	if x == nil {
+		panic(name + ": nil InitX")
+	}
+	ds.method = m
+	ds.initial = true
+	ds.converged = false
+	ds.x = resize(ds.x, len(x))
+	copy(ds.x, x)
+	ds.f = math.Inf(1)
+	ds.n = 0
+	copy(ds.addTrial(), x)
+	if tasks < 1 {
+		tasks = 1
+	}
+	ds.batch.init(tasks, ds.batchIDs())
+	return tasks
+}
+
+func (ds *directSearch) iterate(task int, loc *Location) Operation {
+	return ds.batch.iterate(ds, task, loc)
+}
+
+func (ds *directSearch) status() Status {
+	ds.batch.mux.Lock()
+	defer ds.batch.mux.Unlock()
+	if ds.converged {
+		return MeshConvergence
+	}
+	return NotTerminated
+}
+
+func (ds *directSearch) location(id int, x []float64) {
+	copy(x, ds.trials[id])
+}
+
+func (ds *directSearch) evaluated(id int, loc *Location) {
+	f := loc.F
+	if math.IsNaN(f) {
+		f = math.Inf(1)
+	}
+	ds.values[id] = f
+}
+
+func (ds *directSearch) nextBatch(loc *Location) (ids []int, major bool) {
+	if ds.initial {
+		ds.initial = false
+		ds.f = ds.values[0]
+		ds.n = 0
+		ds.method.start(ds)
+		major = true
+	} else {
+		major = ds.method.update(ds)
+	}
+	copy(loc.X, ds.x)
+	loc.F = ds.f
+	return ds.batchIDs(), major
+}
+
+func (ds *directSearch) batchIDs() []int {
+	ds.ids = ds.ids[:0]
+	for i := 0; i < ds.n; i++ {
+		ds.ids = append(ds.ids, i)
+	}
+	return ds.ids
+}
+
+// reset empties the current batch.
+func (ds *directSearch) reset() {
+	ds.n = 0
+}
+
+// addTrial adds a trial location to the current batch and returns it for
+// the caller to fill.
+func (ds *directSearch) addTrial() []float64 {
+	if ds.n == len(ds.trials) {
+		ds.trials = append(ds.trials, make([]float64, len(ds.x)))
+		ds.values = append(ds.values, 0)
+	}
+	ds.trials[ds.n] = resize(ds.trials[ds.n], len(ds.x))
+	ds.n++
+	return ds.trials[ds.n-1]
+}
+
+// best returns the index of the trial location of the last batch with the
+// lowest function value, and whether that value is lower than ds.f.
+func (ds *directSearch) best() (int, bool) {
+	if ds.n == 0 {
+		return -1, false
+	}
+	k := floats.MinIdx(ds.values[:ds.n])
+	return k, ds.values[k] < ds.f
+}
+
+// move makes the trial location k the best location and stores the step to
+// it in step.
+func (ds *directSearch) move(k int, step []float64) {
+	floats.SubTo(step, ds.trials[k], ds.x)
+	copy(ds.x, ds.trials[k])
+	ds.f = ds.values[k]
+}
+
+// search adds the trial locations of the search step of PatternSearch and
+// MADS: the speculative location ds.x + 2*last if last is not nil, and the
+// locations proposed by the search function, rounded to the mesh around ds.x
+// with the given mesh size.
+func (ds *directSearch) search(last []float64, search func(x []float64, mesh float64) [][]float64, mesh float64) {
+	if last != nil {
+		floats.AddScaledTo(ds.addTrial(), ds.x, 2, last)
+	}
+	if search == nil {
+		return
+	}
+	for _, y := range search(ds.x, mesh) {
+		if len(y) != len(ds.x) {
+			panic("optimize: search location size mismatch")
+		}
+		trial := ds.addTrial()
+		for i, v := range y {
+			trial[i] = ds.x[i] + mesh*math.Floor((v-ds.x[i])/mesh+0.5)
+		}
+	}
+}
+
+// PollBasis is the set of poll directions of PatternSearch.
+type PollBasis int
+
+const (
+	// CoordinateBasis polls along the 2*dim positive and negative
+	// coordinate directions.
+	CoordinateBasis PollBasis = iota
+	// MinimalBasis polls along the dim+1 directions e_1, ..., e_dim and
+	// -(e_1 + ... + e_dim), the smallest positive spanning set.
+	MinimalBasis
+)
+
+// PatternSearch implements the generalized pattern search (GPS) method for
+// derivative-free minimization described in
+//
+//  Torczon, V.: On the convergence of pattern search algorithms. SIAM J.
+//  Optim. 7 (1997), 1-25.
+//  Audet, C., Dennis, J.E.: Analysis of generalized pattern searches. SIAM J.
+//  Optim. 13 (2003), 889-903.
+//
+// Every iteration consists of a search step and a poll step on the mesh of
+// locations x + Δ*z, where x is the best location found so far, Δ is the mesh
+// size and z is an integer vector. The search step evaluates the speculative
+// location that repeats the last successful step with twice its length, and
+// the locations returned by Search. If none of them is better than x, the
+// poll step evaluates x + Δ*d for the directions d of Basis. If a better
+// location is found, it becomes x and Δ is multiplied by Expansion, otherwise
+// Δ is multiplied by Contraction. PatternSearch terminates with
+// MeshConvergence when Δ is smaller than MeshTolerance.
+//
+// The method uses only comparisons of function values, so it is robust on
+// nonsmooth and noisy functions. The locations of a search or poll step are
+// evaluated concurrently when PatternSearch is used with Global, in which
+// case the search starts at InitX. The evaluated locations do not depend on
+// the number of concurrent tasks. Every iteration is a major iteration.
+type PatternSearch struct {
+	// InitX is the initial location of the search with Global. It is not
+	// used with Local.
+	InitX []float64
+	// Basis is the set of poll directions.
+	Basis PollBasis
+	// Search returns locations near x, the best location found so far, that
+	// are evaluated by the search step. They are rounded to the mesh of size
+	// mesh. Search may be nil.
+	Search func(x []float64, mesh float64) [][]float64
+	// InitialStep is the initial mesh size.
+	// If InitialStep is zero, it will be set to 1.
+	InitialStep float64
+	// MeshTolerance is the mesh size below which the search terminates.
+	// If MeshTolerance is zero, it will be set to 1e-8.
+	MeshTolerance float64
+	// Expansion is the factor by which the mesh size grows after a
+	// successful iteration. It must not be smaller than one.
+	// If Expansion is zero, it will be set to 2.
+	Expansion float64
+	// Contraction is the factor by which the mesh size shrinks after an
+	// unsuccessful iteration. It must be between zero and one.
+	// If Contraction is zero, it will be set to 0.5.
+	Contraction float64
+
+	mesh, tol, expansion, contraction float64
+
+	ds      directSearch
+	polling bool      // The current batch is a poll step.
+	last    []float64 // Last successful step.
+	success bool      // The last iteration was successful.
+}
+
+func (p *PatternSearch) Init(loc *Location) (Operation, error) {
+	p.init(len(loc.X))
+	return p.ds.initLocal(p, loc), nil
+}
+
+func (p *PatternSearch) Iterate(loc *Location) (Operation, error) {
+	return p.ds.iterate(0, loc), nil
+}
+
+func (p *PatternSearch) InitGlobal(dim, tasks int) int {
+	p.init(dim)
+	return p.ds.initGlobal(p, p.InitX, tasks, "patternsearch")
+}
+
+func (p *PatternSearch) IterateGlobal(task int, loc *Location) (Operation, error) {
+	return p.ds.iterate(task, loc), nil
+}
+
+// Status returns MeshConvergence once the mesh size is smaller than
+// MeshTolerance.
+func (p *PatternSearch) Status() (Status, error) {
+	return p.ds.status(), nil
+}
+
+func (p *PatternSearch) Lockstep() {
+	p.ds.batch.lockstep = true
+}
+
+func (p *PatternSearch) Done() {
+	p.ds.batch.finish()
+}
+
+func (*PatternSearch) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+func (p *PatternSearch) init(dim int) {
+	p.mesh, p.tol = meshSizes(p.InitialStep, p.MeshTolerance, "patternsearch")
+	p.expansion = p.Expansion
+	if p.expansion == 0 {
+		p.expansion = defaultMeshExpansion
+	}
+	if p.expansion < 1 {
+		panic("patternsearch: Expansion smaller than one")
+	}
+	p.contraction = p.Contraction
+	if p.contraction == 0 {
+		p.contraction = defaultMeshContraction
+	}
+	if p.contraction <= 0 || p.contraction >= 1 {
+		panic("patternsearch: Contraction not between zero and one")
+	}
+	if p.Basis != CoordinateBasis && p.Basis != MinimalBasis {
+		panic("patternsearch: unknown Basis")
+	}
+	p.last = resize(p.last, dim)
+	p.success = false
+}
+
+func (p *PatternSearch) start(ds *directSearch) {
+	ds.reset()
+	p.polling = false
+	if p.success {
+		ds.search(p.last, p.Search, p.mesh)
+	} else {
+		ds.search(nil, p.Search, p.mesh)
+	}
+	if ds.n == 0 {
+		p.poll(ds)
+	}
+}
+
+func (p *PatternSearch) update(ds *directSearch) bool {
+	if k, ok := ds.best(); ok {
+		ds.move(k, p.last)
+		p.success = true
+		p.mesh *= p.expansion
+	} else if !p.polling {
+		// The search step has failed, so poll.
+		ds.reset()
+		p.poll(ds)
+		return false
+	} else {
+		p.success = false
+		p.mesh *= p.contraction
+		if p.mesh < p.tol {
+			ds.reset()
+			ds.converged = true
+			return true
+		}
+	}
+	p.start(ds)
+	return true
+}
+
+// poll adds the poll locations.
+func (p *PatternSearch) poll(ds *directSearch) {
+	p.polling = true
+	dim := len(ds.x)
+	for i := 0; i < dim; i++ {
+		trial := ds.addTrial()
+		copy(trial, ds.x)
+		trial[i] += p.mesh
+	}
+	switch p.Basis {
+	case CoordinateBasis:
+		for i := 0; i < dim; i++ {
+			trial := ds.addTrial()
+			copy(trial, ds.x)
+			trial[i] -= p.mesh
+		}
+	case MinimalBasis:
+		trial := ds.addTrial()
+		copy(trial, ds.x)
+		floats.AddConst(-p.mesh, trial)
+	}
+}
+
+// meshSizes returns the initial mesh size and the mesh tolerance with their
+// defaults applied.
+func meshSizes(step, tol float64, name string) (float64, float64) {
+	if step == 0 {
+		step = defaultMeshStep
+	}
+	if step < 0 {
+		panic(name + ": negative InitialStep")
+	}
+	if tol == 0 {
+		tol = defaultMeshTolerance
+	}
+	if tol < 0 {
+		panic(name + ": negative MeshTolerance")
+	}
+	return step, tol
+}
@@ -0,0 +1,125 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/optimize/functions"
+)
+
+type meshGlobalMethod interface {
+	GlobalMethod
+	Statuser
+}
+
+func TestDirectSearchNonsmooth(t *testing.T) {
+	// The weighted sum of absolute values is not differentiable at its
+	// minimum.
+	c := []float64{0.75, -2.5, 1.25}
+	p := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i, v := range x {
+				f += float64(i+1) * math.Abs(v-c[i])
+			}
+			return f
+		},
+	}
+	for _, method := range []Method{
+		&HookeJeeves{},
+		&PatternSearch{},
+		&MADS{},
+	} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		result, err := Local(p, []float64{3, 3, 3}, settings, method)
+		if err != nil {
+			t.Errorf("%T: unexpected error: %v", method, err)
+			continue
+		}
+		if result.Status != MeshConvergence {
+			t.Errorf("%T: unexpected status %v", method, result.Status)
+		}
+		if !floats.EqualApprox(result.X, c, 1e-7) {
+			t.Errorf("%T: minimum not found, want %v, got %v", method, c, result.X)
+		}
+	}
+
+	// PatternSearch with MinimalBasis stalls at the kinks of the second and
+	// third terms before the first term is minimized. There -e_1 is the only
+	// descent direction, and it is not in the basis.
+	settings := DefaultSettings()
+	settings.FunctionConverge = nil
+	result, err := Local(p, []float64{3, 3, 3}, settings, &PatternSearch{Basis: MinimalBasis})
+	if err != nil {
+		t.Fatalf("MinimalBasis: unexpected error: %v", err)
+	}
+	if result.Status != MeshConvergence {
+		t.Errorf("MinimalBasis: unexpected status %v", result.Status)
+	}
+	if result.X[0] <= c[0] || !floats.EqualApprox(result.X[1:], c[1:], 1e-7) {
+		t.Errorf("MinimalBasis: unexpected location %v", result.X)
+	}
+}
+
+func TestDirectSearchGlobal(t *testing.T) {
+	x := []float64{-1.2, 1, -1.2}
+	f := functions.ExtendedRosenbrock{}.Func
+	for _, method := range []func() meshGlobalMethod{
+		func() meshGlobalMethod { return &HookeJeeves{InitX: x} },
+		func() meshGlobalMethod { return &PatternSearch{InitX: x} },
+		func() meshGlobalMethod {
+			return &PatternSearch{
+				InitX: x,
+				Search: func(x []float64, mesh float64) [][]float64 {
+					// A location on the line to the minimum.
+					y := make([]float64, len(x))
+					for i, v := range x {
+						y[i] = 0.5 * (v + 1)
+					}
+					return [][]float64{y}
+				},
+			}
+		},
+		func() meshGlobalMethod { return &MADS{InitX: x} },
+	} {
+		var results []*Result
+		var name string
+		for _, concurrent := range []int{1, 4} {
+			m := method()
+			name = fmt.Sprintf("%T", m)
+			settings := DefaultSettingsGlobal()
+			settings.FunctionConverge = nil
+			settings.Concurrent = concurrent
+			result, err := Global(Problem{Func: f}, len(x), settings, m)
+			if err != nil {
+				t.Errorf("%v, Concurrent=%v: unexpected error: %v", name, concurrent, err)
+				continue
+			}
+			if result.Status != MeshConvergence {
+				t.Errorf("%v, Concurrent=%v: unexpected status %v", name, concurrent, result.Status)
+			}
+			if result.F > 1e-10 {
+				t.Errorf("%v, Concurrent=%v: minimum not found, got %v", name, concurrent, result.F)
+			}
+			if result.F != f(result.X) {
+				t.Errorf("%v, Concurrent=%v: function value at X not equal to F", name, concurrent)
+			}
+			results = append(results, result)
+		}
+		if len(results) == 2 {
+			// The evaluated locations must not depend on the number of
+			// concurrent tasks.
+			if results[0].F != results[1].F || !floats.Equal(results[0].X, results[1].X) ||
+				results[0].FuncEvaluations != results[1].FuncEvaluations {
+				t.Errorf("%v: different result with concurrent evaluations", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,409 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultPowellStep          = 0.1
+	defaultPowellLineTolerance = 1.4901161193847656e-08 // Square root of the machine epsilon.
+)
+
+// powellStage is the stage of an iteration of Powell's method.
+type powellStage int
+
+const (
+	powellLine powellStage = iota
+	powellExtrapolate
+	powellNewDirection
+	powellMajor
+)
+
+// Powell implements Powell's conjugate direction method for derivative-free
+// minimization. Every iteration minimizes the function along each of a set of
+// n directions in turn, starting from the coordinate directions, by bracketing
+// the minimum and Brent's method. The direction of the total displacement of
+// the iteration then replaces the direction along which the function decreased
+// the most, unless the heuristic test of Powell indicates that the set of
+// directions would become nearly linearly dependent. On a quadratic function
+// the directions become mutually conjugate, so the method converges much
+// faster than NelderMead in many dimensions. See
+//
+//  Powell, M.J.D.: An efficient method for finding the minimum of a function
+//  of several variables without calculating derivatives. Comput. J. 7 (1964),
+//  155-162.
+//
+//  Press, W.H. et al.: Numerical Recipes (3rd ed). Cambridge University Press
+//  (2007), Section 10.7.
+//
+// Powell requests only FuncEvaluation and announces a MajorIteration after
+// every iteration over all the directions, so it should be used with
+// Settings.FunctionConverge.
+type Powell struct {
+	// InitialStep is the length of the first trial step of the line
+	// minimizations along the initial directions. The later trial steps along
+	// a direction have the length of the previous step along it.
+	// If InitialStep is 0, it is defaulted to 0.1.
+	InitialStep float64
+	// LineTolerance is the relative tolerance of the location of the minimum
+	// of the line minimizations. If LineTolerance is 0, it is defaulted to the
+	// square root of the machine epsilon.
+	LineTolerance float64
+
+	stage powellStage
+
+	x    []float64   // Best location.
+	f    float64     // Function value at x.
+	x0   []float64   // Location at the start of the iteration.
+	f0   float64     // Function value at x0.
+	dirs [][]float64 // Set of unit directions.
+	step []float64   // Length of the trial steps along the directions.
+
+	idx      int     // Index of the current direction.
+	biggest  float64 // Biggest decrease of f along a direction in this iteration.
+	bigIdx   int     // Index of the direction of the biggest decrease.
+	newDir   []float64 // Direction of the displacement of the iteration.
+	base     []float64 // Starting location of the line minimization.
+	lineDir  []float64 // Direction of the line minimization.
+	line     brentLine
+	lineStep *float64 // Storage of the trial step length of the line minimization.
+}
+
+func (p *Powell) Init(loc *Location) (Operation, error) {
+	if p.InitialStep == 0 {
+		p.InitialStep = defaultPowellStep
+	}
+	if p.InitialStep < 0 {
+		panic("powell: negative InitialStep")
+	}
+	if p.LineTolerance == 0 {
+		p.LineTolerance = defaultPowellLineTolerance
+	}
+	if p.LineTolerance < 0 {
+		panic("powell: negative LineTolerance")
+	}
+
+	dim := len(loc.X)
+	p.x = resize(p.x, dim)
+	p.x0 = resize(p.x0, dim)
+	p.base = resize(p.base, dim)
+	p.newDir = resize(p.newDir, dim)
+	p.step = resize(p.step, dim)
+	if cap(p.dirs) < dim {
+		p.dirs = make([][]float64, dim)
+	}
+	p.dirs = p.dirs[:dim]
+	for i := range p.dirs {
+		p.dirs[i] = resize(p.dirs[i], dim)
+		for j := range p.dirs[i] {
+			p.dirs[i][j] = 0
+		}
+		p.dirs[i][i] = 1
+		p.step[i] = p.InitialStep
+	}
+
+	copy(p.x, loc.X)
+	p.f = loc.F
+	return p.startIteration(loc)
+}
+
+func (p *Powell) Iterate(loc *Location) (Operation, error) {
+	switch p.stage {
+	case powellMajor:
+		return p.startIteration(loc)
+	case powellExtrapolate:
+		return p.extrapolated(loc)
+	}
+
+	// loc holds the function value at a trial point of a line minimization.
+	t, done := p.line.iterate(loc.F)
+	if !done {
+		floats.AddScaledTo(loc.X, p.base, t, p.lineDir)
+		return FuncEvaluation, nil
+	}
+	t, ft := p.line.result()
+	floats.AddScaledTo(p.x, p.base, t, p.lineDir)
+	decrease := p.f - ft
+	p.f = ft
+	if t != 0 {
+		*p.lineStep = math.Abs(t)
+	} else {
+		*p.lineStep /= 2
+	}
+
+	if p.stage == powellNewDirection {
+		return p.major(loc)
+	}
+	if decrease > p.biggest {
+		p.biggest = decrease
+		p.bigIdx = p.idx
+	}
+	p.idx++
+	if p.idx < len(p.dirs) {
+		return p.startLine(loc, p.dirs[p.idx], &p.step[p.idx])
+	}
+
+	// Evaluate the function at the extrapolated point 2x - x0.
+	p.stage = powellExtrapolate
+	for i, v := range p.x {
+		loc.X[i] = 2*v - p.x0[i]
+	}
+	return FuncEvaluation, nil
+}
+
+// startIteration starts a new iteration over all the directions.
+func (p *Powell) startIteration(loc *Location) (Operation, error) {
+	copy(p.x0, p.x)
+	p.f0 = p.f
+	p.idx = 0
+	p.biggest = 0
+	p.bigIdx = 0
+	p.stage = powellLine
+	return p.startLine(loc, p.dirs[0], &p.step[0])
+}
+
+// startLine starts the line minimization from x along dir with the trial step
+// length stored in step.
+func (p *Powell) startLine(loc *Location, dir []float64, step *float64) (Operation, error) {
+	copy(p.base, p.x)
+	p.lineDir = dir
+	p.lineStep = step
+	abs := p.LineTolerance * math.Max(floats.Norm(p.base, math.Inf(1)), *step)
+	t := p.line.init(p.f, *step, p.LineTolerance, abs)
+	floats.AddScaledTo(loc.X, p.base, t, p.lineDir)
+	return FuncEvaluation, nil
+}
+
+// extrapolated decides from the function value at the extrapolated point in
+// loc whether the direction of the displacement of the iteration replaces the
+// direction of the biggest decrease.
+func (p *Powell) extrapolated(loc *Location) (Operation, error) {
+	fe := loc.F
+	if fe >= p.f0 {
+		return p.major(loc)
+	}
+	a := p.f0 - p.f - p.biggest
+	b := p.f0 - fe
+	test := 2*(p.f0-2*p.f+fe)*a*a - p.biggest*b*b
+	if test >= 0 {
+		return p.major(loc)
+	}
+	floats.SubTo(p.newDir, p.x, p.x0)
+	norm := floats.Norm(p.newDir, 2)
+	if norm == 0 {
+		return p.major(loc)
+	}
+	floats.Scale(1/norm, p.newDir)
+
+	// Replace the direction of the biggest decrease by the last one, and
+	// the last one by the new direction.
+	n := len(p.dirs) - 1
+	last := p.dirs[p.bigIdx]
+	p.dirs[p.bigIdx] = p.dirs[n]
+	p.step[p.bigIdx] = p.step[n]
+	copy(last, p.newDir)
+	p.dirs[n] = last
+	p.step[n] = norm
+
+	p.stage = powellNewDirection
+	return p.startLine(loc, p.dirs[n], &p.step[n])
+}
+
+func (p *Powell) major(loc *Location) (Operation, error) {
+	p.stage = powellMajor
+	copy(loc.X, p.x)
+	loc.F = p.f
+	return MajorIteration, nil
+}
+
+func (*Powell) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{false, false}
+}
+
+const (
+	goldenRatio   = 1.618033988749895  // (1 + sqrt(5)) / 2
+	goldenSection = 0.3819660112501051 // (3 - sqrt(5)) / 2
+
+	maxBracketExpansions = 100
+)
+
+// brentStage is the stage of a brentLine.
+type brentStage int
+
+const (
+	brentFirstStep brentStage = iota
+	brentBracket
+	brentMinimize
+)
+
+// brentLine minimizes a function of one variable t starting from t = 0 by
+// reverse communication. It first brackets a minimum by expanding the steps by
+// the golden ratio until the function value increases, and then locates it by
+// Brent's method, which combines parabolic interpolation with golden section
+// steps. See
+//
+//  Brent, R.P.: Algorithms for Minimization without Derivatives.
+//  Prentice-Hall (1973), Chapter 5.
+type brentLine struct {
+	stage    brentStage
+	tol, abs float64 // Relative and absolute tolerance of t.
+	expanded int
+
+	// Bracketing triple with fb <= fa.
+	a, b, c    float64
+	fa, fb, fc float64
+
+	// Brent's method.
+	lo, hi     float64 // Interval that contains the minimum.
+	x, w, v, u float64 // Best, second best and previous second best point, and the trial point.
+	fx, fw, fv float64
+	d, e       float64 // Last step and the step before.
+}
+
+// init starts the minimization with the function value f0 at t = 0 and the
+// length of the first trial step. It returns the first trial point.
+func (bl *brentLine) init(f0, step, tol, abs float64) float64 {
+	bl.stage = brentFirstStep
+	bl.tol = tol
+	bl.abs = abs
+	bl.expanded = 0
+	bl.a, bl.fa = 0, f0
+	bl.b = step
+	return step
+}
+
+// iterate takes the function value f at the last trial point and returns the
+// next trial point, or done = true if the minimization has finished.
+func (bl *brentLine) iterate(f float64) (t float64, done bool) {
+	switch bl.stage {
+	case brentFirstStep:
+		bl.fb = f
+		if bl.fb > bl.fa {
+			// Search downhill from b to a.
+			bl.a, bl.b = bl.b, bl.a
+			bl.fa, bl.fb = bl.fb, bl.fa
+		}
+		bl.c = bl.b + goldenRatio*(bl.b-bl.a)
+		bl.stage = brentBracket
+		return bl.c, false
+	case brentBracket:
+		bl.fc = f
+		if bl.fc < bl.fb {
+			bl.expanded++
+			if bl.expanded == maxBracketExpansions {
+				// The function seems to be unbounded below along the line.
+				bl.x, bl.fx = bl.c, bl.fc
+				return 0, true
+			}
+			bl.a, bl.b = bl.b, bl.c
+			bl.fa, bl.fb = bl.fb, bl.fc
+			bl.c = bl.b + goldenRatio*(bl.b-bl.a)
+			return bl.c, false
+		}
+		return bl.minimize(bl.a, bl.b, bl.c, bl.fb)
+	}
+
+	// f is the function value at u.
+	if f <= bl.fx {
+		if bl.u >= bl.x {
+			bl.lo = bl.x
+		} else {
+			bl.hi = bl.x
+		}
+		bl.v, bl.fv = bl.w, bl.fw
+		bl.w, bl.fw = bl.x, bl.fx
+		bl.x, bl.fx = bl.u, f
+	} else {
+		if bl.u < bl.x {
+			bl.lo = bl.u
+		} else {
+			bl.hi = bl.u
+		}
+		if f <= bl.fw || bl.w == bl.x {
+			bl.v, bl.fv = bl.w, bl.fw
+			bl.w, bl.fw = bl.u, f
+		} else if f <= bl.fv || bl.v == bl.x || bl.v == bl.w {
+			bl.v, bl.fv = bl.u, f
+		}
+	}
+	return bl.next()
+}
+
+// minimize starts Brent's method from the bracketing triple a, b, c, where b
+// lies between a and c, and fb is the function value at b, which is not
+// larger than at a and c. It returns the first trial point.
+func (bl *brentLine) minimize(a, b, c, fb float64) (t float64, done bool) {
+	bl.lo, bl.hi = math.Min(a, c), math.Max(a, c)
+	bl.x, bl.w, bl.v = b, b, b
+	bl.fx, bl.fw, bl.fv = fb, fb, fb
+	bl.d, bl.e = 0, 0
+	bl.stage = brentMinimize
+	return bl.next()
+}
+
+// next returns the next trial point of Brent's method, or done = true if the
+// minimum has been located within the tolerance.
+func (bl *brentLine) next() (t float64, done bool) {
+	m := 0.5 * (bl.lo + bl.hi)
+	tol1 := bl.tol*math.Abs(bl.x) + bl.abs
+	tol2 := 2 * tol1
+	if math.Abs(bl.x-m) <= tol2-0.5*(bl.hi-bl.lo) || tol1 == 0 {
+		return 0, true
+	}
+
+	golden := true
+	if math.Abs(bl.e) > tol1 {
+		// Fit a parabola through x, v and w.
+		r := (bl.x - bl.w) * (bl.fx - bl.fv)
+		q := (bl.x - bl.v) * (bl.fx - bl.fw)
+		p := (bl.x-bl.v)*q - (bl.x-bl.w)*r
+		q = 2 * (q - r)
+		if q > 0 {
+			p = -p
+		}
+		q = math.Abs(q)
+		e := bl.e
+		bl.e = bl.d
+		// Accept the parabolic step if it is smaller than half of the step
+		// before the last and falls within the interval.
+		if math.Abs(p) < math.Abs(0.5*q*e) && p > q*(bl.lo-bl.x) && p < q*(bl.hi-bl.x) {
+			golden = false
+			bl.d = p / q
+			u := bl.x + bl.d
+			if u-bl.lo < tol2 || bl.hi-u < tol2 {
+				bl.d = math.Copysign(tol1, m-bl.x)
+			}
+		}
+	}
+	if golden {
+		if bl.x >= m {
+			bl.e = bl.lo - bl.x
+		} else {
+			bl.e = bl.hi - bl.x
+		}
+		bl.d = goldenSection * bl.e
+	}
+	if math.Abs(bl.d) >= tol1 {
+		bl.u = bl.x + bl.d
+	} else {
+		bl.u = bl.x + math.Copysign(tol1, bl.d)
+	}
+	return bl.u, false
+}
+
+// result returns the located minimum and the function value at it.
+func (bl *brentLine) result() (t, f float64) {
+	return bl.x, bl.fx
+}
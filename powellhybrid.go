@@ -0,0 +1,221 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultHybridRadiusFactor  = 100
+	defaultHybridStepTolerance = 1.4901161193847656e-08 // Square root of the machine epsilon.
+)
+
+// hybridStage is the stage of an iteration of PowellHybrid.
+type hybridStage int
+
+const (
+	hybridTrial hybridStage = iota
+	hybridJacobian
+	hybridMajor
+)
+
+// PowellHybrid implements the hybrid method of Powell for solving systems of
+// nonlinear equations with Root. It is a trust-region method for the merit
+// function 1/2 |F(x)|^2 with the model 1/2 |F_k + J_k s|^2, which it
+// minimizes approximately within the trust region |s| <= Δ by the dogleg
+// step: the Newton step -J_k^{-1} F_k if it lies within the trust region,
+// and otherwise the point where the path from the minimizer of the model
+// along the steepest descent direction -J_k^T F_k to the Newton step crosses
+// the boundary of the trust region. Far from a solution the steps are close
+// to steepest descent steps, and close to a solution they become Newton
+// steps, so the method converges from remote starting locations and
+// converges quadratically at the end. The trust region is updated as in
+// the minimization with the trust-region methods, and the Jacobian is
+// evaluated only at accepted locations. See
+//
+//  Powell, M.J.D.: A hybrid method for nonlinear equations. In: Rabinowitz,
+//  P. (ed.) Numerical Methods for Nonlinear Algebraic Equations. Gordon and
+//  Breach (1970), 87-114.
+//  Nocedal, J., Wright, S.: Numerical Optimization (2nd ed). Springer (2006),
+//  Section 11.2.
+//
+// PowellHybrid terminates with StepConvergence when Δ becomes smaller than
+// StepTolerance * max(|x|, 1), where the merit function cannot be decreased
+// further, for example at a minimum of |F(x)| that is not a solution.
+type PowellHybrid struct {
+	// InitialRadius is the initial radius of the trust region.
+	// If InitialRadius is 0, it is defaulted to 100 * max(|x_0|, 1).
+	InitialRadius float64
+	// StepTolerance is the relative radius of the trust region below which
+	// the method terminates.
+	// If StepTolerance is 0, it is defaulted to the square root of the
+	// machine epsilon.
+	StepTolerance float64
+
+	stage     hybridStage
+	radius    float64
+	tol       float64
+	converged bool
+
+	x    []float64    // Location at the last major iteration.
+	r    []float64    // Residuals at x.
+	f    float64      // Half of the squared norm of r.
+	grad []float64    // Gradient J^T r of f.
+	jac  *mat64.Dense // Jacobian at x.
+	pred float64      // Reduction of f predicted by the model.
+
+	s, newton, cauchy, js []float64
+}
+
+func (h *PowellHybrid) Init(loc *Location) (Operation, error) {
+	if h.InitialRadius < 0 {
+		panic("powellhybrid: negative InitialRadius")
+	}
+	h.tol = h.StepTolerance
+	if h.tol == 0 {
+		h.tol = defaultHybridStepTolerance
+	}
+	if h.tol < 0 {
+		panic("powellhybrid: negative StepTolerance")
+	}
+
+	dim := len(loc.X)
+	h.x = resize(h.x, dim)
+	h.r = resize(h.r, dim)
+	h.grad = resize(h.grad, dim)
+	h.s = resize(h.s, dim)
+	h.newton = resize(h.newton, dim)
+	h.cauchy = resize(h.cauchy, dim)
+	h.js = resize(h.js, dim)
+	if h.jac == nil {
+		h.jac = mat64.NewDense(dim, dim, nil)
+	} else if r, _ := h.jac.Dims(); r != dim {
+		h.jac = mat64.NewDense(dim, dim, nil)
+	}
+
+	h.radius = h.InitialRadius
+	if h.radius == 0 {
+		h.radius = defaultHybridRadiusFactor * math.Max(floats.Norm(loc.X, 2), 1)
+	}
+	h.converged = false
+	h.accept(loc)
+	h.jac.Copy(loc.Jacobian)
+	return h.trial(loc)
+}
+
+func (h *PowellHybrid) Iterate(loc *Location) (Operation, error) {
+	switch h.stage {
+	case hybridJacobian:
+		h.jac.Copy(loc.Jacobian)
+		copy(h.grad, loc.Gradient)
+		h.stage = hybridMajor
+		return MajorIteration, nil
+	case hybridMajor:
+		return h.trial(loc)
+	}
+
+	// loc holds the residuals at x + s.
+	rho := (h.f - loc.F) / h.pred
+	norm := floats.Norm(h.s, 2)
+	switch {
+	case rho > trustRegionExpand:
+		h.radius = math.Max(h.radius, 2*norm)
+	case rho < trustRegionShrink || math.IsNaN(rho):
+		h.radius = norm / 2
+	}
+	if rho > trustRegionAccept {
+		h.accept(loc)
+		h.stage = hybridJacobian
+		return GradEvaluation, nil
+	}
+	if h.radius < h.tol*math.Max(floats.Norm(h.x, 2), 1) {
+		h.converged = true
+		return NoOperation, nil
+	}
+	return h.trial(loc)
+}
+
+// Status returns StepConvergence once the radius of the trust region is
+// smaller than the tolerance.
+func (h *PowellHybrid) Status() (Status, error) {
+	if h.converged {
+		return StepConvergence, nil
+	}
+	return NotTerminated, nil
+}
+
+// accept makes loc the location of the last major iteration.
+func (h *PowellHybrid) accept(loc *Location) {
+	copy(h.x, loc.X)
+	copy(h.r, loc.Objectives)
+	h.f = loc.F
+	copy(h.grad, loc.Gradient)
+}
+
+// trial computes the dogleg step from x, stores the trial location in loc.X
+// and requests the residuals at it.
+func (h *PowellHybrid) trial(loc *Location) (Operation, error) {
+	h.dogleg()
+	// The predicted reduction is f - 1/2 |r + J s|^2.
+	mat64.NewVector(len(h.js), h.js).MulVec(h.jac, mat64.NewVector(len(h.s), h.s))
+	h.pred = -floats.Dot(h.r, h.js) - 0.5*floats.Dot(h.js, h.js)
+	floats.AddTo(loc.X, h.x, h.s)
+	if floats.Equal(loc.X, h.x) {
+		return NoOperation, ErrNoTrustRegionProgress
+	}
+	h.stage = hybridTrial
+	return FuncEvaluation, nil
+}
+
+// dogleg stores in h.s the dogleg step within the trust region.
+func (h *PowellHybrid) dogleg() {
+	ok := newtonRootStep(h.newton, h.jac, h.r)
+	if ok && floats.Norm(h.newton, 2) <= h.radius {
+		copy(h.s, h.newton)
+		return
+	}
+
+	// The minimizer of the model along the steepest descent direction.
+	gg := floats.Dot(h.grad, h.grad)
+	if gg == 0 {
+		for i := range h.s {
+			h.s[i] = 0
+		}
+		return
+	}
+	mat64.NewVector(len(h.js), h.js).MulVec(h.jac, mat64.NewVector(len(h.grad), h.grad))
+	jgjg := floats.Dot(h.js, h.js)
+	copy(h.cauchy, h.grad)
+	floats.Scale(-gg/jgjg, h.cauchy)
+	norm := floats.Norm(h.cauchy, 2)
+	if norm >= h.radius || !ok {
+		if norm > h.radius {
+			floats.Scale(h.radius/norm, h.cauchy)
+		}
+		copy(h.s, h.cauchy)
+		return
+	}
+
+	// Follow the path from the Cauchy point to the Newton step to the
+	// boundary of the trust region.
+	floats.SubTo(h.s, h.newton, h.cauchy)
+	tau := boundaryStep(h.cauchy, h.s, h.radius)
+	floats.Scale(tau, h.s)
+	floats.Add(h.s, h.cauchy)
+}
+
+func (*PowellHybrid) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
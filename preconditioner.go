@@ -0,0 +1,239 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// icShiftFactor is the initial diagonal shift of IncompleteCholesky
+	// relative to the largest diagonal element after a breakdown.
+	icShiftFactor = 1e-3
+	// icMaxShifts is the maximum number of attempts of IncompleteCholesky to
+	// factorize a shifted matrix.
+	icMaxShifts = 64
+)
+
+// Preconditioner approximates the inverse M^{-1} of the Hessian of the
+// objective function. Gradient-based methods apply M^{-1} to the gradient,
+// which amounts to minimizing the function in variables for which the
+// Hessian is closer to the identity matrix and can reduce the number of
+// iterations drastically on ill-conditioned problems, such as
+// discretizations of partial differential equations.
+type Preconditioner interface {
+	// Init is called at the first iteration and provides a way to initialize
+	// any internal state.
+	Init(loc *Location)
+	// Update is called at every subsequent major iteration before Apply and
+	// provides a way to update M at the new location.
+	Update(loc *Location)
+	// Apply stores M^{-1} v in dst. dst and v may be the same slice, and M^{-1}
+	// must be symmetric and positive definite.
+	Apply(dst, v []float64)
+}
+
+// Jacobi is the diagonal preconditioner whose M is the diagonal of the Hessian.
+// It is cheap and effective when the variables have different scales.
+// Non-positive diagonal elements of the Hessian are replaced by one.
+type Jacobi struct {
+	// Diagonal computes the diagonal of the Hessian at x and stores it in
+	// diag. Diagonal must not modify x.
+	Diagonal func(diag, x []float64)
+	// Frequency is the number of major iterations between evaluations of
+	// Diagonal. If Frequency is 0, Diagonal is evaluated only at the initial
+	// location.
+	Frequency int
+
+	diag []float64
+	iter int
+}
+
+func (j *Jacobi) Init(loc *Location) {
+	if j.Diagonal == nil {
+		panic("jacobi: nil Diagonal")
+	}
+	if j.Frequency < 0 {
+		panic("jacobi: negative Frequency")
+	}
+	j.diag = resize(j.diag, len(loc.X))
+	j.iter = 0
+	j.evaluate(loc.X)
+}
+
+func (j *Jacobi) Update(loc *Location) {
+	j.iter++
+	if j.Frequency > 0 && j.iter%j.Frequency == 0 {
+		j.evaluate(loc.X)
+	}
+}
+
+func (j *Jacobi) evaluate(x []float64) {
+	j.Diagonal(j.diag, x)
+	for i, v := range j.diag {
+		if !(v > 0) || math.IsInf(v, 1) {
+			j.diag[i] = 1
+		}
+	}
+}
+
+func (j *Jacobi) Apply(dst, v []float64) {
+	for i, d := range j.diag {
+		dst[i] = v[i] / d
+	}
+}
+
+// IncompleteCholesky is the preconditioner whose M = L L^T is the incomplete
+// Cholesky factorization with zero fill-in, IC(0), of the Hessian H: the lower
+// triangular factor L is nonzero only where H is nonzero, and L L^T equals H on
+// that sparsity pattern. For sparse Hessians, such as those of discretized
+// partial differential equations, M approximates H much better than its
+// diagonal, and M^{-1} is applied in O(nnz) operations, where nnz is the
+// number of nonzero elements of H.
+//
+// If the factorization breaks down because H is not positive definite, H is
+// shifted by a multiple of the identity that is doubled until the
+// factorization succeeds. See
+//
+//  Lin, C.-J., Moré, J.J.: Incomplete Cholesky factorizations with limited
+//  memory. SIAM J. Sci. Comput. 21 (1999), 24-45.
+//
+// The Hessian is evaluated into dense storage of O(dim^2) memory, but the
+// factor is stored sparsely.
+type IncompleteCholesky struct {
+	// Hessian computes the Hessian at x and stores it in hess. Hessian must
+	// not modify x. The Hess function of a Problem can be used.
+	Hessian func(hess mat64.MutableSymmetric, x []float64)
+	// Frequency is the number of major iterations between evaluations and
+	// factorizations of the Hessian. If Frequency is 0, the Hessian is
+	// evaluated only at the initial location.
+	Frequency int
+
+	hess *mat64.SymDense
+	cols [][]int     // Column indices of the nonzero elements in the rows of L, in increasing order.
+	vals [][]float64 // Nonzero elements in the rows of L. The last one is on the diagonal.
+	iter int
+}
+
+func (ic *IncompleteCholesky) Init(loc *Location) {
+	if ic.Hessian == nil {
+		panic("incompletecholesky: nil Hessian")
+	}
+	if ic.Frequency < 0 {
+		panic("incompletecholesky: negative Frequency")
+	}
+	dim := len(loc.X)
+	ic.hess = resizeSymDense(ic.hess, dim)
+	if cap(ic.cols) < dim {
+		ic.cols = make([][]int, dim)
+		ic.vals = make([][]float64, dim)
+	}
+	ic.cols = ic.cols[:dim]
+	ic.vals = ic.vals[:dim]
+	ic.iter = 0
+	ic.evaluate(loc.X)
+}
+
+func (ic *IncompleteCholesky) Update(loc *Location) {
+	ic.iter++
+	if ic.Frequency > 0 && ic.iter%ic.Frequency == 0 {
+		ic.evaluate(loc.X)
+	}
+}
+
+// evaluate evaluates the Hessian at x and factorizes it.
+func (ic *IncompleteCholesky) evaluate(x []float64) {
+	ic.Hessian(ic.hess, x)
+
+	dim := len(x)
+	var maxDiag float64
+	for i := 0; i < dim; i++ {
+		maxDiag = math.Max(maxDiag, math.Abs(ic.hess.At(i, i)))
+	}
+	if maxDiag == 0 {
+		maxDiag = 1
+	}
+	var shift float64
+	for k := 0; k < icMaxShifts; k++ {
+		if ic.factorize(shift) {
+			return
+		}
+		shift = math.Max(2*shift, icShiftFactor*maxDiag)
+	}
+	// The Hessian is not usable, for example because it contains NaN, so
+	// fall back to the identity.
+	for i := range ic.cols {
+		ic.cols[i] = append(ic.cols[i][:0], i)
+		ic.vals[i] = append(ic.vals[i][:0], 1)
+	}
+}
+
+// factorize computes the IC(0) factorization of the Hessian shifted by shift
+// times the identity and returns whether it succeeded.
+func (ic *IncompleteCholesky) factorize(shift float64) bool {
+	for i := range ic.cols {
+		cols := ic.cols[i][:0]
+		vals := ic.vals[i][:0]
+		for j := 0; j < i; j++ {
+			aij := ic.hess.At(i, j)
+			if aij == 0 {
+				continue
+			}
+			// Subtract the products of the already computed elements of the
+			// rows i and j of L in the common columns smaller than j.
+			colsj, valsj := ic.cols[j], ic.vals[j]
+			var p, q int
+			for p < len(cols) && q < len(colsj)-1 {
+				switch {
+				case cols[p] < colsj[q]:
+					p++
+				case cols[p] > colsj[q]:
+					q++
+				default:
+					aij -= vals[p] * valsj[q]
+					p++
+					q++
+				}
+			}
+			cols = append(cols, j)
+			vals = append(vals, aij/valsj[len(valsj)-1])
+		}
+		d := ic.hess.At(i, i) + shift
+		for _, v := range vals {
+			d -= v * v
+		}
+		if !(d > 0) || math.IsInf(d, 1) {
+			return false
+		}
+		ic.cols[i] = append(cols, i)
+		ic.vals[i] = append(vals, math.Sqrt(d))
+	}
+	return true
+}
+
+func (ic *IncompleteCholesky) Apply(dst, v []float64) {
+	copy(dst, v)
+	// Solve L y = v.
+	for i, cols := range ic.cols {
+		vals := ic.vals[i]
+		n := len(cols) - 1
+		s := dst[i]
+		for p, j := range cols[:n] {
+			s -= vals[p] * dst[j]
+		}
+		dst[i] = s / vals[n]
+	}
+	// Solve L^T z = y.
+	for i := len(ic.cols) - 1; i >= 0; i-- {
+		cols, vals := ic.cols[i], ic.vals[i]
+		n := len(cols) - 1
+		dst[i] /= vals[n]
+		for p, j := range cols[:n] {
+			dst[j] -= vals[p] * dst[i]
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// diffusion is the quadratic function 1/2 x^T A x - b^T x, where A is the
+// finite-difference discretization of -(c u')' on a uniform grid in [0, 1]
+// with diffusion coefficients c that vary over three orders of magnitude, and
+// b is a vector of ones. A is tridiagonal and badly conditioned.
+type diffusion struct {
+	c []float64 // Coefficients between the grid points, len(c) = dim+1.
+}
+
+func newDiffusion(dim int) diffusion {
+	// The coefficients are divided by the square of the grid spacing.
+	h2 := float64((dim + 1) * (dim + 1))
+	c := make([]float64, dim+1)
+	for i := range c {
+		c[i] = h2 * math.Pow(10, 3*float64(i)/float64(dim))
+	}
+	return diffusion{c}
+}
+
+func (d diffusion) mulVec(dst, x []float64) {
+	n := len(x)
+	for i := range x {
+		v := (d.c[i] + d.c[i+1]) * x[i]
+		if i > 0 {
+			v -= d.c[i] * x[i-1]
+		}
+		if i < n-1 {
+			v -= d.c[i+1] * x[i+1]
+		}
+		dst[i] = v
+	}
+}
+
+func (d diffusion) Func(x []float64) float64 {
+	ax := make([]float64, len(x))
+	d.mulVec(ax, x)
+	return 0.5*floats.Dot(x, ax) - floats.Sum(x)
+}
+
+func (d diffusion) Grad(grad, x []float64) {
+	d.mulVec(grad, x)
+	floats.AddConst(-1, grad)
+}
+
+func (d diffusion) Diagonal(diag, _ []float64) {
+	for i := range diag {
+		diag[i] = d.c[i] + d.c[i+1]
+	}
+}
+
+func (d diffusion) Hess(hess mat64.MutableSymmetric, _ []float64) {
+	n := hess.Symmetric()
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			var v float64
+			switch j {
+			case i:
+				v = d.c[i] + d.c[i+1]
+			case i + 1:
+				v = -d.c[i+1]
+			}
+			hess.SetSym(i, j, v)
+		}
+	}
+}
+
+func TestIncompleteCholesky(t *testing.T) {
+	// The IC(0) factorization of a tridiagonal matrix is its exact Cholesky
+	// factorization.
+	const dim = 50
+	d := newDiffusion(dim)
+	ic := &IncompleteCholesky{Hessian: d.Hess}
+	ic.Init(&Location{X: make([]float64, dim)})
+	rnd := rand.New(rand.NewSource(1))
+	x := make([]float64, dim)
+	for i := range x {
+		x[i] = rnd.NormFloat64()
+	}
+	ax := make([]float64, dim)
+	d.mulVec(ax, x)
+	got := make([]float64, dim)
+	ic.Apply(got, ax)
+	if !floats.EqualApprox(got, x, 1e-8) {
+		t.Errorf("unexpected solution: got %v, want %v", got, x)
+	}
+	// Apply must work in place.
+	ic.Apply(ax, ax)
+	if !floats.Equal(ax, got) {
+		t.Errorf("in-place Apply differs")
+	}
+
+	// An indefinite matrix is shifted so that M^{-1} is positive definite.
+	indefinite := func(hess mat64.MutableSymmetric, _ []float64) {
+		n := hess.Symmetric()
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				var v float64
+				switch j {
+				case i:
+					v = float64(i%3 - 1)
+				case i + 1, i + 3:
+					v = 2
+				}
+				hess.SetSym(i, j, v)
+			}
+		}
+	}
+	ic = &IncompleteCholesky{Hessian: indefinite}
+	ic.Init(&Location{X: make([]float64, dim)})
+	for k := 0; k < 10; k++ {
+		for i := range x {
+			x[i] = rnd.NormFloat64()
+		}
+		ic.Apply(got, x)
+		if dot := floats.Dot(x, got); !(dot > 0) {
+			t.Errorf("M^{-1} not positive definite: v·M^{-1}v = %v", dot)
+		}
+	}
+}
+
+func TestPreconditioner(t *testing.T) {
+	const dim = 50
+	d := newDiffusion(dim)
+	// The largest eigenvalue of the Hessian is about 1e7, so the decrease
+	// of the function value for much smaller gradients is lost in rounding.
+	test := unconstrainedTest{
+		name:    "Diffusion",
+		p:       Problem{Func: d.Func, Grad: d.Grad},
+		x:       make([]float64, dim),
+		gradTol: 1e-4,
+	}
+	newPreconditioners := []func() Preconditioner{
+		func() Preconditioner { return &Jacobi{Diagonal: d.Diagonal} },
+		func() Preconditioner { return &IncompleteCholesky{Hessian: d.Hess} },
+		func() Preconditioner { return &IncompleteCholesky{Hessian: d.Hess, Frequency: 1} },
+	}
+	for _, newPreconditioner := range newPreconditioners {
+		methods := []Method{
+			&CG{Preconditioner: newPreconditioner()},
+			&CG{Variant: &FletcherReeves{}, Preconditioner: newPreconditioner()},
+			&CG{Variant: &PolakRibierePolyak{}, Preconditioner: newPreconditioner()},
+			&CG{Variant: &DaiYuan{}, Preconditioner: newPreconditioner()},
+			&CG{Variant: &HagerZhang{}, Preconditioner: newPreconditioner()},
+			&LBFGS{Preconditioner: newPreconditioner()},
+			&LBFGS{Scaling: IdentityScaling, Preconditioner: newPreconditioner()},
+		}
+		for _, method := range methods {
+			testLocal(t, []unconstrainedTest{test}, method)
+		}
+	}
+
+	// Preconditioning reduces the number of iterations at least by half.
+	settings := DefaultSettings()
+	settings.Recorder = nil
+	settings.FunctionConverge = nil
+	settings.GradientThreshold = test.gradTol
+	for _, newMethod := range []func(Preconditioner) Method{
+		func(pc Preconditioner) Method { return &CG{Preconditioner: pc} },
+		func(pc Preconditioner) Method { return &LBFGS{Preconditioner: pc} },
+	} {
+		result, err := Local(test.p, test.x, settings, newMethod(nil))
+		if err != nil {
+			t.Errorf("%T: unexpected error: %v", newMethod(nil), err)
+			continue
+		}
+		plain := result.MajorIterations
+		for _, newPreconditioner := range newPreconditioners {
+			method := newMethod(newPreconditioner())
+			result, err := Local(test.p, test.x, settings, method)
+			if err != nil {
+				t.Errorf("%T: unexpected error: %v", method, err)
+				continue
+			}
+			if result.Status != GradientThreshold {
+				t.Errorf("%T: unexpected status %v", method, result.Status)
+			}
+			if 2*result.MajorIterations > plain {
+				t.Errorf("%T with %T: %v iterations, %v without a preconditioner",
+					method, newPreconditioner(), result.MajorIterations, plain)
+			}
+		}
+	}
+
+	// With the exact factorization of the Hessian, the first direction of
+	// GradientDescent is the Newton direction to the minimum.
+	result, err := Local(test.p, test.x, settings, &GradientDescent{
+		Preconditioner: &IncompleteCholesky{Hessian: d.Hess},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.MajorIterations > 2 {
+		t.Errorf("too many iterations with the exact preconditioner: %d", result.MajorIterations)
+	}
+}
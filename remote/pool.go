@@ -0,0 +1,269 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/gonum/optimize"
+)
+
+const (
+	defaultHeartbeatInterval = time.Second
+	defaultTimeout           = 5 * time.Second
+	defaultRetries           = 3
+)
+
+// ErrNoWorkers is returned by Pool.Evaluate when no worker is connected.
+var ErrNoWorkers = errors.New("remote: no workers")
+
+// Pool is an optimize.Evaluator that distributes the evaluations among the
+// connected Workers. Every evaluation is sent to the worker with the fewest
+// evaluations in progress.
+//
+// The Pool sends a heartbeat to every worker at regular intervals. A worker
+// that does not answer a heartbeat within Timeout, or whose connection fails,
+// is lost: it is disconnected and its evaluations in progress are retried on
+// the other workers. A retried evaluation keeps its request ID, and only one
+// result is accepted for every evaluation, so results that arrive late from
+// a lost worker are discarded.
+//
+// The settings of a Pool must not be changed after the first worker is added.
+type Pool struct {
+	// HeartbeatInterval is the interval between two heartbeats sent to
+	// a worker.
+	// If HeartbeatInterval is zero, it will be set to one second.
+	HeartbeatInterval time.Duration
+	// Timeout is the time a worker has to answer a heartbeat.
+	// If Timeout is zero, it will be set to five seconds.
+	Timeout time.Duration
+	// Retries is the number of times a lost evaluation is retried before
+	// Evaluate returns an error.
+	// If Retries is zero, it will be set to 3.
+	Retries int
+
+	mux     sync.Mutex
+	workers []*poolWorker
+	nextID  uint64
+	started bool
+}
+
+// poolWorker is a Worker connected to a Pool.
+type poolWorker struct {
+	client *rpc.Client
+	active int           // Evaluations in progress.
+	lost   bool          // The worker has been disconnected.
+	stop   chan struct{} // Stops the heartbeats.
+}
+
+// Dial connects the Pool to the Worker at the given network address.
+func (p *Pool) Dial(network, address string) error {
+	client, err := rpc.Dial(network, address)
+	if err != nil {
+		return err
+	}
+	p.AddWorker(client)
+	return nil
+}
+
+// AddWorker adds the Worker served on the other end of client to the Pool.
+// The Pool takes ownership of client and closes it when the worker is lost
+// or the Pool is closed.
+func (p *Pool) AddWorker(client *rpc.Client) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if !p.started {
+		// Start the IDs at a random value so that the IDs of different
+		// pools connected to the same worker do not collide.
+		p.nextID = uint64(rand.Int63())
+		p.started = true
+	}
+	w := &poolWorker{
+		client: client,
+		stop:   make(chan struct{}),
+	}
+	p.workers = append(p.workers, w)
+	go p.heartbeat(w)
+}
+
+// Workers returns the number of connected workers.
+func (p *Pool) Workers() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return len(p.workers)
+}
+
+// Close disconnects all the workers. Evaluations in progress fail.
+func (p *Pool) Close() {
+	p.mux.Lock()
+	workers := p.workers
+	p.mux.Unlock()
+	for _, w := range workers {
+		p.lose(w)
+	}
+}
+
+// Evaluate sends the evaluation to a worker and stores the result in loc.
+// Evaluate returns an error if the evaluation fails on the worker, if no
+// worker is connected, or if the evaluation is lost more than Retries times.
+func (p *Pool) Evaluate(op optimize.Operation, x []float64, loc *optimize.Location) error {
+	retries := p.Retries
+	if retries == 0 {
+		retries = defaultRetries
+	}
+	p.mux.Lock()
+	p.nextID++
+	req := Request{ID: p.nextID, Op: op, X: x, Component: loc.Component}
+	if op&optimize.HessVecEvaluation != 0 {
+		req.Direction = loc.Direction
+	}
+	if op&optimize.PartialGradEvaluation != 0 {
+		req.Coordinates = loc.Coordinates
+	}
+	p.mux.Unlock()
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		w := p.acquire()
+		if w == nil {
+			return ErrNoWorkers
+		}
+		var reply Reply
+		err = w.client.Call(serviceName+".Evaluate", req, &reply)
+		p.release(w)
+		if err == nil {
+			return store(loc, op, &reply)
+		}
+		if _, ok := err.(rpc.ServerError); ok {
+			return err
+		}
+		// The connection has failed, so the evaluation is lost.
+		p.lose(w)
+	}
+	return fmt.Errorf("remote: evaluation lost %d times: %v", retries+1, err)
+}
+
+// store stores the result of the evaluation in loc.
+func store(loc *optimize.Location, op optimize.Operation, reply *Reply) error {
+	dim := len(loc.X)
+	if op&optimize.FuncEvaluation != 0 {
+		loc.F = reply.F
+	}
+	if op&(optimize.GradEvaluation|optimize.ComponentGradEvaluation) != 0 {
+		if len(reply.Gradient) != dim {
+			return errors.New("remote: gradient size mismatch")
+		}
+		copy(loc.Gradient, reply.Gradient)
+	}
+	if op&optimize.HessEvaluation != 0 {
+		if len(reply.Hessian) != dim*dim {
+			return errors.New("remote: Hessian size mismatch")
+		}
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				loc.Hessian.SetSym(i, j, reply.Hessian[i*dim+j])
+			}
+		}
+	}
+	if op&optimize.HessVecEvaluation != 0 {
+		if len(reply.HessVec) != dim {
+			return errors.New("remote: Hessian-vector product size mismatch")
+		}
+		copy(loc.HessVec, reply.HessVec)
+	}
+	if op&optimize.PartialGradEvaluation != 0 {
+		if len(reply.PartialGradient) != dim {
+			return errors.New("remote: partial gradient size mismatch")
+		}
+		for _, i := range loc.Coordinates {
+			loc.PartialGradient[i] = reply.PartialGradient[i]
+		}
+	}
+	return nil
+}
+
+// acquire returns the worker with the fewest evaluations in progress, or nil
+// if no worker is connected.
+func (p *Pool) acquire() *poolWorker {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	var best *poolWorker
+	for _, w := range p.workers {
+		if best == nil || w.active < best.active {
+			best = w
+		}
+	}
+	if best != nil {
+		best.active++
+	}
+	return best
+}
+
+func (p *Pool) release(w *poolWorker) {
+	p.mux.Lock()
+	w.active--
+	p.mux.Unlock()
+}
+
+// lose disconnects the worker w. Its evaluations in progress fail with
+// rpc.ErrShutdown and are retried.
+func (p *Pool) lose(w *poolWorker) {
+	p.mux.Lock()
+	if w.lost {
+		p.mux.Unlock()
+		return
+	}
+	w.lost = true
+	for i, v := range p.workers {
+		if v == w {
+			p.workers = append(p.workers[:i], p.workers[i+1:]...)
+			break
+		}
+	}
+	p.mux.Unlock()
+	close(w.stop)
+	w.client.Close()
+}
+
+// heartbeat sends heartbeats to w until it is lost.
+func (p *Pool) heartbeat(w *poolWorker) {
+	interval := p.HeartbeatInterval
+	if interval == 0 {
+		interval = defaultHeartbeatInterval
+	}
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for seq := 0; ; seq++ {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+		call := w.client.Go(serviceName+".Ping", seq, new(int), make(chan *rpc.Call, 1))
+		timer := time.NewTimer(timeout)
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return
+		case <-call.Done:
+			timer.Stop()
+			if call.Error == nil {
+				continue
+			}
+		case <-timer.C:
+		}
+		p.lose(w)
+		return
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package remote
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/rpc"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize"
+	"github.com/gonum/optimize/functions"
+)
+
+// addLocalWorker serves w in-process and adds it to p.
+func addLocalWorker(p *Pool, w *Worker) {
+	client, server := net.Pipe()
+	go w.ServeConn(server)
+	p.AddWorker(rpc.NewClient(client))
+}
+
+// addDeadWorker adds to p a worker that reads its requests but never
+// answers them.
+func addDeadWorker(p *Pool) {
+	client, server := net.Pipe()
+	go io.Copy(ioutil.Discard, server)
+	p.AddWorker(rpc.NewClient(client))
+}
+
+// sphere is the squared distance from (1, 1, ..., 1).
+func sphere(x []float64) float64 {
+	var f float64
+	for _, v := range x {
+		f += (v - 1) * (v - 1)
+	}
+	return f
+}
+
+func TestPoolGlobal(t *testing.T) {
+	var calls int64
+	problem := optimize.Problem{
+		Func: func(x []float64) float64 {
+			atomic.AddInt64(&calls, 1)
+			return sphere(x)
+		},
+	}
+	pool := &Pool{HeartbeatInterval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond}
+	defer pool.Close()
+	for i := 0; i < 3; i++ {
+		addLocalWorker(pool, &Worker{Problem: problem})
+	}
+	addDeadWorker(pool)
+
+	settings := optimize.DefaultSettingsGlobal()
+	settings.FunctionConverge = nil
+	settings.FuncEvaluations = 1000
+	settings.Concurrent = 4
+	settings.Evaluator = pool
+	method := &optimize.GeneticAlgorithm{
+		Bounds: []optimize.Bound{{-2, 2}, {-2, 2}},
+		Src:    rand.New(rand.NewSource(1)),
+	}
+	// The objective function is only known to the workers.
+	result, err := optimize.Global(optimize.Problem{}, 2, settings, method)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != optimize.FunctionEvaluationLimit {
+		t.Errorf("unexpected status %v", result.Status)
+	}
+	if result.F > 1e-2 {
+		t.Errorf("minimum not found, got %v", result.F)
+	}
+	if result.F != sphere(result.X) {
+		t.Errorf("function value at X not equal to F")
+	}
+	if calls != int64(result.FuncEvaluations) {
+		t.Errorf("unexpected number of evaluations on the workers, want %v, got %v", result.FuncEvaluations, calls)
+	}
+	if pool.Workers() != 3 {
+		t.Errorf("dead worker not lost, %v workers connected", pool.Workers())
+	}
+}
+
+func TestPoolLocal(t *testing.T) {
+	f := functions.Beale{}
+	problem := optimize.Problem{Func: f.Func, Grad: f.Grad, Hess: f.Hess}
+	pool := &Pool{}
+	defer pool.Close()
+	addLocalWorker(pool, &Worker{Problem: problem})
+
+	settings := optimize.DefaultSettings()
+	settings.Evaluator = pool
+	result, err := optimize.Local(optimize.Problem{}, []float64{1, 1}, settings, &optimize.Newton{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !floats.EqualApprox(result.X, []float64{3, 0.5}, 1e-6) {
+		t.Errorf("minimum not found, got %v", result.X)
+	}
+}
+
+func TestPoolHessVec(t *testing.T) {
+	f := functions.Beale{}
+	problem := optimize.Problem{
+		Func: f.Func,
+		Grad: f.Grad,
+		HessVec: func(hv, x, v []float64) {
+			h := mat64.NewSymDense(len(x), nil)
+			f.Hess(h, x)
+			mat64.NewVector(len(hv), hv).MulVec(h, mat64.NewVector(len(v), v))
+		},
+	}
+	pool := &Pool{}
+	defer pool.Close()
+	addLocalWorker(pool, &Worker{Problem: problem})
+
+	settings := optimize.DefaultSettings()
+	settings.Evaluator = pool
+	// The products are requested from the workers.
+	result, err := optimize.Local(optimize.Problem{}, []float64{1, 1}, settings, &optimize.TruncatedNewton{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.HessEvaluations == 0 {
+		t.Errorf("no Hessian-vector products evaluated")
+	}
+	if !floats.EqualApprox(result.X, []float64{3, 0.5}, 1e-4) {
+		t.Errorf("minimum not found, got %v", result.X)
+	}
+}
+
+func TestPoolPartialGrad(t *testing.T) {
+	// The separable quadratic sum_i (x_i - i)^2 / 2.
+	problem := optimize.Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i, v := range x {
+				f += 0.5 * (v - float64(i)) * (v - float64(i))
+			}
+			return f
+		},
+		PartialGrad: func(grad, x []float64, coords []int) {
+			for _, i := range coords {
+				grad[i] = x[i] - float64(i)
+			}
+		},
+	}
+	pool := &Pool{}
+	defer pool.Close()
+	addLocalWorker(pool, &Worker{Problem: problem})
+
+	settings := optimize.DefaultSettings()
+	settings.Evaluator = pool
+	// GaussSouthwellSelection needs the partial derivatives, which are
+	// requested from the workers.
+	method := &optimize.CoordinateDescent{Selection: optimize.GaussSouthwellSelection}
+	result, err := optimize.Local(optimize.Problem{}, []float64{3, 3, 3}, settings, method)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.GradEvaluations == 0 {
+		t.Errorf("no partial derivatives evaluated")
+	}
+	if !floats.EqualApprox(result.X, []float64{0, 1, 2}, 1e-6) {
+		t.Errorf("minimum not found, got %v", result.X)
+	}
+}
+
+func TestPoolNoWorkers(t *testing.T) {
+	pool := &Pool{}
+	loc := &optimize.Location{X: []float64{0}}
+	if err := pool.Evaluate(optimize.FuncEvaluation, []float64{0}, loc); err != ErrNoWorkers {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkerDuplicates(t *testing.T) {
+	var calls int64
+	w := &Worker{
+		Problem: optimize.Problem{
+			Func: func(x []float64) float64 {
+				atomic.AddInt64(&calls, 1)
+				return x[0] * x[0]
+			},
+		},
+	}
+	for i := 0; i < 3; i++ {
+		var reply Reply
+		if err := w.Evaluate(Request{ID: 7, Op: optimize.FuncEvaluation, X: []float64{3}}, &reply); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reply.F != 9 {
+			t.Errorf("unexpected function value, want 9, got %v", reply.F)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("duplicate request evaluated %v times", calls)
+	}
+	var reply Reply
+	w.Evaluate(Request{ID: 8, Op: optimize.FuncEvaluation, X: []float64{2}}, &reply)
+	if calls != 2 || reply.F != 4 {
+		t.Errorf("new request not evaluated")
+	}
+}
+
+func TestWorkerMissingRoutine(t *testing.T) {
+	w := &Worker{Problem: optimize.Problem{Func: sphere}}
+	for _, op := range []optimize.Operation{
+		optimize.GradEvaluation,
+		optimize.FuncEvaluation | optimize.HessEvaluation,
+	} {
+		var reply Reply
+		if err := w.Evaluate(Request{ID: uint64(op), Op: op, X: []float64{3}}, &reply); err == nil {
+			t.Errorf("no error for %v", op)
+		}
+	}
+
+	// The error reaches the optimization through the Pool.
+	pool := &Pool{}
+	defer pool.Close()
+	addLocalWorker(pool, w)
+	settings := optimize.DefaultSettings()
+	settings.Evaluator = pool
+	_, err := optimize.Local(optimize.Problem{}, []float64{1, 1}, settings, &optimize.BFGS{})
+	if _, ok := err.(rpc.ServerError); !ok {
+		t.Errorf("unexpected error for missing gradient: %v", err)
+	}
+	if pool.Workers() != 1 {
+		t.Errorf("worker lost after an evaluation error")
+	}
+}
+
+func TestPoolComponents(t *testing.T) {
+	// The components (x - c_i)^2 / 2 have the sum minimized by the mean of
+	// c_i.
+	c := []float64{-1, 0, 2, 3}
+	sum := optimize.FiniteSum(optimize.FiniteSumProblem{
+		Func: func(x []float64, i int) float64 {
+			return 0.5 * (x[0] - c[i]) * (x[0] - c[i])
+		},
+		Grad: func(grad, x []float64, i int) {
+			grad[0] = x[0] - c[i]
+		},
+		Components: len(c),
+	})
+	pool := &Pool{}
+	defer pool.Close()
+	addLocalWorker(pool, &Worker{Problem: sum})
+
+	settings := optimize.DefaultSettings()
+	settings.FunctionConverge = nil
+	settings.MajorIterations = 100
+	settings.Evaluator = pool
+	// Only the number of components is used locally.
+	local := optimize.Problem{Components: len(c)}
+	method := &optimize.SAGA{StepSize: 0.3, Src: rand.New(rand.NewSource(1))}
+	result, err := optimize.Local(local, []float64{10}, settings, method)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != optimize.GradientThreshold {
+		t.Errorf("unexpected status %v", result.Status)
+	}
+	if math.Abs(result.X[0]-1) > 1e-6 {
+		t.Errorf("minimum not found, got %v", result.X)
+	}
+}
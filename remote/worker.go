@@ -0,0 +1,180 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package remote evaluates the objective functions of optimization problems in
+// other processes or on other machines. A Worker serves the evaluations of
+// a Problem over net/rpc, and a Pool distributes the evaluations requested by
+// an optimization among the connected workers. A Pool implements
+// optimize.Evaluator, so it is used through the Evaluator field of
+// optimize.Settings.
+package remote
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize"
+)
+
+// serviceName is the name under which a Worker is registered with net/rpc.
+const serviceName = "Worker"
+
+// workerCacheSize is the number of recent evaluations remembered by a Worker.
+const workerCacheSize = 1024
+
+// Request is an evaluation request sent by a Pool to a Worker.
+type Request struct {
+	// ID identifies the evaluation. A retried evaluation keeps its ID.
+	ID uint64
+	// Op is the evaluation operation.
+	Op optimize.Operation
+	// X is the location of the evaluation.
+	X []float64
+	// Component is the component of a ComponentGradEvaluation.
+	Component int
+	// Direction is the vector of a HessVecEvaluation.
+	Direction []float64
+	// Coordinates are the coordinates of a PartialGradEvaluation.
+	Coordinates []int
+}
+
+// Reply is the result of an evaluation sent by a Worker to a Pool.
+type Reply struct {
+	F        float64
+	Gradient []float64
+	Hessian  []float64 // Hessian in row-major order.
+	HessVec  []float64
+
+	PartialGradient []float64
+}
+
+// Worker evaluates the routines of Problem for the Pools connected to it.
+// A Worker remembers the results of its recent evaluations, so a request that
+// is sent again with the same ID, for example after a dropped connection, is
+// not evaluated twice.
+type Worker struct {
+	Problem optimize.Problem
+
+	mux     sync.Mutex
+	results map[uint64]*evaluation
+	order   []uint64 // IDs of the remembered evaluations, oldest first.
+}
+
+// evaluation is an evaluation carried out by a Worker.
+type evaluation struct {
+	done  chan struct{} // Closed when reply and err are complete.
+	reply Reply
+	err   error
+}
+
+// Serve accepts connections on l and serves the requests of Pools on them.
+// Serve blocks until l is closed.
+func (w *Worker) Serve(l net.Listener) {
+	w.server().Accept(l)
+}
+
+// ServeConn serves the requests of a Pool on a single connection. ServeConn
+// blocks until the client hangs up.
+func (w *Worker) ServeConn(conn io.ReadWriteCloser) {
+	w.server().ServeConn(conn)
+}
+
+func (w *Worker) server() *rpc.Server {
+	s := rpc.NewServer()
+	if err := s.RegisterName(serviceName, w); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Evaluate evaluates the request and stores the result in reply. If an
+// evaluation with the same ID has been requested recently, its result is
+// returned instead, waiting for it if it is still in progress. Evaluate
+// returns an error if the request needs a routine that Problem does not
+// provide.
+func (w *Worker) Evaluate(req Request, reply *Reply) error {
+	w.mux.Lock()
+	if w.results == nil {
+		w.results = make(map[uint64]*evaluation)
+	}
+	e, ok := w.results[req.ID]
+	if !ok {
+		e = &evaluation{done: make(chan struct{})}
+		w.results[req.ID] = e
+		w.order = append(w.order, req.ID)
+		if len(w.order) > workerCacheSize {
+			delete(w.results, w.order[0])
+			w.order = w.order[1:]
+		}
+	}
+	w.mux.Unlock()
+
+	if !ok {
+		e.err = w.evaluate(req, &e.reply)
+		close(e.done)
+	}
+	<-e.done
+	*reply = e.reply
+	return e.err
+}
+
+// evaluate evaluates the routines of Problem requested by req.
+func (w *Worker) evaluate(req Request, reply *Reply) error {
+	p := w.Problem
+	dim := len(req.X)
+	switch {
+	case req.Op&optimize.FuncEvaluation != 0 && p.Func == nil:
+		return errors.New("remote: problem does not provide Func")
+	case req.Op&optimize.GradEvaluation != 0 && p.Grad == nil:
+		return errors.New("remote: problem does not provide Grad")
+	case req.Op&optimize.HessEvaluation != 0 && p.Hess == nil:
+		return errors.New("remote: problem does not provide Hess")
+	case req.Op&optimize.ComponentGradEvaluation != 0 && p.ComponentGrad == nil:
+		return errors.New("remote: problem does not provide ComponentGrad")
+	case req.Op&optimize.HessVecEvaluation != 0 && p.HessVec == nil:
+		return errors.New("remote: problem does not provide HessVec")
+	case req.Op&optimize.PartialGradEvaluation != 0 && p.PartialGrad == nil:
+		return errors.New("remote: problem does not provide PartialGrad")
+	}
+	if req.Op&optimize.FuncEvaluation != 0 {
+		reply.F = p.Func(req.X)
+	}
+	if req.Op&optimize.GradEvaluation != 0 {
+		reply.Gradient = make([]float64, dim)
+		p.Grad(reply.Gradient, req.X)
+	}
+	if req.Op&optimize.ComponentGradEvaluation != 0 {
+		reply.Gradient = make([]float64, dim)
+		p.ComponentGrad(reply.Gradient, req.X, req.Component)
+	}
+	if req.Op&optimize.HessEvaluation != 0 {
+		hess := mat64.NewSymDense(dim, nil)
+		p.Hess(hess, req.X)
+		reply.Hessian = make([]float64, dim*dim)
+		for i := 0; i < dim; i++ {
+			for j := 0; j < dim; j++ {
+				reply.Hessian[i*dim+j] = hess.At(i, j)
+			}
+		}
+	}
+	if req.Op&optimize.HessVecEvaluation != 0 {
+		reply.HessVec = make([]float64, dim)
+		p.HessVec(reply.HessVec, req.X, req.Direction)
+	}
+	if req.Op&optimize.PartialGradEvaluation != 0 {
+		reply.PartialGradient = make([]float64, dim)
+		p.PartialGrad(reply.PartialGradient, req.X, req.Coordinates)
+	}
+	return nil
+}
+
+// Ping answers the heartbeats of a Pool by returning seq in reply.
+func (w *Worker) Ping(seq int, reply *int) error {
+	*reply = seq
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+// RMSProp implements the method of Tieleman and Hinton for minimizing
+// a StochasticProblem, see Minibatches. The learning rate of every variable is
+// divided by the root of a moving average of its squared minibatch gradients,
+//  s_{k+1} = Decay * s_k + (1 - Decay) * g_k^2,
+//  x_{k+1} = x_k - rate_k * g_k / (sqrt(s_{k+1}) + Epsilon).
+// Unlike in AdaGrad, old gradients are forgotten and the steps do not shrink
+// over time.
+//
+// RMSProp does not keep the best location found, so the result of Local is
+// the last location. The convergence of the noisy iterations is tested by
+// Converge, see StochasticConverge and DefaultSettingsStochastic.
+type RMSProp struct {
+	// LearningRate is the schedule of the learning rate.
+	// If LearningRate is nil, a constant rate of 0.001 is used.
+	LearningRate LearningRate
+	// Decay is the weight of the previous average, in [0, 1).
+	// If Decay is zero, 0.9 is used.
+	Decay float64
+	// Epsilon avoids the division by zero.
+	// If Epsilon is zero, 1e-8 is used.
+	Epsilon float64
+	// Converge tests the convergence of the iterations. If Converge is nil,
+	// the iterations run until a limit of Settings is reached.
+	Converge *StochasticConverge
+
+	decay float64
+	eps   float64
+	s     []float64
+	sg    stochasticGradient
+}
+
+func (r *RMSProp) Init(loc *Location) (Operation, error) {
+	r.decay = r.Decay
+	if r.decay == 0 {
+		r.decay = defaultAdaptiveDecay
+	}
+	if r.decay < 0 || r.decay >= 1 {
+		panic("rmsprop: Decay out of range")
+	}
+	r.eps = r.Epsilon
+	if r.eps == 0 {
+		r.eps = defaultAdaptiveEps
+	}
+	if r.eps < 0 {
+		panic("rmsprop: negative Epsilon")
+	}
+	return r.sg.init(loc, r, r.Converge)
+}
+
+func (r *RMSProp) Iterate(loc *Location) (Operation, error) {
+	return r.sg.iterate(loc, r)
+}
+
+// Status returns the status of the convergence test of Converge.
+func (r *RMSProp) Status() (Status, error) {
+	return r.sg.status, nil
+}
+
+func (r *RMSProp) initStep(dim int) {
+	r.s = resize(r.s, dim)
+	for i := range r.s {
+		r.s[i] = 0
+	}
+}
+
+func (r *RMSProp) step(x, grad []float64, k int) {
+	rate := learningRate(r.LearningRate, k, defaultAdaptiveRate)
+	for i, g := range grad {
+		r.s[i] = r.decay*r.s[i] + (1-r.decay)*g*g
+		x[i] -= rate * g / (math.Sqrt(r.s[i]) + r.eps)
+	}
+}
+
+func (*RMSProp) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return stochasticNeeds()
+}
@@ -0,0 +1,144 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// defaultRootThreshold is the default threshold of half of the squared norm
+// of the residuals, which corresponds to a norm of about 1.4e-10.
+const defaultRootThreshold = 1e-20
+
+// RootProblem describes a system of n nonlinear equations F(x) = 0 in n
+// unknowns to be solved.
+type RootProblem struct {
+	// Func evaluates the residuals F(x) of the equations at x and stores
+	// them in f, which has the same length as x. Func must not modify x.
+	Func func(f, x []float64)
+
+	// Jacobian evaluates the Jacobian of F at x and stores it in jac, which
+	// has a row for every equation and a column for every dimension.
+	// Jacobian must not modify x.
+	Jacobian func(jac *mat64.Dense, x []float64)
+
+	// Status reports the status of the equations being solved and any error,
+	// as for Problem.
+	Status func() (Status, error)
+}
+
+// DefaultRootSettings returns the default settings of Root. The solution
+// terminates with FunctionThreshold when half of the squared norm of the
+// residuals is smaller than 1e-20.
+func DefaultRootSettings() *Settings {
+	return &Settings{
+		FunctionThreshold: defaultRootThreshold,
+	}
+}
+
+// Root solves the system of nonlinear equations p starting from the initial
+// location initX with method.
+//
+// The equations are solved as the minimization of half of the squared norm
+// of the residuals, 1/2 |F(x)|^2, by Local, so method is a Method that uses
+// the same reverse-communication interface and Operations as the minimization
+// methods. A FuncEvaluation evaluates the residuals, which are stored in
+// Location.Objectives, and sets Location.F to half of their squared norm.
+// A GradEvaluation evaluates the Jacobian of F in Location.Jacobian and the
+// gradient J^T F of Location.F in Location.Gradient. As the gradient needs
+// the residuals, a method must request a GradEvaluation together with or
+// after the FuncEvaluation at the same location. Methods request the Jacobian
+// with Needs().Gradient, and Hessians are not supported. NewtonRoot, Broyden
+// and PowellHybrid are methods for Root. If method is nil, PowellHybrid is
+// used if p.Jacobian is not nil, and Broyden otherwise.
+//
+// The solution runs with the given settings, or with DefaultRootSettings if
+// settings is nil, and Root returns when Location.F is smaller than
+// FunctionThreshold. The limits of settings apply, where GradEvaluations
+// limits the evaluations of the Jacobian. GradientThreshold applies to the
+// gradient J^T F, which also vanishes at the minima of |F(x)| that are not
+// solutions. UseInitialData is ignored, and the Evaluator of settings must be
+// nil.
+func Root(p RootProblem, initX []float64, settings *Settings, method Method) (*Result, error) {
+	if p.Func == nil {
+		panic("optimize: residual function is undefined")
+	}
+	if method == nil {
+		method = getDefaultRootMethod(&p)
+	}
+	if settings == nil {
+		settings = DefaultRootSettings()
+	}
+	if settings.Evaluator != nil {
+		return nil, errors.New("optimize: Evaluator not supported for root problems")
+	}
+	needs := method.Needs()
+	if needs.Gradient && p.Jacobian == nil {
+		return nil, errors.New("optimize: problem does not provide needed Jacobian function")
+	}
+	if needs.Hessian {
+		return nil, errors.New("optimize: root problems do not support Hessian evaluations")
+	}
+	s := *settings
+	s.UseInitialData = false
+	s.Evaluator = rootEvaluator{p}
+	return Local(Problem{Status: p.Status}, initX, &s, method)
+}
+
+func getDefaultRootMethod(p *RootProblem) Method {
+	if p.Jacobian != nil {
+		return &PowellHybrid{}
+	}
+	return &Broyden{}
+}
+
+// rootEvaluator evaluates the residuals and the Jacobian of a RootProblem.
+type rootEvaluator struct {
+	p RootProblem
+}
+
+func (e rootEvaluator) Evaluate(op Operation, x []float64, loc *Location) error {
+	if op&^(FuncEvaluation|GradEvaluation) != 0 {
+		return errors.New("optimize: root problems only support function and Jacobian evaluations")
+	}
+	dim := len(x)
+	if op&FuncEvaluation != 0 {
+		loc.Objectives = resize(loc.Objectives, dim)
+		e.p.Func(loc.Objectives, x)
+		loc.F = 0.5 * floats.Dot(loc.Objectives, loc.Objectives)
+	}
+	if op&GradEvaluation != 0 {
+		if loc.Jacobian == nil {
+			loc.Jacobian = mat64.NewDense(dim, dim, nil)
+		}
+		e.p.Jacobian(loc.Jacobian, x)
+		if loc.Gradient != nil {
+			grad := mat64.NewVector(dim, loc.Gradient)
+			grad.MulVec(loc.Jacobian.T(), mat64.NewVector(dim, loc.Objectives))
+		}
+	}
+	return nil
+}
+
+// newtonRootStep stores in step the Newton step that solves jac*step = -r
+// and returns true. If jac is singular, it stores the steepest descent
+// direction -jac^T*r of 1/2 |r|^2 in step instead and returns false.
+func newtonRootStep(step []float64, jac mat64.Matrix, r []float64) bool {
+	dim := len(r)
+	s := mat64.NewVector(dim, step)
+	rv := mat64.NewVector(dim, r)
+	err := s.SolveVec(jac, rv)
+	if err == nil && floats.Norm(step, math.Inf(1)) < math.Inf(1) {
+		floats.Scale(-1, step)
+		return true
+	}
+	s.MulVec(jac.T(), rv)
+	floats.Scale(-1, step)
+	return false
+}
@@ -0,0 +1,201 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+type rootTest struct {
+	name string
+	p    RootProblem
+	x    []float64
+	root []float64
+}
+
+var rootTests = []rootTest{
+	{
+		name: "Rosenbrock",
+		p: RootProblem{
+			Func: func(f, x []float64) {
+				f[0] = 10 * (x[1] - x[0]*x[0])
+				f[1] = 1 - x[0]
+			},
+			Jacobian: func(jac *mat64.Dense, x []float64) {
+				jac.Set(0, 0, -20*x[0])
+				jac.Set(0, 1, 10)
+				jac.Set(1, 0, -1)
+				jac.Set(1, 1, 0)
+			},
+		},
+		x:    []float64{-1.2, 1},
+		root: []float64{1, 1},
+	},
+	{
+		name: "PowellBadlyScaled",
+		p: RootProblem{
+			Func: func(f, x []float64) {
+				f[0] = 1e4*x[0]*x[1] - 1
+				f[1] = math.Exp(-x[0]) + math.Exp(-x[1]) - 1.0001
+			},
+			Jacobian: func(jac *mat64.Dense, x []float64) {
+				jac.Set(0, 0, 1e4*x[1])
+				jac.Set(0, 1, 1e4*x[0])
+				jac.Set(1, 0, -math.Exp(-x[0]))
+				jac.Set(1, 1, -math.Exp(-x[1]))
+			},
+		},
+		x:    []float64{0, 1},
+		root: []float64{1.0981593296997149e-05, 9.106146739867318},
+	},
+	{
+		name: "BroydenTridiagonal",
+		p: RootProblem{
+			Func: func(f, x []float64) {
+				n := len(x)
+				for i, v := range x {
+					f[i] = (3-2*v)*v + 1
+					if i > 0 {
+						f[i] -= x[i-1]
+					}
+					if i < n-1 {
+						f[i] -= 2 * x[i+1]
+					}
+				}
+			},
+			Jacobian: func(jac *mat64.Dense, x []float64) {
+				n := len(x)
+				for i := 0; i < n; i++ {
+					for j := 0; j < n; j++ {
+						jac.Set(i, j, 0)
+					}
+					jac.Set(i, i, 3-4*x[i])
+					if i > 0 {
+						jac.Set(i, i-1, -1)
+					}
+					if i < n-1 {
+						jac.Set(i, i+1, -2)
+					}
+				}
+			},
+		},
+		x: []float64{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+	},
+	{
+		name: "Trigonometric",
+		p: RootProblem{
+			Func: func(f, x []float64) {
+				f[0] = math.Sin(x[0]) + x[1] - 1
+				f[1] = x[0] - math.Cos(x[1])
+			},
+			Jacobian: func(jac *mat64.Dense, x []float64) {
+				jac.Set(0, 0, math.Cos(x[0]))
+				jac.Set(0, 1, 1)
+				jac.Set(1, 0, 1)
+				jac.Set(1, 1, math.Sin(x[1]))
+			},
+		},
+		x: []float64{5, -3},
+	},
+}
+
+func testRoot(t *testing.T, tests []rootTest, method Method) {
+	for _, test := range tests {
+		result, err := Root(test.p, test.x, nil, method)
+		if err != nil {
+			t.Errorf("%v, %T: unexpected error: %v", test.name, method, err)
+			continue
+		}
+		if result.Status != FunctionThreshold {
+			t.Errorf("%v, %T: unexpected status %v", test.name, method, result.Status)
+		}
+		f := make([]float64, len(test.x))
+		test.p.Func(f, result.X)
+		if !floats.Equal(f, result.Objectives) {
+			t.Errorf("%v, %T: residuals at X not equal to the returned ones", test.name, method)
+		}
+		if result.F != 0.5*floats.Dot(f, f) {
+			t.Errorf("%v, %T: F not equal to half of the squared norm of the residuals", test.name, method)
+		}
+		if norm := floats.Norm(f, 2); norm > 1.5e-10 {
+			t.Errorf("%v, %T: residual norm %v too large", test.name, method, norm)
+		}
+		if test.root != nil && !floats.EqualApprox(result.X, test.root, 1e-8) {
+			t.Errorf("%v, %T: unexpected root, want %v, got %v", test.name, method, test.root, result.X)
+		}
+		if method.Needs().Gradient {
+			if result.GradEvaluations == 0 {
+				t.Errorf("%v, %T: Jacobian not evaluated", test.name, method)
+			}
+		} else if result.GradEvaluations != 0 {
+			t.Errorf("%v, %T: Jacobian evaluated by derivative-free method", test.name, method)
+		}
+	}
+}
+
+func TestNewtonRoot(t *testing.T) {
+	testRoot(t, rootTests, &NewtonRoot{})
+}
+
+func TestBroyden(t *testing.T) {
+	testRoot(t, rootTests, &Broyden{})
+}
+
+func TestPowellHybrid(t *testing.T) {
+	testRoot(t, rootTests, &PowellHybrid{})
+}
+
+func TestRootDefaultMethod(t *testing.T) {
+	test := rootTests[0]
+	for _, p := range []RootProblem{test.p, {Func: test.p.Func}} {
+		result, err := Root(p, test.x, nil, nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result.Status != FunctionThreshold {
+			t.Errorf("unexpected status %v", result.Status)
+		}
+		if (p.Jacobian == nil) != (result.GradEvaluations == 0) {
+			t.Errorf("unexpected number of Jacobian evaluations %v", result.GradEvaluations)
+		}
+	}
+
+	_, err := Root(RootProblem{Func: test.p.Func}, test.x, nil, &NewtonRoot{})
+	if err == nil {
+		t.Errorf("no error for missing Jacobian")
+	}
+}
+
+func TestPowellHybridNoRoot(t *testing.T) {
+	// The equations x_0 = 1 and x_0 = -1 have no solution, and the merit
+	// function has its minimum at x_0 = 0.
+	p := RootProblem{
+		Func: func(f, x []float64) {
+			f[0] = x[0] - 1
+			f[1] = x[0] + 1 + x[1]*x[1]
+		},
+		Jacobian: func(jac *mat64.Dense, x []float64) {
+			jac.Set(0, 0, 1)
+			jac.Set(0, 1, 0)
+			jac.Set(1, 0, 1)
+			jac.Set(1, 1, 2*x[1])
+		},
+	}
+	result, err := Root(p, []float64{3, 1}, nil, &PowellHybrid{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != StepConvergence {
+		t.Errorf("unexpected status %v", result.Status)
+	}
+	if !floats.EqualApprox(result.X, []float64{0, 0}, 1e-6) {
+		t.Errorf("minimum of the residual norm not found: %v", result.X)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math/rand"
+
+// sagaStage is the stage of an iteration of SAGA.
+type sagaStage int
+
+const (
+	sagaComponent sagaStage = iota
+	sagaFull
+	sagaMajor
+)
+
+// SAGA implements the incremental gradient method of
+//
+//  Defazio, A., Bach, F., Lacoste-Julien, S.: SAGA: A fast incremental
+//  gradient method with support for non-strongly convex composite objectives.
+//  NIPS 27 (2014), 1646-1654.
+//
+// for minimizing a finite sum. SAGA keeps a table with the last evaluated
+// gradient of every component, and their mean. Every step chooses a component
+// i uniformly at random and moves along
+//  x_{k+1} = x_k - StepSize * (∇f_i(x_k) - table_i + mean(table)),
+// then stores ∇f_i(x_k) in the table. The step direction is an unbiased
+// estimate of the gradient for any content of the table, so every entry of
+// the table is initialized with the full gradient at the starting location,
+// which Local has already evaluated, instead of evaluating all the components
+// there. Unlike SVRG, SAGA never evaluates the full gradient to make a step,
+// at the cost of storing a gradient per component. SAGA converges linearly on
+// strongly convex problems with a constant step size.
+//
+// SAGA must be used with Local on the Problem returned by FiniteSum. Every
+// step requests a ComponentGradEvaluation, which is counted as a gradient
+// evaluation. After every EpochLength steps, the function value and the full
+// gradient are evaluated and announced as a MajorIteration, so the
+// convergence tests of Settings apply to the full gradient.
+type SAGA struct {
+	// StepSize is the constant step size. It must be positive, and it is
+	// typically 1/(3L) for components with L-Lipschitz gradients.
+	StepSize float64
+	// EpochLength is the number of steps between major iterations.
+	// If EpochLength is zero, the number of components is used.
+	EpochLength int
+
+	// Src is the source of random numbers. If Src is nil, a source seeded
+	// from the global source of the math/rand package is used.
+	Src *rand.Rand
+
+	stage sagaStage
+	src   *rand.Rand
+	n     int // Number of components.
+	m     int
+	k     int         // Number of steps since the last major iteration.
+	x     []float64   // Current location.
+	table [][]float64 // Last gradient of every component.
+	mean  []float64   // Mean of table.
+}
+
+func (s *SAGA) initProblem(p *Problem, settings *Settings) error {
+	var err error
+	s.n, err = finiteSumComponents(p, settings)
+	return err
+}
+
+func (s *SAGA) Init(loc *Location) (Operation, error) {
+	s.src = checkFiniteSum(s.StepSize, s.Src)
+	s.m = s.EpochLength
+	if s.m == 0 {
+		s.m = s.n
+	}
+	if s.m < 1 {
+		panic("saga: EpochLength not positive")
+	}
+	dim := len(loc.X)
+	if len(s.table) != s.n {
+		s.table = make([][]float64, s.n)
+	}
+	for i := range s.table {
+		s.table[i] = resize(s.table[i], dim)
+		copy(s.table[i], loc.Gradient)
+	}
+	s.mean = resize(s.mean, dim)
+	copy(s.mean, loc.Gradient)
+	s.x = resize(s.x, dim)
+	copy(s.x, loc.X)
+	s.k = 0
+	return s.component(loc)
+}
+
+func (s *SAGA) Iterate(loc *Location) (Operation, error) {
+	switch s.stage {
+	case sagaFull:
+		s.stage = sagaMajor
+		return MajorIteration, nil
+	case sagaMajor:
+		s.k = 0
+		return s.component(loc)
+	}
+
+	// loc holds the gradient of the component loc.Component at x.
+	n := float64(s.n)
+	old := s.table[loc.Component]
+	for j, g := range loc.Gradient {
+		s.x[j] -= s.StepSize * (g - old[j] + s.mean[j])
+		s.mean[j] += (g - old[j]) / n
+	}
+	copy(old, loc.Gradient)
+	s.k++
+	if s.k < s.m {
+		return s.component(loc)
+	}
+	copy(loc.X, s.x)
+	s.stage = sagaFull
+	return FuncEvaluation | GradEvaluation, nil
+}
+
+// component requests the gradient of a random component at x.
+func (s *SAGA) component(loc *Location) (Operation, error) {
+	loc.Component = s.src.Intn(s.n)
+	copy(loc.X, s.x)
+	s.stage = sagaComponent
+	return ComponentGradEvaluation, nil
+}
+
+func (*SAGA) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
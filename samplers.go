@@ -0,0 +1,314 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// The samplers in this file generate space-filling sequences of locations in
+// a box. They satisfy the distmv.Rander interface, so they can be used by
+// GuessAndCheck and to initialize GeneticAlgorithm and BayesianOptimization.
+// The samplers are safe for concurrent use, and the sequence of locations is
+// reproducible: it does not depend on how the calls to Rand are interleaved,
+// only on their order.
+
+// LatinHypercube generates locations in Bounds by Latin hypercube sampling,
+// see
+//
+//  McKay, M.D., Beckman, R.J., Conover, W.J.: A comparison of three methods
+//  for selecting values of input variables in the analysis of output from a
+//  computer code. Technometrics 21 (1979), 239-245.
+//
+// The locations are generated in designs of Samples locations. In a design,
+// every dimension of the box is divided into Samples intervals of equal width,
+// and each interval contains exactly one location. A new design is started
+// when all the locations of the previous one have been returned by Rand.
+type LatinHypercube struct {
+	// Bounds is the box in which the locations are generated. LatinHypercube
+	// will panic if Min > Max for any Bound.
+	Bounds []Bound
+	// Samples is the number of locations in a design. It must be positive.
+	Samples int
+	// Src is the source of random numbers. If Src is nil, a source seeded
+	// from the global source of the math/rand package is used.
+	Src *rand.Rand
+
+	mux    sync.Mutex
+	src    *rand.Rand
+	design [][]float64 // Locations of the current design scaled to the unit hypercube.
+	next   int         // Index of the next location in design.
+}
+
+// Rand stores the next location in x and returns it. If x is nil, new memory
+// is allocated, otherwise x must have the same length as Bounds.
+func (l *LatinHypercube) Rand(x []float64) []float64 {
+	x = samplerDst(x, l.Bounds)
+	if l.Samples <= 0 {
+		panic("optimize: LatinHypercube Samples not positive")
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.src == nil {
+		l.src = l.Src
+		if l.src == nil {
+			l.src = rand.New(rand.NewSource(rand.Int63()))
+		}
+	}
+	if l.next == len(l.design) {
+		l.newDesign()
+	}
+	scaleToBounds(x, l.design[l.next], l.Bounds)
+	l.next++
+	return x
+}
+
+// newDesign generates a new design with Samples locations.
+func (l *LatinHypercube) newDesign() {
+	n := l.Samples
+	if cap(l.design) < n {
+		l.design = make([][]float64, n)
+	}
+	l.design = l.design[:n]
+	for i := range l.design {
+		l.design[i] = resize(l.design[i], len(l.Bounds))
+	}
+	for j := range l.Bounds {
+		for i, p := range l.src.Perm(n) {
+			l.design[i][j] = (float64(p) + l.src.Float64()) / float64(n)
+		}
+	}
+	l.next = 0
+}
+
+// Halton generates the locations of the Halton sequence in Bounds, see
+//
+//  Halton, J.H.: On the efficiency of certain quasi-random sequences of
+//  points in evaluating multi-dimensional integrals. Numerische Mathematik 2
+//  (1960), 84-90.
+//
+// The coordinate of the i-th location in the j-th dimension is the radical
+// inverse of i in the base of the j-th prime number. The sequence starts at
+// i = 0, the corner of the box at the minimum of every Bound. The projections
+// of the sequence on pairs of dimensions become increasingly correlated in
+// high dimensions, so Halton is best suited for up to about ten dimensions.
+type Halton struct {
+	// Bounds is the box in which the locations are generated. Halton will
+	// panic if Min > Max for any Bound.
+	Bounds []Bound
+
+	mux    sync.Mutex
+	primes []int
+	index  int
+	u      []float64
+}
+
+// Rand stores the next location in x and returns it. If x is nil, new memory
+// is allocated, otherwise x must have the same length as Bounds.
+func (h *Halton) Rand(x []float64) []float64 {
+	x = samplerDst(x, h.Bounds)
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if len(h.primes) != len(h.Bounds) {
+		h.primes = primes(len(h.Bounds))
+		h.u = make([]float64, len(h.Bounds))
+	}
+	for j, b := range h.primes {
+		h.u[j] = radicalInverse(h.index, b)
+	}
+	h.index++
+	scaleToBounds(x, h.u, h.Bounds)
+	return x
+}
+
+// radicalInverse returns the number in [0, 1) whose digits in base b are the
+// digits of i mirrored at the radix point.
+func radicalInverse(i, b int) float64 {
+	var r float64
+	f := 1 / float64(b)
+	for scale := f; i > 0; i /= b {
+		r += float64(i%b) * scale
+		scale *= f
+	}
+	return r
+}
+
+// primes returns the first n prime numbers.
+func primes(n int) []int {
+	p := make([]int, 0, n)
+	for c := 2; len(p) < n; c++ {
+		prime := true
+		for _, q := range p {
+			if q*q > c {
+				break
+			}
+			if c%q == 0 {
+				prime = false
+				break
+			}
+		}
+		if prime {
+			p = append(p, c)
+		}
+	}
+	return p
+}
+
+// sobolMaxDim is the largest dimension supported by Sobol, the first
+// dimension and one for every entry of sobolDirections.
+const sobolMaxDim = 21
+
+// sobolDirections holds the degree s, the coefficients a and the initial
+// direction numbers m of the primitive polynomials of the dimensions after
+// the first one, from
+//
+//  Joe, S., Kuo, F.Y.: Constructing Sobol sequences with better
+//  two-dimensional projections. SIAM J. Sci. Comput. 30 (2008), 2635-2654.
+var sobolDirections = []struct {
+	s, a int
+	m    []uint32
+}{
+	{1, 0, []uint32{1}},
+	{2, 1, []uint32{1, 3}},
+	{3, 1, []uint32{1, 3, 1}},
+	{3, 2, []uint32{1, 1, 1}},
+	{4, 1, []uint32{1, 1, 3, 3}},
+	{4, 4, []uint32{1, 3, 5, 13}},
+	{5, 2, []uint32{1, 1, 5, 5, 17}},
+	{5, 4, []uint32{1, 1, 5, 5, 5}},
+	{5, 7, []uint32{1, 1, 7, 11, 19}},
+	{5, 11, []uint32{1, 1, 5, 1, 1}},
+	{5, 13, []uint32{1, 1, 1, 3, 11}},
+	{5, 14, []uint32{1, 3, 5, 5, 31}},
+	{6, 1, []uint32{1, 3, 3, 9, 7, 49}},
+	{6, 13, []uint32{1, 1, 1, 15, 21, 21}},
+	{6, 16, []uint32{1, 3, 1, 13, 27, 49}},
+	{6, 19, []uint32{1, 1, 1, 15, 7, 5}},
+	{6, 22, []uint32{1, 3, 1, 15, 13, 25}},
+	{6, 25, []uint32{1, 1, 5, 5, 19, 61}},
+	{7, 1, []uint32{1, 3, 7, 11, 23, 15, 103}},
+	{7, 4, []uint32{1, 3, 7, 13, 13, 15, 69}},
+}
+
+// sobolBits is the number of bits of the coordinates generated by Sobol.
+const sobolBits = 32
+
+// Sobol generates the locations of the Sobol sequence in Bounds, see
+//
+//  Sobol, I.M.: On the distribution of points in a cube and the approximate
+//  evaluation of integrals. USSR Comput. Math. Math. Phys. 7 (1967), 86-112.
+//
+// The locations are generated in Gray code order with the direction numbers
+// of Joe and Kuo. For every k, the first 2^k locations contain exactly one
+// location in every interval [i/2^k, (i+1)/2^k) of each dimension scaled to
+// Bounds. The sequence starts at the corner of the box at the minimum of
+// every Bound. Sobol supports at most 21 dimensions and 2^32 - 1 locations,
+// and it will panic if Bounds is larger or if more locations are requested.
+type Sobol struct {
+	// Bounds is the box in which the locations are generated. Sobol will
+	// panic if Min > Max for any Bound.
+	Bounds []Bound
+
+	mux   sync.Mutex
+	v     [][sobolBits]uint32 // Direction numbers of every dimension.
+	x     []uint32            // Current location.
+	index uint32
+	u     []float64
+}
+
+// Rand stores the next location in x and returns it. If x is nil, new memory
+// is allocated, otherwise x must have the same length as Bounds.
+func (s *Sobol) Rand(x []float64) []float64 {
+	x = samplerDst(x, s.Bounds)
+	if len(s.Bounds) > sobolMaxDim {
+		panic("optimize: too many dimensions for Sobol")
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if len(s.v) != len(s.Bounds) {
+		s.init()
+	}
+	if s.index > 0 {
+		if s.index == math.MaxUint32 {
+			panic("optimize: Sobol sequence exhausted")
+		}
+		// Flip the direction number given by the lowest zero bit of the
+		// index of the previous location.
+		c := 0
+		for i := s.index - 1; i&1 == 1; i >>= 1 {
+			c++
+		}
+		for j := range s.x {
+			s.x[j] ^= s.v[j][c]
+		}
+	}
+	s.index++
+	for j, v := range s.x {
+		s.u[j] = float64(v) / (1 << sobolBits)
+	}
+	scaleToBounds(x, s.u, s.Bounds)
+	return x
+}
+
+// init computes the direction numbers and resets the sequence.
+func (s *Sobol) init() {
+	dim := len(s.Bounds)
+	s.v = make([][sobolBits]uint32, dim)
+	s.x = make([]uint32, dim)
+	s.u = make([]float64, dim)
+	s.index = 0
+	if dim == 0 {
+		return
+	}
+	// The first dimension is the van der Corput sequence in base 2.
+	for k := range s.v[0] {
+		s.v[0][k] = 1 << uint(sobolBits-1-k)
+	}
+	for j := 1; j < dim; j++ {
+		d := sobolDirections[j-1]
+		v := &s.v[j]
+		for k := 0; k < d.s; k++ {
+			v[k] = d.m[k] << uint(sobolBits-1-k)
+		}
+		for k := d.s; k < sobolBits; k++ {
+			v[k] = v[k-d.s] ^ (v[k-d.s] >> uint(d.s))
+			for i := 1; i < d.s; i++ {
+				if (d.a>>uint(d.s-1-i))&1 == 1 {
+					v[k] ^= v[k-i]
+				}
+			}
+		}
+	}
+}
+
+// samplerDst returns the destination of a sampled location, allocating it if
+// x is nil, and checks the bounds.
+func samplerDst(x []float64, bounds []Bound) []float64 {
+	if x == nil {
+		x = make([]float64, len(bounds))
+	}
+	if len(x) != len(bounds) {
+		panic("optimize: sampler dimension mismatch")
+	}
+	for _, b := range bounds {
+		if b.Min > b.Max {
+			panic("optimize: invalid sampler bound")
+		}
+	}
+	return x
+}
+
+// scaleToBounds stores in x the location u in the unit hypercube scaled to
+// bounds.
+func scaleToBounds(x, u []float64, bounds []Bound) {
+	for i, b := range bounds {
+		x[i] = b.Min + u[i]*(b.Max-b.Min)
+	}
+}
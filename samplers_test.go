@@ -0,0 +1,141 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/stat/distmv"
+)
+
+// stratified reports whether every interval [i/n, (i+1)/n) of every dimension
+// of bounds contains exactly one of the locations in x.
+func stratified(x [][]float64, bounds []Bound) bool {
+	n := len(x)
+	for j, b := range bounds {
+		count := make([]int, n)
+		for _, v := range x {
+			i := int(float64(n) * (v[j] - b.Min) / (b.Max - b.Min))
+			if i < 0 || i >= n {
+				return false
+			}
+			count[i]++
+		}
+		for _, c := range count {
+			if c != 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestLatinHypercube(t *testing.T) {
+	bounds := []Bound{{-1, 1}, {0, 10}, {5, 6}}
+	const n = 16
+	l := &LatinHypercube{Bounds: bounds, Samples: n, Src: rand.New(rand.NewSource(1))}
+	for design := 0; design < 3; design++ {
+		x := make([][]float64, n)
+		for i := range x {
+			x[i] = l.Rand(nil)
+		}
+		if !stratified(x, bounds) {
+			t.Errorf("design %v is not a Latin hypercube", design)
+		}
+	}
+
+	l1 := &LatinHypercube{Bounds: bounds, Samples: n, Src: rand.New(rand.NewSource(2))}
+	l2 := &LatinHypercube{Bounds: bounds, Samples: n, Src: rand.New(rand.NewSource(2))}
+	for i := 0; i < 2*n; i++ {
+		if !floats.Equal(l1.Rand(nil), l2.Rand(nil)) {
+			t.Errorf("sequence not reproducible")
+			break
+		}
+	}
+}
+
+func TestHalton(t *testing.T) {
+	h := &Halton{Bounds: []Bound{{0, 1}, {0, 1}, {-1, 1}}}
+	want := [][]float64{
+		{0, 0, -1},
+		{1.0 / 2, 1.0 / 3, -1 + 2.0/5},
+		{1.0 / 4, 2.0 / 3, -1 + 4.0/5},
+		{3.0 / 4, 1.0 / 9, -1 + 6.0/5},
+		{1.0 / 8, 4.0 / 9, -1 + 8.0/5},
+		{5.0 / 8, 7.0 / 9, -1 + 2.0/25},
+	}
+	x := make([]float64, 3)
+	for i, w := range want {
+		h.Rand(x)
+		if !floats.EqualApprox(x, w, 1e-14) {
+			t.Errorf("location %v: want %v, got %v", i, w, x)
+		}
+	}
+}
+
+func TestSobol(t *testing.T) {
+	bounds := make([]Bound, sobolMaxDim)
+	for i := range bounds {
+		bounds[i] = Bound{-float64(i), float64(i + 1)}
+	}
+	s := &Sobol{Bounds: bounds}
+	x := make([][]float64, 1024)
+	for i := range x {
+		x[i] = s.Rand(nil)
+	}
+	for k := uint(0); k <= 10; k++ {
+		if !stratified(x[:1<<k], bounds) {
+			t.Errorf("first 2^%v locations not stratified", k)
+		}
+	}
+
+	// The first locations in the unit square.
+	s = &Sobol{Bounds: []Bound{{0, 1}, {0, 1}}}
+	want := [][]float64{
+		{0, 0},
+		{0.5, 0.5},
+		{0.75, 0.25},
+		{0.25, 0.75},
+		{0.375, 0.375},
+		{0.875, 0.875},
+		{0.625, 0.125},
+		{0.125, 0.625},
+	}
+	for i, w := range want {
+		if got := s.Rand(nil); !floats.Equal(got, w) {
+			t.Errorf("location %v: want %v, got %v", i, w, got)
+		}
+	}
+}
+
+func TestGuessAndCheckSamplers(t *testing.T) {
+	bounds := []Bound{{-3, 3}, {-2, 2}}
+	for _, test := range []struct {
+		name    string
+		sampler func() distmv.Rander
+	}{
+		{"LatinHypercube", func() distmv.Rander {
+			return &LatinHypercube{Bounds: bounds, Samples: 100, Src: rand.New(rand.NewSource(1))}
+		}},
+		{"Halton", func() distmv.Rander { return &Halton{Bounds: bounds} }},
+		{"Sobol", func() distmv.Rander { return &Sobol{Bounds: bounds} }},
+	} {
+		settings := DefaultSettingsGlobal()
+		settings.FunctionConverge = nil
+		settings.FuncEvaluations = 500
+		settings.Concurrent = 2
+		result, err := Global(Problem{Func: sixHumpCamel}, len(bounds), settings, &GuessAndCheck{Rander: test.sampler()})
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.name, err)
+			continue
+		}
+		if math.Abs(result.F+1.0316284534898774) > 0.05 {
+			t.Errorf("%v: minimum not found, got %v", test.name, result.F)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+const defaultScalarTolerance = 1.4901161193847656e-08 // Square root of the machine epsilon.
+
+// ScalarBracket is a bracketing triple of a minimum of a function of one
+// variable. B lies strictly between A and C, and the function value at B is
+// not larger than the function values at A and C, so a continuous function has
+// a minimum between A and C.
+type ScalarBracket struct {
+	A, B, C    float64
+	FA, FB, FC float64 // Function values at A, B and C.
+
+	// FuncEvaluations is the number of function evaluations used to find
+	// the bracket.
+	FuncEvaluations int
+}
+
+// ScalarSettings represents settings of the one-dimensional minimizations and
+// root finding. They terminate with StepConvergence when the minimizer or the
+// root is located within Tolerance*|x| + AbsTolerance.
+type ScalarSettings struct {
+	// Tolerance is the relative tolerance of the location of the minimum
+	// or the root. If Tolerance is 0, it is defaulted to the square root of
+	// the machine epsilon.
+	Tolerance float64
+	// AbsTolerance is the absolute tolerance of the location of the minimum
+	// or the root. If AbsTolerance is 0, it is defaulted to Tolerance times
+	// the width of the bracket.
+	AbsTolerance float64
+	// FuncEvaluations is the maximum number of function evaluations. If it
+	// is reached, the minimization terminates with FunctionEvaluationLimit.
+	// If FuncEvaluations is 0, the number of evaluations is not limited.
+	FuncEvaluations int
+}
+
+// ScalarResult represents the result of a one-dimensional minimization or root
+// finding.
+type ScalarResult struct {
+	X      float64 // Minimizer or root.
+	F      float64 // Function value at X.
+	Status Status
+
+	// FuncEvaluations and DerivEvaluations are the number of evaluations of
+	// the function and its derivative. The evaluations of a bracket of a
+	// minimum are not included.
+	FuncEvaluations  int
+	DerivEvaluations int
+}
+
+// Bracket finds a bracketing triple of a minimum of f starting from the
+// points a and b. It searches downhill from the one with the larger function
+// value by steps that grow by the golden ratio until the function value
+// increases. If the function keeps decreasing, Bracket returns ErrNoBracket
+// together with the last three evaluated points. Bracket panics if a == b.
+func Bracket(f func(float64) float64, a, b float64) (ScalarBracket, error) {
+	if a == b {
+		panic("optimize: bracket from equal points")
+	}
+	br := ScalarBracket{A: a, B: b, FA: f(a), FB: f(b)}
+	if br.FB > br.FA {
+		br.A, br.B = br.B, br.A
+		br.FA, br.FB = br.FB, br.FA
+	}
+	br.C = br.B + goldenRatio*(br.B-br.A)
+	br.FC = f(br.C)
+	br.FuncEvaluations = 3
+	for expanded := 1; br.FC < br.FB; expanded++ {
+		if expanded == maxBracketExpansions {
+			return br, ErrNoBracket
+		}
+		br.A, br.B = br.B, br.C
+		br.FA, br.FB = br.FB, br.FC
+		br.C = br.B + goldenRatio*(br.B-br.A)
+		br.FC = f(br.C)
+		br.FuncEvaluations++
+	}
+	return br, nil
+}
+
+// GoldenSection minimizes f within the bracket br by golden section search.
+// Every iteration evaluates f at a point that divides the larger part of the
+// interval around the best point in the golden ratio, so the interval shrinks
+// linearly by a factor of at most 0.618 per evaluation regardless of the
+// smoothness of f. If settings is nil, the default settings are used.
+func GoldenSection(f func(float64) float64, br ScalarBracket, settings *ScalarSettings) *ScalarResult {
+	checkScalarBracket(br)
+	tol, abs, maxEvals := scalarSettings(settings, math.Abs(br.C-br.A))
+
+	lo, hi := math.Min(br.A, br.C), math.Max(br.A, br.C)
+	result := &ScalarResult{X: br.B, F: br.FB}
+	for {
+		m := 0.5 * (lo + hi)
+		tol1 := tol*math.Abs(result.X) + abs
+		if math.Abs(result.X-m) <= 2*tol1-0.5*(hi-lo) {
+			result.Status = StepConvergence
+			return result
+		}
+		if maxEvals > 0 && result.FuncEvaluations == maxEvals {
+			result.Status = FunctionEvaluationLimit
+			return result
+		}
+
+		x := result.X
+		var u float64
+		if x >= m {
+			u = x + goldenSection*(lo-x)
+		} else {
+			u = x + goldenSection*(hi-x)
+		}
+		fu := f(u)
+		result.FuncEvaluations++
+		if fu <= result.F {
+			if u < x {
+				hi = x
+			} else {
+				lo = x
+			}
+			result.X, result.F = u, fu
+		} else {
+			if u < x {
+				lo = u
+			} else {
+				hi = u
+			}
+		}
+	}
+}
+
+// Brent minimizes f within the bracket br by Brent's method, which combines
+// parabolic interpolation through the three best points with golden section
+// steps when the interpolation does not make enough progress. It converges
+// superlinearly on smooth functions and never much slower than GoldenSection.
+// See
+//
+//  Brent, R.P.: Algorithms for Minimization without Derivatives.
+//  Prentice-Hall (1973), Chapter 5.
+//
+// If settings is nil, the default settings are used.
+func Brent(f func(float64) float64, br ScalarBracket, settings *ScalarSettings) *ScalarResult {
+	checkScalarBracket(br)
+	tol, abs, maxEvals := scalarSettings(settings, math.Abs(br.C-br.A))
+
+	result := &ScalarResult{}
+	bl := brentLine{tol: tol, abs: abs}
+	t, done := bl.minimize(br.A, br.B, br.C, br.FB)
+	for !done {
+		if maxEvals > 0 && result.FuncEvaluations == maxEvals {
+			result.Status = FunctionEvaluationLimit
+			break
+		}
+		ft := f(t)
+		result.FuncEvaluations++
+		t, done = bl.iterate(ft)
+	}
+	if done {
+		result.Status = StepConvergence
+	}
+	result.X, result.F = bl.result()
+	return result
+}
+
+// BrentDerivative minimizes f within the bracket br by the variant of Brent's
+// method that uses the derivative df of f. The sign of the derivative at the
+// best point decides which part of the interval contains the minimum, and
+// secant steps on the derivative through the best point and the two previous
+// best points replace the parabolic interpolation. See
+//
+//  Press, W.H. et al.: Numerical Recipes (3rd ed). Cambridge University Press
+//  (2007), Section 10.4.
+//
+// Every evaluation of f is followed by an evaluation of df at the same point,
+// unless the evaluation of f shows that the minimum has been located.
+// If settings is nil, the default settings are used.
+func BrentDerivative(f, df func(float64) float64, br ScalarBracket, settings *ScalarSettings) *ScalarResult {
+	checkScalarBracket(br)
+	tol, abs, maxEvals := scalarSettings(settings, math.Abs(br.C-br.A))
+
+	a, b := math.Min(br.A, br.C), math.Max(br.A, br.C)
+	x, w, v := br.B, br.B, br.B
+	fx, fw, fv := br.FB, br.FB, br.FB
+	dx := df(x)
+	dw, dv := dx, dx
+	result := &ScalarResult{DerivEvaluations: 1}
+	var d, e float64 // Last step and the step before.
+	for {
+		m := 0.5 * (a + b)
+		tol1 := tol*math.Abs(x) + abs
+		tol2 := 2 * tol1
+		if math.Abs(x-m) <= tol2-0.5*(b-a) {
+			result.Status = StepConvergence
+			break
+		}
+		if maxEvals > 0 && result.FuncEvaluations == maxEvals {
+			result.Status = FunctionEvaluationLimit
+			break
+		}
+
+		bisect := true
+		if math.Abs(e) > tol1 {
+			// Secant steps to the zero of the derivative from w and v.
+			d1, d2 := 2*(b-a), 2*(b-a)
+			if dw != dx {
+				d1 = (w - x) * dx / (dx - dw)
+			}
+			if dv != dx {
+				d2 = (v - x) * dx / (dx - dv)
+			}
+			// A step is acceptable if it stays within the interval and goes
+			// downhill.
+			u1, u2 := x+d1, x+d2
+			ok1 := (a-u1)*(u1-b) > 0 && dx*d1 <= 0
+			ok2 := (a-u2)*(u2-b) > 0 && dx*d2 <= 0
+			olde := e
+			e = d
+			if ok1 || ok2 {
+				switch {
+				case ok1 && ok2:
+					if math.Abs(d1) < math.Abs(d2) {
+						d = d1
+					} else {
+						d = d2
+					}
+				case ok1:
+					d = d1
+				default:
+					d = d2
+				}
+				// Accept the step if it is smaller than half of the
+				// step before the last.
+				if math.Abs(d) <= math.Abs(0.5*olde) {
+					bisect = false
+					u := x + d
+					if u-a < tol2 || b-u < tol2 {
+						d = math.Copysign(tol1, m-x)
+					}
+				}
+			}
+		}
+		if bisect {
+			// Bisect the part of the interval in which the derivative
+			// indicates the minimum.
+			if dx >= 0 {
+				e = a - x
+			} else {
+				e = b - x
+			}
+			d = 0.5 * e
+		}
+
+		var u, fu float64
+		if math.Abs(d) >= tol1 {
+			u = x + d
+			fu = f(u)
+			result.FuncEvaluations++
+		} else {
+			u = x + math.Copysign(tol1, d)
+			fu = f(u)
+			result.FuncEvaluations++
+			if fu > fx {
+				// The minimum step goes uphill, so x is the minimizer
+				// within the tolerance.
+				result.Status = StepConvergence
+				break
+			}
+		}
+		du := df(u)
+		result.DerivEvaluations++
+		if fu <= fx {
+			if u >= x {
+				a = x
+			} else {
+				b = x
+			}
+			v, fv, dv = w, fw, dw
+			w, fw, dw = x, fx, dx
+			x, fx, dx = u, fu, du
+		} else {
+			if u < x {
+				a = u
+			} else {
+				b = u
+			}
+			if fu <= fw || w == x {
+				v, fv, dv = w, fw, dw
+				w, fw, dw = u, fu, du
+			} else if fu < fv || v == x || v == w {
+				v, fv, dv = u, fu, du
+			}
+		}
+	}
+	result.X, result.F = x, fx
+	return result
+}
+
+// checkScalarBracket panics if br is not a bracketing triple.
+func checkScalarBracket(br ScalarBracket) {
+	if (br.B-br.A)*(br.C-br.B) <= 0 || br.FB > br.FA || br.FB > br.FC {
+		panic("optimize: invalid bracket")
+	}
+}
+
+// scalarSettings returns the relative and absolute tolerance and the maximum
+// number of function evaluations of settings with the defaults applied for an
+// initial interval of the given width.
+func scalarSettings(settings *ScalarSettings, width float64) (tol, abs float64, maxEvals int) {
+	if settings != nil {
+		tol = settings.Tolerance
+		abs = settings.AbsTolerance
+		maxEvals = settings.FuncEvaluations
+	}
+	if tol == 0 {
+		tol = defaultScalarTolerance
+	}
+	if tol < 0 {
+		panic("optimize: negative Tolerance")
+	}
+	if abs == 0 {
+		abs = tol * width
+	}
+	if abs < 0 {
+		panic("optimize: negative AbsTolerance")
+	}
+	if maxEvals < 0 {
+		panic("optimize: negative FuncEvaluations")
+	}
+	return tol, abs, maxEvals
+}
@@ -0,0 +1,155 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+)
+
+var scalarTests = []struct {
+	name   string
+	f, df  func(float64) float64
+	a, b   float64
+	xOpt   float64
+	smooth bool
+}{
+	{
+		name:   "Quadratic",
+		f:      func(x float64) float64 { return (x - 2) * (x - 2) },
+		df:     func(x float64) float64 { return 2 * (x - 2) },
+		a:      0,
+		b:      1,
+		xOpt:   2,
+		smooth: true,
+	},
+	{
+		name:   "Cosine",
+		f:      math.Cos,
+		df:     func(x float64) float64 { return -math.Sin(x) },
+		a:      2,
+		b:      2.5,
+		xOpt:   math.Pi,
+		smooth: true,
+	},
+	{
+		name:   "Quartic",
+		f:      func(x float64) float64 { return x * x * x * x },
+		df:     func(x float64) float64 { return 4 * x * x * x },
+		a:      -3,
+		b:      -2,
+		xOpt:   0,
+		smooth: true,
+	},
+	{
+		name:   "Exponential",
+		f:      func(x float64) float64 { return math.Exp(x) - 5*x },
+		df:     func(x float64) float64 { return math.Exp(x) - 5 },
+		a:      10,
+		b:      9,
+		xOpt:   math.Log(5),
+		smooth: true,
+	},
+	{
+		name: "AbsoluteValue",
+		f:    func(x float64) float64 { return math.Abs(x - 0.3) },
+		df: func(x float64) float64 {
+			if x < 0.3 {
+				return -1
+			}
+			return 1
+		},
+		a:    -1,
+		b:    1,
+		xOpt: 0.3,
+	},
+}
+
+func TestScalarMinimization(t *testing.T) {
+	for _, test := range scalarTests {
+		br, err := Bracket(test.f, test.a, test.b)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.name, err)
+			continue
+		}
+		if (br.B-br.A)*(br.C-br.B) <= 0 || br.FB > br.FA || br.FB > br.FC {
+			t.Errorf("%v: invalid bracket %+v", test.name, br)
+			continue
+		}
+		if br.FA != test.f(br.A) || br.FB != test.f(br.B) || br.FC != test.f(br.C) {
+			t.Errorf("%v: bracket function values not equal to the function", test.name)
+		}
+		if math.Min(br.A, br.C) > test.xOpt || math.Max(br.A, br.C) < test.xOpt {
+			t.Errorf("%v: bracket %+v does not contain %v", test.name, br, test.xOpt)
+		}
+
+		golden := GoldenSection(test.f, br, nil)
+		brent := Brent(test.f, br, nil)
+		deriv := BrentDerivative(test.f, test.df, br, nil)
+		for _, r := range []struct {
+			name   string
+			result *ScalarResult
+		}{
+			{"GoldenSection", golden},
+			{"Brent", brent},
+			{"BrentDerivative", deriv},
+		} {
+			if r.result.Status != StepConvergence {
+				t.Errorf("%v, %v: unexpected status %v", test.name, r.name, r.result.Status)
+			}
+			if math.Abs(r.result.X-test.xOpt) > 1e-6 {
+				t.Errorf("%v, %v: minimum not found, want %v, got %v", test.name, r.name, test.xOpt, r.result.X)
+			}
+			if r.result.F != test.f(r.result.X) {
+				t.Errorf("%v, %v: function value at X not equal to F", test.name, r.name)
+			}
+		}
+		if golden.DerivEvaluations != 0 || brent.DerivEvaluations != 0 {
+			t.Errorf("%v: derivative evaluations without derivative", test.name)
+		}
+		if deriv.DerivEvaluations < deriv.FuncEvaluations || deriv.DerivEvaluations > deriv.FuncEvaluations+1 {
+			t.Errorf("%v: unexpected number of derivative evaluations %v for %v function evaluations",
+				test.name, deriv.DerivEvaluations, deriv.FuncEvaluations)
+		}
+		if test.smooth && brent.FuncEvaluations >= golden.FuncEvaluations {
+			t.Errorf("%v: Brent not faster than GoldenSection: %v and %v evaluations",
+				test.name, brent.FuncEvaluations, golden.FuncEvaluations)
+		}
+	}
+}
+
+func TestScalarFuncEvaluations(t *testing.T) {
+	test := scalarTests[1]
+	br, err := Bracket(test.f, test.a, test.b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	settings := &ScalarSettings{FuncEvaluations: 3}
+	for _, r := range []struct {
+		name   string
+		result *ScalarResult
+	}{
+		{"GoldenSection", GoldenSection(test.f, br, settings)},
+		{"Brent", Brent(test.f, br, settings)},
+		{"BrentDerivative", BrentDerivative(test.f, test.df, br, settings)},
+	} {
+		if r.result.Status != FunctionEvaluationLimit {
+			t.Errorf("%v: unexpected status %v", r.name, r.result.Status)
+		}
+		if r.result.FuncEvaluations != settings.FuncEvaluations {
+			t.Errorf("%v: unexpected number of evaluations %v", r.name, r.result.FuncEvaluations)
+		}
+		if r.result.F > br.FB || r.result.F != test.f(r.result.X) {
+			t.Errorf("%v: best location not returned", r.name)
+		}
+	}
+}
+
+func TestBracketUnbounded(t *testing.T) {
+	_, err := Bracket(func(x float64) float64 { return -x }, 0, 1)
+	if err != ErrNoBracket {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	defaultEpsilonPenalty = 1e4
+	defaultAugmentation   = 1e-6
+)
+
+// Scalarization combines the objectives of a MultiObjectiveProblem into a
+// single objective, so that the problem can be solved by the methods for
+// scalar problems. The minima of a scalarization are Pareto optimal, and
+// different parameters of the scalarization lead to different locations of
+// the Pareto front.
+type Scalarization interface {
+	// Scalarize returns the scalar objective for the objectives f. If df is
+	// not nil, it stores the derivatives of the scalar objective with
+	// respect to each objective in df. Scalarize must not modify f.
+	Scalarize(df, f []float64) float64
+}
+
+// WeightedSum is the sum of the objectives multiplied by Weights. Only the
+// convex parts of the Pareto front can be found with a weighted sum.
+type WeightedSum struct {
+	// Weights holds the non-negative weight of every objective.
+	Weights []float64
+}
+
+func (w WeightedSum) Scalarize(df, f []float64) float64 {
+	checkObjectives(w.Weights, f)
+	var s float64
+	for i, v := range f {
+		s += w.Weights[i] * v
+	}
+	if df != nil {
+		copy(df, w.Weights)
+	}
+	return s
+}
+
+// Chebyshev is the largest weighted distance of the objectives from the
+// reference point,
+//  max_i Weights[i] * (f[i] - Reference[i]).
+// Chebyshev finds the whole Pareto front including its non-convex parts, but
+// it is not differentiable where several objectives attain the maximum.
+type Chebyshev struct {
+	// Weights holds the positive weight of every objective.
+	Weights []float64
+	// Reference is a point not larger than the objectives at any location,
+	// typically the ideal point of the individual minima of the objectives.
+	// If Reference is nil, the origin is used.
+	Reference []float64
+}
+
+func (c Chebyshev) Scalarize(df, f []float64) float64 {
+	checkObjectives(c.Weights, f)
+	if c.Reference != nil {
+		checkObjectives(c.Reference, f)
+	}
+	s, _ := maxWeightedDistance(df, f, c.Weights, c.Reference)
+	return s
+}
+
+// AchievementScalarizing is the achievement scalarizing function of
+// Wierzbicki,
+//  max_i w_i * (f[i] - Reference[i]) + Rho * Σ_i w_i * (f[i] - Reference[i]),
+// where w are the Weights. Its minimum is the Pareto optimal location closest
+// to the Reference point in the direction given by the Weights, whether the
+// reference point is achievable or not. The small augmentation term makes the
+// minimum Pareto optimal rather than only weakly so.
+type AchievementScalarizing struct {
+	// Reference is the point of aspiration levels of the objectives.
+	Reference []float64
+	// Weights holds the positive weight of every objective.
+	// If Weights is nil, all weights are 1.
+	Weights []float64
+	// Rho is the augmentation coefficient. It must not be negative.
+	// If Rho is zero, 1e-6 is used.
+	Rho float64
+}
+
+func (a AchievementScalarizing) Scalarize(df, f []float64) float64 {
+	checkObjectives(a.Reference, f)
+	if a.Weights != nil {
+		checkObjectives(a.Weights, f)
+	}
+	rho := a.Rho
+	if rho == 0 {
+		rho = defaultAugmentation
+	}
+	if rho < 0 {
+		panic("optimize: negative augmentation coefficient")
+	}
+	s, _ := maxWeightedDistance(df, f, a.Weights, a.Reference)
+	for i, v := range f {
+		w := 1.0
+		if a.Weights != nil {
+			w = a.Weights[i]
+		}
+		s += rho * w * (v - a.Reference[i])
+		if df != nil {
+			df[i] += rho * w
+		}
+	}
+	return s
+}
+
+// EpsilonConstraint minimizes the objective with index Objective while the
+// other objectives are constrained by the upper bounds in Epsilon. The
+// constraints are enforced by the quadratic penalty
+//  Penalty * Σ_{j != Objective} max(0, f[j] - Epsilon[j])^2,
+// so they may be slightly violated at the minimum.
+type EpsilonConstraint struct {
+	// Objective is the index of the minimized objective.
+	Objective int
+	// Epsilon holds the upper bound of every objective. The bound of the
+	// minimized objective is ignored.
+	Epsilon []float64
+	// Penalty is the positive coefficient of the penalty.
+	// If Penalty is zero, 1e4 is used.
+	Penalty float64
+}
+
+func (e EpsilonConstraint) Scalarize(df, f []float64) float64 {
+	checkObjectives(e.Epsilon, f)
+	if e.Objective < 0 || e.Objective >= len(f) {
+		panic("optimize: objective index out of range")
+	}
+	penalty := e.Penalty
+	if penalty == 0 {
+		penalty = defaultEpsilonPenalty
+	}
+	if penalty < 0 {
+		panic("optimize: negative penalty")
+	}
+	s := f[e.Objective]
+	for j, v := range f {
+		var d float64
+		if j != e.Objective {
+			d = math.Max(0, v-e.Epsilon[j])
+			s += penalty * d * d
+		}
+		if df != nil {
+			df[j] = 2 * penalty * d
+		}
+	}
+	if df != nil {
+		df[e.Objective] = 1
+	}
+	return s
+}
+
+// maxWeightedDistance returns the largest weighted distance of the objectives
+// f from the reference point and the index of the objective attaining it. A
+// nil weights or reference stands for weights of 1 or the origin. If df is
+// not nil, it stores the derivatives of the distance in df.
+func maxWeightedDistance(df, f, weights, reference []float64) (float64, int) {
+	max := math.Inf(-1)
+	arg := 0
+	for i, v := range f {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		if reference != nil {
+			v -= reference[i]
+		}
+		if w*v > max {
+			max = w * v
+			arg = i
+		}
+	}
+	if df != nil {
+		for i := range df {
+			df[i] = 0
+		}
+		df[arg] = 1
+		if weights != nil {
+			df[arg] = weights[arg]
+		}
+	}
+	return max, arg
+}
+
+func checkObjectives(param, f []float64) {
+	if len(param) != len(f) {
+		panic("optimize: scalarization parameter length mismatch")
+	}
+}
+
+// Scalarize returns the scalar Problem whose objective function is the
+// scalarization s of the objectives of p. The gradient of the Problem is
+// defined if p.Jacobian is not nil, and evaluates the objectives as well as
+// their Jacobian. The functions of the Problem may be called concurrently if
+// the functions of p may.
+func Scalarize(p MultiObjectiveProblem, s Scalarization) Problem {
+	if p.Func == nil {
+		panic("optimize: objective function is undefined")
+	}
+	if p.Objectives < 1 {
+		panic("optimize: number of objectives not positive")
+	}
+	sp := Problem{
+		Func: func(x []float64) float64 {
+			f := make([]float64, p.Objectives)
+			p.Func(f, x)
+			return s.Scalarize(nil, f)
+		},
+		Status: p.Status,
+	}
+	if p.Jacobian != nil {
+		sp.Grad = func(grad, x []float64) {
+			f := make([]float64, p.Objectives)
+			df := make([]float64, p.Objectives)
+			jac := mat64.NewDense(p.Objectives, len(x), nil)
+			p.Func(f, x)
+			s.Scalarize(df, f)
+			p.Jacobian(jac, x)
+			for j := range grad {
+				grad[j] = 0
+				for i, d := range df {
+					grad[j] += d * jac.At(i, j)
+				}
+			}
+		}
+	}
+	return sp
+}
+
+// SimplexWeights returns the weight vectors with the given number of
+// objectives whose entries are multiples of 1/divisions and sum to one, the
+// simplex-lattice design of Das and Dennis. The weights are spread evenly
+// between the objectives and include the weight vectors of the individual
+// objectives.
+func SimplexWeights(objectives, divisions int) [][]float64 {
+	if objectives < 1 {
+		panic("optimize: number of objectives not positive")
+	}
+	if divisions < 1 {
+		panic("optimize: number of divisions not positive")
+	}
+	var weights [][]float64
+	counts := make([]int, objectives)
+	var fill func(i, left int)
+	fill = func(i, left int) {
+		if i == objectives-1 {
+			counts[i] = left
+			w := make([]float64, objectives)
+			for k, c := range counts {
+				w[k] = float64(c) / float64(divisions)
+			}
+			weights = append(weights, w)
+			return
+		}
+		for c := left; c >= 0; c-- {
+			counts[i] = c
+			fill(i+1, left-c)
+		}
+	}
+	fill(0, divisions)
+	return weights
+}
+
+// ParetoSweep approximates the Pareto front of p by minimizing the
+// scalarization of p returned by scalarization for each of the weights with
+// Local, see Scalarize. The first minimization starts at initX and every
+// following one at the minimum of the previous one, so consecutive weights
+// should be close to each other as those of SimplexWeights. The settings and
+// method are passed to Local for every minimization.
+//
+// The returned Front holds the non-dominated minima, whose objectives are
+// evaluated once more for the result. The Stats are summed over the
+// minimizations, and Status is the status of the last one. ParetoSweep stops
+// at the first minimization that returns an error and returns the error.
+func ParetoSweep(p MultiObjectiveProblem, initX []float64, weights [][]float64, scalarization func(weights []float64) Scalarization, settings *Settings, method Method) (*ParetoResult, error) {
+	startTime := time.Now()
+	if len(weights) == 0 {
+		return nil, errors.New("optimize: no weights")
+	}
+	x := append([]float64(nil), initX...)
+	var xs, fs [][]float64
+	result := &ParetoResult{}
+	for _, w := range weights {
+		r, err := Local(Scalarize(p, scalarization(w)), x, settings, method)
+		if err != nil {
+			return nil, err
+		}
+		result.MajorIterations += r.MajorIterations
+		result.FuncEvaluations += r.FuncEvaluations
+		result.GradEvaluations += r.GradEvaluations
+		result.HessEvaluations += r.HessEvaluations
+		result.Status = r.Status
+		copy(x, r.X)
+
+		f := make([]float64, p.Objectives)
+		p.Func(f, r.X)
+		xs = append(xs, append([]float64(nil), r.X...))
+		fs = append(fs, f)
+	}
+
+	front := nondominatedSort(fs)[0]
+	sort.Stable(objectiveSorter{front, fs, 0})
+	for _, i := range front {
+		if containsX(result.Front, xs[i], fs[i][0]) {
+			continue
+		}
+		result.Front = append(result.Front, Location{
+			X:          xs[i],
+			F:          fs[i][0],
+			Objectives: fs[i],
+		})
+	}
+	result.Runtime = time.Since(startTime)
+	return result, nil
+}
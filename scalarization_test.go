@@ -0,0 +1,142 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestScalarizations(t *testing.T) {
+	f := []float64{3, 1}
+	for _, test := range []struct {
+		name string
+		s    Scalarization
+		want float64
+		df   []float64
+	}{
+		{
+			name: "WeightedSum",
+			s:    WeightedSum{Weights: []float64{0.25, 0.75}},
+			want: 1.5,
+			df:   []float64{0.25, 0.75},
+		},
+		{
+			name: "Chebyshev",
+			s:    Chebyshev{Weights: []float64{0.5, 2}, Reference: []float64{1, 0}},
+			want: 2,
+			df:   []float64{0, 2},
+		},
+		{
+			name: "AchievementScalarizing",
+			s:    AchievementScalarizing{Reference: []float64{2, 2}, Rho: 0.5},
+			want: 1,
+			df:   []float64{1.5, 0.5},
+		},
+		{
+			name: "EpsilonConstraint",
+			s:    EpsilonConstraint{Objective: 1, Epsilon: []float64{2, 0}, Penalty: 10},
+			want: 11,
+			df:   []float64{20, 1},
+		},
+	} {
+		df := make([]float64, len(f))
+		if got := test.s.Scalarize(df, f); got != test.want {
+			t.Errorf("%s: unexpected value: got %v, want %v", test.name, got, test.want)
+		}
+		if !floats.Equal(df, test.df) {
+			t.Errorf("%s: unexpected derivatives: got %v, want %v", test.name, df, test.df)
+		}
+		if got := test.s.Scalarize(nil, f); got != test.want {
+			t.Errorf("%s: unexpected value without derivatives: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSimplexWeights(t *testing.T) {
+	w := SimplexWeights(3, 4)
+	// The number of weights is the binomial coefficient (4+3-1 choose 3-1).
+	if len(w) != 15 {
+		t.Errorf("unexpected number of weights: %d", len(w))
+	}
+	for _, v := range w {
+		if math.Abs(floats.Sum(v)-1) > 1e-14 {
+			t.Errorf("weights %v do not sum to one", v)
+		}
+	}
+	if !floats.Equal(w[0], []float64{1, 0, 0}) || !floats.Equal(w[len(w)-1], []float64{0, 0, 1}) {
+		t.Errorf("unexpected extreme weights %v, %v", w[0], w[len(w)-1])
+	}
+}
+
+func TestParetoSweep(t *testing.T) {
+	// The Pareto set of the distances to a and b is the segment between them.
+	a := []float64{0, 0}
+	b := []float64{2, 1}
+	p := MultiObjectiveProblem{
+		Func: func(f, x []float64) {
+			f[0] = floats.Distance(x, a, 2) * floats.Distance(x, a, 2)
+			f[1] = floats.Distance(x, b, 2) * floats.Distance(x, b, 2)
+		},
+		Jacobian: func(jac *mat64.Dense, x []float64) {
+			for j := range x {
+				jac.Set(0, j, 2*(x[j]-a[j]))
+				jac.Set(1, j, 2*(x[j]-b[j]))
+			}
+		},
+		Objectives: 2,
+	}
+	onSegment := func(x []float64) bool {
+		// The distance of x to the line through a and b, and the position of
+		// x along it.
+		d := math.Abs(x[0]*b[1]-x[1]*b[0]) / floats.Norm(b, 2)
+		s := floats.Dot(x, b) / floats.Dot(b, b)
+		return d < 1e-4 && s > -1e-4 && s < 1+1e-4
+	}
+
+	for _, test := range []struct {
+		name          string
+		scalarization func(w []float64) Scalarization
+		method        Method
+	}{
+		{
+			name: "WeightedSum",
+			scalarization: func(w []float64) Scalarization {
+				return WeightedSum{Weights: w}
+			},
+			method: &BFGS{},
+		},
+		{
+			name: "Chebyshev",
+			scalarization: func(w []float64) Scalarization {
+				return Chebyshev{Weights: []float64{w[0] + 0.01, w[1] + 0.01}}
+			},
+			method: &NelderMead{},
+		},
+	} {
+		result, err := ParetoSweep(p, []float64{5, -3}, SimplexWeights(2, 10), test.scalarization, nil, test.method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if len(result.Front) != 11 {
+			t.Errorf("%s: unexpected number of locations on the front: %d", test.name, len(result.Front))
+		}
+		for i, loc := range result.Front {
+			if !onSegment(loc.X) {
+				t.Errorf("%s: location %v not in the Pareto set", test.name, loc.X)
+			}
+			if i > 0 && loc.F < result.Front[i-1].F {
+				t.Errorf("%s: front not sorted", test.name)
+			}
+		}
+		if result.FuncEvaluations == 0 {
+			t.Errorf("%s: no function evaluations counted", test.name)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math"
+
+// BrentRoot finds a root of f in the interval [a, b] by Brent's method. The
+// function values at a and b must have opposite signs, otherwise BrentRoot
+// returns ErrNoRootBracket. The method keeps a bracket of the root and
+// combines inverse quadratic interpolation and secant steps with bisection
+// whenever the interpolation does not shrink the bracket fast enough, so it
+// converges superlinearly on smooth functions and never much slower than
+// bisection. See
+//
+//  Brent, R.P.: Algorithms for Minimization without Derivatives.
+//  Prentice-Hall (1973), Chapter 4.
+//
+// BrentRoot terminates with StepConvergence when the root is located within
+// the tolerance of settings, and with Success when f is zero at X. The
+// evaluations at a and b are included in FuncEvaluations. If settings is nil,
+// the default settings are used.
+func BrentRoot(f func(float64) float64, a, b float64, settings *ScalarSettings) (*ScalarResult, error) {
+	tol, abs, maxEvals := scalarSettings(settings, math.Abs(b-a))
+	result := &ScalarResult{}
+	fa, fb, err := rootBracket(f, a, b, result)
+	if err != nil || result.Status != NotTerminated {
+		return result, err
+	}
+
+	var br brentRoot
+	br.init(a, b, fa, fb, tol, abs)
+	for {
+		done := br.converged()
+		result.X, result.F = br.b, br.fb
+		switch {
+		case done && br.fb == 0:
+			result.Status = Success
+			return result, nil
+		case done:
+			result.Status = StepConvergence
+			return result, nil
+		case maxEvals > 0 && result.FuncEvaluations >= maxEvals:
+			result.Status = FunctionEvaluationLimit
+			return result, nil
+		}
+		x := br.step()
+		br.fb = f(x)
+		result.FuncEvaluations++
+	}
+}
+
+// brentRoot locates a root of a function of one variable by Brent's method
+// in reverse communication. After init, converged and step are called in turn
+// and the function value at the trial point returned by step is stored in fb.
+type brentRoot struct {
+	tol, abs   float64 // Relative and absolute tolerance of the root.
+	a, b, c    float64 // Previous estimate, best estimate and other end of the bracket.
+	fa, fb, fc float64
+	d, e       float64 // Last step and the step before.
+}
+
+// init starts from the bracket [a, b], where the function values fa and fb
+// have opposite signs.
+func (br *brentRoot) init(a, b, fa, fb, tol, abs float64) {
+	br.tol, br.abs = tol, abs
+	br.a, br.b = a, b
+	br.fa, br.fb = fa, fb
+	br.c, br.fc = a, fa
+	br.d = b - a
+	br.e = br.d
+}
+
+// converged updates the bracket with the function value fb at b and returns
+// whether b is a root or has been located within the tolerance.
+func (br *brentRoot) converged() bool {
+	if (br.fb > 0) == (br.fc > 0) {
+		// Keep the root between b and c.
+		br.c, br.fc = br.a, br.fa
+		br.d = br.b - br.a
+		br.e = br.d
+	}
+	if math.Abs(br.fc) < math.Abs(br.fb) {
+		// Make b the best estimate of the root.
+		br.a, br.b, br.c = br.b, br.c, br.b
+		br.fa, br.fb, br.fc = br.fb, br.fc, br.fb
+	}
+	return br.fb == 0 || math.Abs(0.5*(br.c-br.b)) <= br.tol1()
+}
+
+func (br *brentRoot) tol1() float64 {
+	return 0.5 * (br.tol*math.Abs(br.b) + br.abs)
+}
+
+// step returns the next trial point, which becomes b.
+func (br *brentRoot) step() float64 {
+	tol1 := br.tol1()
+	m := 0.5 * (br.c - br.b)
+	if math.Abs(br.e) >= tol1 && math.Abs(br.fa) > math.Abs(br.fb) {
+		// Interpolate through a, b and c, or take a secant step if
+		// a and c coincide.
+		var p, q float64
+		s := br.fb / br.fa
+		if br.a == br.c {
+			p = 2 * m * s
+			q = 1 - s
+		} else {
+			q = br.fa / br.fc
+			r := br.fb / br.fc
+			p = s * (2*m*q*(q-r) - (br.b-br.a)*(r-1))
+			q = (q - 1) * (r - 1) * (s - 1)
+		}
+		if p > 0 {
+			q = -q
+		} else {
+			p = -p
+		}
+		// Accept the interpolation if it falls within the bracket and
+		// the step is smaller than half of the step before the last.
+		if 2*p < math.Min(3*m*q-math.Abs(tol1*q), math.Abs(br.e*q)) {
+			br.e = br.d
+			br.d = p / q
+		} else {
+			br.d = m
+			br.e = br.d
+		}
+	} else {
+		br.d = m
+		br.e = br.d
+	}
+	br.a, br.fa = br.b, br.fb
+	if math.Abs(br.d) > tol1 {
+		br.b += br.d
+	} else {
+		br.b += math.Copysign(tol1, m)
+	}
+	return br.b
+}
+
+// Illinois finds a root of f in the interval [a, b] by the Illinois variant
+// of the regula falsi method. The function values at a and b must have
+// opposite signs, otherwise Illinois returns ErrNoRootBracket. Every iteration
+// evaluates f at the zero of the secant through the ends of the bracket and
+// replaces the end at which f has the same sign. If the same end is kept twice
+// in a row, its function value is halved for the next secant, which prevents
+// the stagnation of one end of the regula falsi method and makes the
+// convergence superlinear. See
+//
+//  Dowell, M., Jarratt, P.: A modified regula falsi method for computing the
+//  root of an equation. BIT 11 (1971), 168-174.
+//
+// Illinois terminates with StepConvergence when the bracket is narrower than
+// the tolerance of settings, and with Success when f is zero at X. The
+// evaluations at a and b are included in FuncEvaluations. If settings is nil,
+// the default settings are used.
+func Illinois(f func(float64) float64, a, b float64, settings *ScalarSettings) (*ScalarResult, error) {
+	tol, abs, maxEvals := scalarSettings(settings, math.Abs(b-a))
+	result := &ScalarResult{}
+	fa, fb, err := rootBracket(f, a, b, result)
+	if err != nil || result.Status != NotTerminated {
+		return result, err
+	}
+
+	ya := fa // Function value at a used by the secant.
+	for {
+		if math.Abs(fa) < math.Abs(fb) {
+			result.X, result.F = a, fa
+		} else {
+			result.X, result.F = b, fb
+		}
+		if math.Abs(b-a) <= tol*math.Abs(result.X)+abs {
+			result.Status = StepConvergence
+			return result, nil
+		}
+		if maxEvals > 0 && result.FuncEvaluations >= maxEvals {
+			result.Status = FunctionEvaluationLimit
+			return result, nil
+		}
+
+		c := b - fb*(b-a)/(fb-ya)
+		if !(math.Min(a, b) < c && c < math.Max(a, b)) {
+			// The secant step has been lost in the rounding errors.
+			c = 0.5 * (a + b)
+		}
+		fc := f(c)
+		result.FuncEvaluations++
+		if fc == 0 {
+			result.X, result.F = c, fc
+			result.Status = Success
+			return result, nil
+		}
+		if (fc > 0) != (fb > 0) {
+			// The root lies between b and c.
+			a, fa, ya = b, fb, fb
+		} else {
+			// a is kept again.
+			ya /= 2
+		}
+		b, fb = c, fc
+	}
+}
+
+// rootBracket evaluates f at the ends of the interval [a, b], which must have
+// opposite signs. If f is zero at one of them, it is stored in result with
+// Success.
+func rootBracket(f func(float64) float64, a, b float64, result *ScalarResult) (fa, fb float64, err error) {
+	if a == b {
+		panic("optimize: root bracket with equal ends")
+	}
+	fa = f(a)
+	fb = f(b)
+	result.FuncEvaluations = 2
+	switch {
+	case fa == 0:
+		result.X, result.F = a, fa
+		result.Status = Success
+	case fb == 0:
+		result.X, result.F = b, fb
+		result.Status = Success
+	case (fa > 0) == (fb > 0):
+		if math.Abs(fa) < math.Abs(fb) {
+			result.X, result.F = a, fa
+		} else {
+			result.X, result.F = b, fb
+		}
+		result.Status = Failure
+		return fa, fb, ErrNoRootBracket
+	}
+	return fa, fb, nil
+}
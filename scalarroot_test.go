@@ -0,0 +1,117 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+)
+
+var scalarRootTests = []struct {
+	name string
+	f    func(float64) float64
+	a, b float64
+	root float64
+}{
+	{
+		name: "Cubic",
+		f:    func(x float64) float64 { return x*x*x - 2*x - 5 },
+		a:    2,
+		b:    3,
+		root: 2.0945514815423265,
+	},
+	{
+		name: "Cosine",
+		f:    func(x float64) float64 { return math.Cos(x) - x },
+		a:    -2,
+		b:    4,
+		root: 0.7390851332151607,
+	},
+	{
+		name: "Exponential",
+		f:    func(x float64) float64 { return math.Exp(x) - 1e4 },
+		a:    0,
+		b:    20,
+		root: math.Log(1e4),
+	},
+	{
+		name: "Step",
+		f: func(x float64) float64 {
+			if x < 1.0/3 {
+				return -1
+			}
+			return 1
+		},
+		a:    -5,
+		b:    5,
+		root: 1.0 / 3,
+	},
+	{
+		name: "ExactZero",
+		f:    func(x float64) float64 { return x - 0.5 },
+		a:    0,
+		b:    1,
+		root: 0.5,
+	},
+}
+
+func TestScalarRoot(t *testing.T) {
+	for _, test := range scalarRootTests {
+		for _, solver := range []struct {
+			name string
+			f    func(func(float64) float64, float64, float64, *ScalarSettings) (*ScalarResult, error)
+		}{
+			{"BrentRoot", BrentRoot},
+			{"Illinois", Illinois},
+		} {
+			result, err := solver.f(test.f, test.a, test.b, nil)
+			if err != nil {
+				t.Errorf("%v, %v: unexpected error: %v", test.name, solver.name, err)
+				continue
+			}
+			if result.Status != StepConvergence && result.Status != Success {
+				t.Errorf("%v, %v: unexpected status %v", test.name, solver.name, result.Status)
+			}
+			if result.Status == Success && result.F != 0 {
+				t.Errorf("%v, %v: Success with nonzero function value", test.name, solver.name)
+			}
+			if math.Abs(result.X-test.root) > 1e-6*math.Max(math.Abs(test.root), 1) {
+				t.Errorf("%v, %v: root not found, want %v, got %v", test.name, solver.name, test.root, result.X)
+			}
+			if result.F != test.f(result.X) {
+				t.Errorf("%v, %v: function value at X not equal to F", test.name, solver.name)
+			}
+			// Bisection needs about 50 evaluations.
+			if result.FuncEvaluations > 60 {
+				t.Errorf("%v, %v: too many evaluations %v", test.name, solver.name, result.FuncEvaluations)
+			}
+		}
+	}
+}
+
+func TestScalarRootErrors(t *testing.T) {
+	f := func(x float64) float64 { return x*x + 1 }
+	for _, solver := range []func(func(float64) float64, float64, float64, *ScalarSettings) (*ScalarResult, error){
+		BrentRoot,
+		Illinois,
+	} {
+		result, err := solver(f, -1, 2, nil)
+		if err != ErrNoRootBracket {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if result.Status != Failure {
+			t.Errorf("unexpected status %v", result.Status)
+		}
+
+		g := func(x float64) float64 { return math.Atan(x - 1) }
+		result, err = solver(g, -10, 100, &ScalarSettings{FuncEvaluations: 5})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if result.Status != FunctionEvaluationLimit || result.FuncEvaluations != 5 {
+			t.Errorf("unexpected status %v after %v evaluations", result.Status, result.FuncEvaluations)
+		}
+	}
+}
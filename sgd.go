@@ -0,0 +1,77 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+// SGD implements stochastic gradient descent with optional momentum for
+// minimizing a StochasticProblem, see Minibatches. Every iteration steps
+// along the negative minibatch gradient g scaled by the learning rate,
+//  v_{k+1} = Momentum * v_k + g_k,
+//  x_{k+1} = x_k - rate_k * v_{k+1}.
+// With Nesterov momentum, the step is taken along g_k + Momentum * v_{k+1}
+// instead, which anticipates the next velocity as in
+//
+//  Sutskever, I., Martens, J., Dahl, G., Hinton, G.: On the importance of
+//  initialization and momentum in deep learning. ICML (2013), 1139-1147.
+//
+// SGD does not keep the best location found, so the result of Local is the
+// last location. The convergence of the noisy iterations is tested by
+// Converge, see StochasticConverge and DefaultSettingsStochastic.
+type SGD struct {
+	// LearningRate is the schedule of the learning rate.
+	// If LearningRate is nil, a constant rate of 0.01 is used.
+	LearningRate LearningRate
+	// Momentum is the weight of the previous velocity, in [0, 1).
+	Momentum float64
+	// Nesterov selects Nesterov momentum.
+	Nesterov bool
+	// Converge tests the convergence of the iterations. If Converge is nil,
+	// the iterations run until a limit of Settings is reached.
+	Converge *StochasticConverge
+
+	v  []float64
+	sg stochasticGradient
+}
+
+func (s *SGD) Init(loc *Location) (Operation, error) {
+	if s.Momentum < 0 || s.Momentum >= 1 {
+		panic("sgd: Momentum out of range")
+	}
+	return s.sg.init(loc, s, s.Converge)
+}
+
+func (s *SGD) Iterate(loc *Location) (Operation, error) {
+	return s.sg.iterate(loc, s)
+}
+
+// Status returns the status of the convergence test of Converge.
+func (s *SGD) Status() (Status, error) {
+	return s.sg.status, nil
+}
+
+func (s *SGD) initStep(dim int) {
+	s.v = resize(s.v, dim)
+	for i := range s.v {
+		s.v[i] = 0
+	}
+}
+
+func (s *SGD) step(x, grad []float64, k int) {
+	rate := learningRate(s.LearningRate, k, defaultSGDRate)
+	for i, g := range grad {
+		s.v[i] = s.Momentum*s.v[i] + g
+		d := s.v[i]
+		if s.Nesterov {
+			d = g + s.Momentum*s.v[i]
+		}
+		x[i] -= rate * d
+	}
+}
+
+func (*SGD) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return stochasticNeeds()
+}
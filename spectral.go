@@ -0,0 +1,230 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultSpectralMinStep = 1e-30
+	defaultSpectralMaxStep = 1e30
+)
+
+// SpectralStep is the rule by which SpectralGradient computes the spectral
+// step length λ_k from s_k = x_k - x_{k-1} and y_k = ∇f_k - ∇f_{k-1}.
+type SpectralStep int
+
+const (
+	// BB1 is the first rule of Barzilai and Borwein,
+	//  λ_k = s_k·s_k / s_k·y_k.
+	BB1 SpectralStep = iota
+	// BB2 is the second rule of Barzilai and Borwein,
+	//  λ_k = s_k·y_k / y_k·y_k.
+	// Its steps are never longer than those of BB1.
+	BB2
+	// AlternatingBB uses BB1 and BB2 at alternate iterations.
+	AlternatingBB
+)
+
+// SpectralGradient implements the spectral projected gradient method of
+//
+//  Birgin, E.G., Martínez, J.M., Raydan, M.: Nonmonotone spectral projected
+//  gradient methods on convex sets. SIAM J. Optim. 10 (2000), 1196-1211.
+//
+// for the minimization of a function, optionally subject to the box
+// constraints in Bounds. The search direction is
+//  d_k = P(x_k - λ_k * ∇f_k) - x_k,
+// where P is the projection onto the box and λ_k is the step length of
+// Barzilai and Borwein given by Step, and the line search starts from a step
+// of one. Without Bounds, the method is the gradient descent method with the
+// Barzilai–Borwein step length, which approximates the inverse of the Hessian
+// by a scalar and converges much faster than GradientDescent on
+// ill-conditioned problems.
+//
+// The steps of Barzilai and Borwein do not decrease the function value
+// monotonically, so the default line search is the nonmonotone Nonmonotone,
+// which accepts the spectral step in most iterations and still guarantees
+// convergence.
+type SpectralGradient struct {
+	// Linesearcher selects suitable steps along the search direction. With
+	// Bounds, the Linesearcher must not try steps larger than one, otherwise
+	// the locations may leave the box. If Linesearcher is nil, it will be
+	// defaulted to Nonmonotone.
+	Linesearcher Linesearcher
+	// Step is the rule for the spectral step length. The default is BB1.
+	Step SpectralStep
+	// MinStep and MaxStep are the safeguards of the spectral step length.
+	// If MinStep is zero, it will be defaulted to 1e-30, and if MaxStep is
+	// zero, it will be defaulted to 1e30.
+	MinStep float64
+	MaxStep float64
+
+	// Bounds is the box in which the minimum is sought. If Bounds is nil, the
+	// problem is unconstrained. Otherwise it must contain one Bound for every
+	// dimension of the problem with Min <= Max, and the initial location must
+	// lie in the box. SpectralGradient will panic if Bounds has the wrong
+	// size or contains an invalid Bound.
+	Bounds []Bound
+	// GradientThreshold stops the minimization with GradientThreshold status
+	// when the infinity norm of the projected gradient P(x_k - ∇f_k) - x_k is
+	// less than GradientThreshold. Without Bounds, the projected gradient is
+	// the negative gradient, but with Bounds, the gradient does not vanish at
+	// a minimum on the boundary of the box, so Settings.GradientThreshold
+	// cannot be used.
+	GradientThreshold float64
+
+	ls *LinesearchMethod
+
+	status Status
+	k      int       // Number of spectral steps.
+	x      []float64 // Location at the last major iteration.
+	grad   []float64 // Gradient at the last major iteration.
+	s, y   []float64
+}
+
+func (sg *SpectralGradient) Init(loc *Location) (Operation, error) {
+	if sg.Linesearcher == nil {
+		sg.Linesearcher = &Nonmonotone{}
+	}
+	if sg.MinStep == 0 {
+		sg.MinStep = defaultSpectralMinStep
+	}
+	if sg.MaxStep == 0 {
+		sg.MaxStep = defaultSpectralMaxStep
+	}
+	if sg.MinStep < 0 || sg.MaxStep < sg.MinStep {
+		panic("spectral: invalid step safeguards")
+	}
+	if sg.Step < BB1 || sg.Step > AlternatingBB {
+		panic("spectral: unknown step rule")
+	}
+	dim := len(loc.X)
+	if sg.Bounds != nil {
+		if len(sg.Bounds) != dim {
+			panic("spectral: bounds size mismatch")
+		}
+		for i, b := range sg.Bounds {
+			if b.Min > b.Max {
+				panic("spectral: invalid bound")
+			}
+			if loc.X[i] < b.Min || loc.X[i] > b.Max {
+				return NoOperation, errors.New("spectral: initial location outside bounds")
+			}
+		}
+	}
+
+	sg.x = resize(sg.x, dim)
+	sg.grad = resize(sg.grad, dim)
+	sg.s = resize(sg.s, dim)
+	sg.y = resize(sg.y, dim)
+
+	sg.status = NotTerminated
+	if sg.converged(loc) {
+		// The direction would be zero, which is not a descent direction.
+		return NoOperation, nil
+	}
+
+	if sg.ls == nil {
+		sg.ls = &LinesearchMethod{}
+	}
+	sg.ls.Linesearcher = sg.Linesearcher
+	sg.ls.NextDirectioner = sg
+
+	return sg.ls.Init(loc)
+}
+
+func (sg *SpectralGradient) Iterate(loc *Location) (Operation, error) {
+	op, err := sg.ls.Iterate(loc)
+	if op == MajorIteration {
+		sg.converged(loc)
+	}
+	return op, err
+}
+
+func (sg *SpectralGradient) Status() (Status, error) {
+	return sg.status, nil
+}
+
+// converged tests the projected gradient at loc and records the status.
+func (sg *SpectralGradient) converged(loc *Location) bool {
+	sg.project(sg.s, loc.X, loc.Gradient, 1)
+	norm := floats.Norm(sg.s, math.Inf(1))
+	if norm == 0 || norm < sg.GradientThreshold {
+		sg.status = GradientThreshold
+		return true
+	}
+	return false
+}
+
+func (sg *SpectralGradient) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	copy(sg.x, loc.X)
+	copy(sg.grad, loc.Gradient)
+	sg.k = 0
+
+	sg.restart(loc, dir)
+	return 1
+}
+
+// restart stores in dir the direction whose step length scales the projected
+// gradient to unit length. It is used at the first iteration and when the
+// curvature along s_k is not positive.
+func (sg *SpectralGradient) restart(loc *Location, dir []float64) {
+	sg.project(dir, loc.X, loc.Gradient, 1)
+	lambda := sg.safeguard(1 / floats.Norm(dir, math.Inf(1)))
+	sg.project(dir, loc.X, loc.Gradient, lambda)
+}
+
+func (sg *SpectralGradient) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	floats.SubTo(sg.s, loc.X, sg.x)
+	floats.SubTo(sg.y, loc.Gradient, sg.grad)
+	copy(sg.x, loc.X)
+	copy(sg.grad, loc.Gradient)
+	sg.k++
+
+	sy := floats.Dot(sg.s, sg.y)
+	if sy <= 0 {
+		sg.restart(loc, dir)
+		return 1
+	}
+	var lambda float64
+	if sg.Step == BB1 || (sg.Step == AlternatingBB && sg.k%2 == 1) {
+		lambda = floats.Dot(sg.s, sg.s) / sy
+	} else {
+		lambda = sy / floats.Dot(sg.y, sg.y)
+	}
+	sg.project(dir, loc.X, loc.Gradient, sg.safeguard(lambda))
+	return 1
+}
+
+// safeguard returns the step length lambda clamped to [MinStep, MaxStep].
+func (sg *SpectralGradient) safeguard(lambda float64) float64 {
+	return math.Max(sg.MinStep, math.Min(lambda, sg.MaxStep))
+}
+
+// project stores in dir the projected gradient direction
+//  P(x - lambda * grad) - x.
+func (sg *SpectralGradient) project(dir, x, grad []float64, lambda float64) {
+	for i, g := range grad {
+		v := x[i] - lambda*g
+		if sg.Bounds != nil {
+			v = math.Max(sg.Bounds[i].Min, math.Min(v, sg.Bounds[i].Max))
+		}
+		dir[i] = v - x[i]
+	}
+}
+
+func (*SpectralGradient) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
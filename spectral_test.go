@@ -0,0 +1,60 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestSpectralGradientBounds(t *testing.T) {
+	// An ill-conditioned quadratic whose unconstrained minimum lies outside
+	// the box in some dimensions.
+	c := []float64{1, 10, 100, 1000, 1e4}
+	target := []float64{0.5, -1, 2, 0.25, 3}
+	bounds := []Bound{{0, 1}, {0, 1}, {0, 1}, {0, 1}, {-1, 1}}
+	p := Problem{
+		Func: func(x []float64) float64 {
+			var f float64
+			for i, v := range x {
+				f += c[i] * (v - target[i]) * (v - target[i])
+			}
+			return f
+		},
+		Grad: func(grad, x []float64) {
+			for i, v := range x {
+				grad[i] = 2 * c[i] * (v - target[i])
+			}
+		},
+	}
+	want := make([]float64, len(target))
+	for i, v := range target {
+		want[i] = math.Max(bounds[i].Min, math.Min(v, bounds[i].Max))
+	}
+
+	for _, step := range []SpectralStep{BB1, BB2, AlternatingBB} {
+		settings := DefaultSettings()
+		settings.FunctionConverge = nil
+		method := &SpectralGradient{Step: step, Bounds: bounds, GradientThreshold: 1e-10}
+		result, err := Local(p, []float64{0.5, 0.5, 0.5, 0.5, 0}, settings, method)
+		if err != nil {
+			t.Errorf("step %v: unexpected error: %v", step, err)
+			continue
+		}
+		if result.Status != GradientThreshold {
+			t.Errorf("step %v: unexpected status: %v", step, result.Status)
+		}
+		if !floats.EqualApprox(result.X, want, 1e-10) {
+			t.Errorf("step %v: unexpected minimum: got %v, want %v", step, result.X, want)
+		}
+	}
+
+	_, err := Local(p, []float64{0.5, 0.5, 0.5, 2, 0}, nil, &SpectralGradient{Bounds: bounds})
+	if err == nil {
+		t.Errorf("no error for initial location outside bounds")
+	}
+}
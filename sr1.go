@@ -0,0 +1,339 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const defaultSR1SkipTolerance = 1e-8
+
+// SR1 implements the symmetric rank-one quasi-Newton method in a trust region.
+// The approximation B of the Hessian is updated as
+//  B_{k+1} = B_k + (y_k - B_k s_k)(y_k - B_k s_k)^T / (y_k - B_k s_k)^T s_k,
+// where s_k is the trial step and y_k the change of the gradient along it.
+// Unlike BFGS, SR1 does not keep B positive definite, so B can represent
+// indefinite curvature, and the approximations are often closer to the true
+// Hessian. The update is skipped if
+//  |s_k^T (y_k - B_k s_k)| < SkipTolerance * |s_k| * |y_k - B_k s_k|,
+// which avoids the breakdown of the update when the denominator vanishes.
+//
+// Because B may be indefinite, the steps are not computed by a line search
+// but by minimizing the quadratic model of the objective function within
+// a trust region by the CG method of Steihaug, and B is updated with every
+// trial step, accepted or not, as in Algorithm 6.2 of
+//
+//  Nocedal, J., Wright, S.: Numerical Optimization (2nd ed). Springer (2006).
+//
+// SR1 stores B as a dense matrix and has memory cost O(n^2). LSR1 is the
+// limited-memory variant for large problems.
+type SR1 struct {
+	// InitialHessian is the initial approximation of the Hessian. It can be
+	// the approximation returned by Hessian after a previous run on a similar
+	// problem to warm start the method. If InitialHessian is nil, the identity
+	// matrix scaled by y_0^T y_0 / s_0^T y_0 at the first update is used.
+	InitialHessian mat64.Symmetric
+	// SkipTolerance is the constant of the skip rule of the update. It must
+	// be in [0, 1). If SkipTolerance is 0, it is defaulted to 1e-8.
+	SkipTolerance float64
+	// Radius is the initial radius of the trust region. It must not be
+	// negative. If Radius is 0, it is defaulted to 1.
+	Radius float64
+
+	tr trustRegion
+
+	hess  *mat64.SymDense
+	first bool // Indicator of the first update.
+	r     []float64
+}
+
+func (sr *SR1) Init(loc *Location) (Operation, error) {
+	dim := len(loc.X)
+	if sr.SkipTolerance == 0 {
+		sr.SkipTolerance = defaultSR1SkipTolerance
+	}
+	if sr.SkipTolerance < 0 || sr.SkipTolerance >= 1 {
+		panic("sr1: SkipTolerance not in [0, 1)")
+	}
+	if sr.Radius == 0 {
+		sr.Radius = defaultTrustRegionRadius
+	}
+	if sr.Radius < 0 {
+		panic("sr1: negative Radius")
+	}
+
+	sr.hess = resizeSymDense(sr.hess, dim)
+	if sr.InitialHessian != nil {
+		if sr.InitialHessian.Symmetric() != dim {
+			panic("sr1: InitialHessian size mismatch")
+		}
+		sr.hess.CopySym(sr.InitialHessian)
+	} else {
+		for i := 0; i < dim; i++ {
+			for j := i; j < dim; j++ {
+				if i == j {
+					sr.hess.SetSym(i, i, 1)
+				} else {
+					sr.hess.SetSym(i, j, 0)
+				}
+			}
+		}
+	}
+	sr.first = sr.InitialHessian == nil
+	sr.r = resize(sr.r, dim)
+
+	return sr.tr.init(loc, sr.Radius, sr)
+}
+
+func (sr *SR1) Iterate(loc *Location) (Operation, error) {
+	return sr.tr.iterate(loc, sr)
+}
+
+// Hessian returns a copy of the approximation of the Hessian after the last
+// update.
+func (sr *SR1) Hessian() *mat64.SymDense {
+	if sr.hess == nil {
+		return nil
+	}
+	h := mat64.NewSymDense(sr.hess.Symmetric(), nil)
+	h.CopySym(sr.hess)
+	return h
+}
+
+func (sr *SR1) mulVec(dst, v []float64) {
+	dim := len(v)
+	mat64.NewVector(dim, dst).MulVec(sr.hess, mat64.NewVector(dim, v))
+}
+
+func (sr *SR1) update(s, y []float64) {
+	if sr.first {
+		// Rescale the initial Hessian as BFGS does.
+		sr.first = false
+		if sy := floats.Dot(s, y); sy > 0 {
+			sr.hess.ScaleSym(floats.Dot(y, y)/sy, sr.hess)
+		}
+	}
+
+	// r = y - B*s.
+	sr.mulVec(sr.r, s)
+	floats.SubTo(sr.r, y, sr.r)
+	rs := floats.Dot(sr.r, s)
+	if skipSR1(rs, s, sr.r, sr.SkipTolerance) {
+		return
+	}
+	sr.hess.SymRankOne(sr.hess, 1/rs, mat64.NewVector(len(sr.r), sr.r))
+}
+
+func (*SR1) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+// LSR1 implements the limited-memory symmetric rank-one method in a trust
+// region. It stores the SR1 approximation of the Hessian implicitly in the
+// compact representation
+//  B = γ I + Ψ M^{-1} Ψ^T,
+//  Ψ = Y - γ S,
+//  M = D + L + L^T - γ S^T S,
+// where the columns of S and Y are the last Store steps and changes of the
+// gradient whose updates have not been skipped, D is the diagonal and L the
+// strictly lower triangle of S^T Y, and γ is y^T y / s^T y of the newest
+// pair with positive curvature. The cost of a product of B with a vector is O(Store * dim),
+// so LSR1 is appropriate for large problems. See
+//
+//  Byrd, R.H., Nocedal, J., Schnabel, R.B.: Representations of quasi-Newton
+//  matrices and their use in limited memory methods. Math. Program. 63 (1994),
+//  129-156.
+//
+// The skip rule, the trust region and the steps are those of SR1.
+type LSR1 struct {
+	// Store is the size of the limited-memory storage.
+	// If Store is 0, it will be defaulted to 15.
+	Store int
+	// SkipTolerance is the constant of the skip rule of the update. It must
+	// be in [0, 1). If SkipTolerance is 0, it is defaulted to 1e-8.
+	SkipTolerance float64
+	// Radius is the initial radius of the trust region. It must not be
+	// negative. If Radius is 0, it is defaulted to 1.
+	Radius float64
+
+	tr trustRegion
+
+	gamma float64
+	first bool        // Indicator of the first update.
+	s, y  [][]float64 // Stored pairs, oldest first.
+	psi   [][]float64 // Columns of Ψ.
+	minv  []float64   // M^{-1} stored row-wise.
+	w     []float64   // Ψ^T v.
+	r     []float64
+}
+
+func (l *LSR1) Init(loc *Location) (Operation, error) {
+	dim := len(loc.X)
+	if l.Store == 0 {
+		l.Store = 15
+	}
+	if l.Store < 0 {
+		panic("lsr1: negative Store")
+	}
+	if l.SkipTolerance == 0 {
+		l.SkipTolerance = defaultSR1SkipTolerance
+	}
+	if l.SkipTolerance < 0 || l.SkipTolerance >= 1 {
+		panic("lsr1: SkipTolerance not in [0, 1)")
+	}
+	if l.Radius == 0 {
+		l.Radius = defaultTrustRegionRadius
+	}
+	if l.Radius < 0 {
+		panic("lsr1: negative Radius")
+	}
+
+	l.gamma = 1
+	l.first = true
+	l.s = l.s[:0]
+	l.y = l.y[:0]
+	l.psi = l.psi[:0]
+	l.minv = l.minv[:0]
+	l.r = resize(l.r, dim)
+
+	return l.tr.init(loc, l.Radius, l)
+}
+
+func (l *LSR1) Iterate(loc *Location) (Operation, error) {
+	return l.tr.iterate(loc, l)
+}
+
+func (l *LSR1) mulVec(dst, v []float64) {
+	copy(dst, v)
+	floats.Scale(l.gamma, dst)
+	m := len(l.psi)
+	l.w = resize(l.w, m)
+	for i, p := range l.psi {
+		l.w[i] = floats.Dot(p, v)
+	}
+	for i, p := range l.psi {
+		u := floats.Dot(l.minv[i*m:(i+1)*m], l.w)
+		floats.AddScaled(dst, u, p)
+	}
+}
+
+func (l *LSR1) update(s, y []float64) {
+	if l.first {
+		l.first = false
+		if sy := floats.Dot(s, y); sy > 0 {
+			l.gamma = floats.Dot(y, y) / sy
+		}
+	}
+
+	// r = y - B*s.
+	l.mulVec(l.r, s)
+	floats.SubTo(l.r, y, l.r)
+	if skipSR1(floats.Dot(l.r, s), s, l.r, l.SkipTolerance) {
+		return
+	}
+	if sy := floats.Dot(s, y); sy > 0 {
+		l.gamma = floats.Dot(y, y) / sy
+	}
+
+	// More than dim steps are linearly dependent and make M singular.
+	store := l.Store
+	if store > len(s) {
+		store = len(s)
+	}
+	if len(l.s) == store {
+		// Reuse the storage of the oldest pair.
+		sOld, yOld := l.s[0], l.y[0]
+		l.s = append(l.s[:0], l.s[1:]...)
+		l.y = append(l.y[:0], l.y[1:]...)
+		l.s = append(l.s, append(sOld[:0], s...))
+		l.y = append(l.y, append(yOld[:0], y...))
+	} else {
+		l.s = append(l.s, append([]float64(nil), s...))
+		l.y = append(l.y, append([]float64(nil), y...))
+	}
+	if !l.rebuild() {
+		// M is singular, typically because the steps are linearly
+		// dependent. Restart the memory from the newest pair. Its M is
+		// s^T (y - γ s) with the updated γ, which can vanish although the
+		// denominator of the SR1 update with the previous γ did not, so
+		// the memory is cleared if M is singular again.
+		n := len(l.s) - 1
+		l.s[0], l.s[n] = l.s[n], l.s[0]
+		l.y[0], l.y[n] = l.y[n], l.y[0]
+		l.s = l.s[:1]
+		l.y = l.y[:1]
+		if !l.rebuild() {
+			l.s = l.s[:0]
+			l.y = l.y[:0]
+			l.psi = l.psi[:0]
+			l.minv = l.minv[:0]
+		}
+	}
+}
+
+// rebuild computes Ψ and M^{-1} from the stored pairs and returns whether M is
+// invertible.
+func (l *LSR1) rebuild() bool {
+	m := len(l.s)
+	dim := len(l.r)
+	if cap(l.psi) < m {
+		l.psi = append(l.psi[:cap(l.psi)], make([][]float64, m-cap(l.psi))...)
+	}
+	l.psi = l.psi[:m]
+	for i := range l.psi {
+		l.psi[i] = resize(l.psi[i], dim)
+		floats.AddScaledTo(l.psi[i], l.y[i], -l.gamma, l.s[i])
+	}
+
+	mat := mat64.NewDense(m, m, nil)
+	for i := 0; i < m; i++ {
+		for j := 0; j <= i; j++ {
+			v := floats.Dot(l.s[i], l.y[j]) - l.gamma*floats.Dot(l.s[i], l.s[j])
+			mat.Set(i, j, v)
+			mat.Set(j, i, v)
+		}
+	}
+	l.minv = resize(l.minv, m*m)
+	e := mat64.NewVector(m, nil)
+	var u mat64.Vector
+	for j := 0; j < m; j++ {
+		for i := 0; i < m; i++ {
+			e.SetVec(i, 0)
+		}
+		e.SetVec(j, 1)
+		if err := u.SolveVec(mat, e); err != nil {
+			return false
+		}
+		for i := 0; i < m; i++ {
+			l.minv[i*m+j] = u.At(i, 0)
+		}
+	}
+	return true
+}
+
+func (*LSR1) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
+
+// skipSR1 returns whether the SR1 update with the denominator rs = r^T s must
+// be skipped.
+func skipSR1(rs float64, s, r []float64, tol float64) bool {
+	return math.Abs(rs) < tol*floats.Norm(s, 2)*floats.Norm(r, 2) || rs == 0
+}
@@ -0,0 +1,356 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultBatchSize     = 32
+	defaultAverageDecay  = 0.99
+	defaultSGDRate       = 0.01
+	defaultAdaptiveRate  = 0.001
+	defaultAdaptiveDecay = 0.9
+	defaultAdaptiveEps   = 1e-8
+)
+
+// StochasticProblem describes a problem whose objective function is the mean
+// of a loss over Samples data samples, for example the training loss of
+// a model. The loss and its gradient are evaluated on minibatches of samples,
+// and the gradient of a minibatch is a noisy estimate of the full gradient.
+// A StochasticProblem is minimized by the stochastic gradient methods SGD,
+// AdaGrad, RMSProp and Adam through the Problem returned by Minibatches.
+type StochasticProblem struct {
+	// Func evaluates the mean loss at x over the samples with the indices
+	// in batch. Func must not modify x or batch.
+	Func func(x []float64, batch []int) float64
+
+	// Grad evaluates the gradient of the mean loss at x over the samples
+	// with the indices in batch and stores it in grad. Grad must not modify
+	// x or batch.
+	Grad func(grad, x []float64, batch []int)
+
+	// Samples is the number of samples. It must be positive.
+	Samples int
+
+	// BatchSize is the number of samples in a minibatch. It must not be
+	// larger than Samples.
+	// If BatchSize is zero, the smaller of 32 and Samples is used.
+	BatchSize int
+
+	// Src is the source of random numbers used to shuffle the samples. If
+	// Src is nil, a source seeded from the global source of the math/rand
+	// package is used.
+	Src *rand.Rand
+
+	// Status reports the status of the objective function being optimized
+	// and any error, as for Problem.
+	Status func() (Status, error)
+}
+
+// Minibatches returns the Problem whose objective function and gradient are
+// those of p on the current minibatch. A new minibatch is drawn whenever Func
+// or Grad is called at a location different from the previous call, so the
+// function value and the gradient at a location are consistent. The samples
+// are shuffled at the start of every epoch and drawn without replacement
+// within an epoch. The functions of the returned Problem must not be called
+// concurrently.
+func Minibatches(p StochasticProblem) Problem {
+	if p.Func == nil {
+		panic("optimize: objective function is undefined")
+	}
+	if p.Samples < 1 {
+		panic("optimize: number of samples not positive")
+	}
+	size := p.BatchSize
+	if size == 0 {
+		size = defaultBatchSize
+		if size > p.Samples {
+			size = p.Samples
+		}
+	}
+	if size < 1 || size > p.Samples {
+		panic("optimize: invalid batch size")
+	}
+	src := p.Src
+	if src == nil {
+		src = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	var (
+		perm  []int // Order of the samples in the current epoch.
+		next  int   // Index in perm of the next sample.
+		batch = make([]int, size)
+		lastX []float64
+	)
+	minibatch := func(x []float64) []int {
+		if lastX != nil && floats.Equal(x, lastX) {
+			return batch
+		}
+		lastX = append(lastX[:0], x...)
+		for i := range batch {
+			if next == len(perm) {
+				perm = src.Perm(p.Samples)
+				next = 0
+			}
+			batch[i] = perm[next]
+			next++
+		}
+		return batch
+	}
+
+	sp := Problem{
+		Func: func(x []float64) float64 {
+			return p.Func(x, minibatch(x))
+		},
+		Status: p.Status,
+	}
+	if p.Grad != nil {
+		sp.Grad = func(grad, x []float64) {
+			p.Grad(grad, x, minibatch(x))
+		}
+	}
+	return sp
+}
+
+// DefaultSettingsStochastic returns a new Settings struct for the stochastic
+// gradient methods. The convergence tests of Settings on the gradient and the
+// function value at single locations are disabled because the minibatch
+// values are noisy. The convergence is instead tested on moving averages by
+// StochasticConverge.
+func DefaultSettingsStochastic() *Settings {
+	return &Settings{
+		FunctionThreshold: math.Inf(-1),
+	}
+}
+
+// LearningRate is a schedule of the learning rate of the stochastic gradient
+// methods.
+type LearningRate interface {
+	// Rate returns the learning rate at iteration k, starting from zero.
+	Rate(k int) float64
+}
+
+// ConstantRate is a constant learning rate.
+type ConstantRate float64
+
+func (c ConstantRate) Rate(int) float64 {
+	return float64(c)
+}
+
+// StepDecay multiplies the learning rate by Factor every Steps iterations,
+//  rate_k = Initial * Factor^floor(k / Steps).
+type StepDecay struct {
+	Initial float64
+	Factor  float64
+	Steps   int
+}
+
+func (s StepDecay) Rate(k int) float64 {
+	if s.Steps < 1 {
+		panic("optimize: StepDecay Steps not positive")
+	}
+	return s.Initial * math.Pow(s.Factor, float64(k/s.Steps))
+}
+
+// ExponentialDecay decreases the learning rate exponentially,
+//  rate_k = Initial * Decay^k.
+type ExponentialDecay struct {
+	Initial float64
+	Decay   float64
+}
+
+func (e ExponentialDecay) Rate(k int) float64 {
+	return e.Initial * math.Pow(e.Decay, float64(k))
+}
+
+// InverseTimeDecay decreases the learning rate as
+//  rate_k = Initial / (1 + Decay * k).
+// The rates satisfy the conditions of Robbins and Monro for the convergence
+// of SGD.
+type InverseTimeDecay struct {
+	Initial float64
+	Decay   float64
+}
+
+func (i InverseTimeDecay) Rate(k int) float64 {
+	return i.Initial / (1 + i.Decay*float64(k))
+}
+
+// CosineAnnealing decreases the learning rate from Max to Min along a half
+// cosine over Period iterations and then restarts at Max,
+//  rate_k = Min + (Max - Min) * (1 + cos(π * (k mod Period) / Period)) / 2.
+type CosineAnnealing struct {
+	Max    float64
+	Min    float64
+	Period int
+}
+
+func (c CosineAnnealing) Rate(k int) float64 {
+	if c.Period < 1 {
+		panic("optimize: CosineAnnealing Period not positive")
+	}
+	t := float64(k%c.Period) / float64(c.Period)
+	return c.Min + (c.Max-c.Min)*(1+math.Cos(math.Pi*t))/2
+}
+
+// StochasticConverge tests the convergence of the stochastic gradient methods
+// on exponential moving averages of the minibatch gradients and function
+// values, in which the noise of the minibatches averages out.
+//
+// GradientThreshold status is returned if the infinity norm of the averaged
+// gradient is less than GradientThreshold.
+//
+// The averaged function value converges as for FunctionConverge: if it does
+// not decrease by more than Relative * |f_best| + Absolute for Iterations
+// major iterations, FunctionConvergence status is returned. If Iterations is
+// zero, the function value is not tested.
+type StochasticConverge struct {
+	// Decay is the weight of the previous average in the moving averages,
+	// in [0, 1). Larger values average over more iterations.
+	// If Decay is zero, 0.99 is used.
+	Decay float64
+
+	GradientThreshold float64
+
+	Absolute   float64
+	Relative   float64
+	Iterations int
+
+	decay  float64
+	k      int
+	weight float64 // 1 - decay^k, the bias correction of the averages.
+	grad   []float64
+	avgG   []float64
+	f      float64
+	best   float64
+	iter   int
+}
+
+// Init initializes the moving averages for a problem of dimension dim.
+func (sc *StochasticConverge) Init(dim int) {
+	sc.decay = sc.Decay
+	if sc.decay == 0 {
+		sc.decay = defaultAverageDecay
+	}
+	if sc.decay < 0 || sc.decay >= 1 {
+		panic("optimize: StochasticConverge Decay out of range")
+	}
+	sc.k = 0
+	sc.weight = 0
+	sc.grad = resize(sc.grad, dim)
+	sc.avgG = resize(sc.avgG, dim)
+	for i := range sc.grad {
+		sc.grad[i] = 0
+	}
+	sc.f = 0
+	sc.best = math.Inf(1)
+	sc.iter = 0
+}
+
+// Converged updates the moving averages with the minibatch function value and
+// gradient in loc, and returns whether they have converged.
+func (sc *StochasticConverge) Converged(loc *Location) Status {
+	sc.k++
+	sc.weight = 1 - math.Pow(sc.decay, float64(sc.k))
+	sc.f = sc.decay*sc.f + (1-sc.decay)*loc.F
+	f := sc.f / sc.weight
+	for i, g := range loc.Gradient {
+		sc.grad[i] = sc.decay*sc.grad[i] + (1-sc.decay)*g
+		sc.avgG[i] = sc.grad[i] / sc.weight
+	}
+	if loc.Gradient != nil && floats.Norm(sc.avgG, math.Inf(1)) < sc.GradientThreshold {
+		return GradientThreshold
+	}
+
+	if sc.Iterations == 0 {
+		return NotTerminated
+	}
+	if math.IsInf(sc.best, 1) || sc.best-f > sc.Relative*math.Abs(sc.best)+sc.Absolute {
+		sc.best = f
+		sc.iter = 0
+		return NotTerminated
+	}
+	sc.iter++
+	if sc.iter < sc.Iterations {
+		return NotTerminated
+	}
+	return FunctionConvergence
+}
+
+// stepper computes the steps of a stochastic gradient method.
+type stepper interface {
+	// initStep initializes the method for a problem of dimension dim.
+	initStep(dim int)
+	// step moves x by the step at iteration k, starting from zero, for the
+	// minibatch gradient grad.
+	step(x, grad []float64, k int)
+}
+
+// stochasticGradient implements the iterations shared by the stochastic
+// gradient methods. Every iteration takes a step from the current location,
+// evaluates the minibatch function value and gradient at the new location,
+// and announces it as a MajorIteration. The methods do not keep the best
+// location, so the result of Local is the last location.
+type stochasticGradient struct {
+	converge  *StochasticConverge
+	k         int
+	status    Status
+	evaluated bool
+}
+
+func (s *stochasticGradient) init(loc *Location, m stepper, converge *StochasticConverge) (Operation, error) {
+	dim := len(loc.X)
+	m.initStep(dim)
+	s.converge = converge
+	if s.converge != nil {
+		s.converge.Init(dim)
+	}
+	s.k = 0
+	s.status = NotTerminated
+	s.evaluated = false
+	return s.iterate(loc, m)
+}
+
+func (s *stochasticGradient) iterate(loc *Location, m stepper) (Operation, error) {
+	if s.evaluated {
+		s.evaluated = false
+		if s.converge != nil {
+			s.status = s.converge.Converged(loc)
+		}
+		return MajorIteration, nil
+	}
+	m.step(loc.X, loc.Gradient, s.k)
+	s.k++
+	s.evaluated = true
+	return FuncEvaluation | GradEvaluation, nil
+}
+
+// learningRate returns the learning rate at iteration k given by rate, or
+// the constant def if rate is nil.
+func learningRate(rate LearningRate, k int, def float64) float64 {
+	if rate == nil {
+		return def
+	}
+	r := rate.Rate(k)
+	if r <= 0 {
+		panic("optimize: learning rate not positive")
+	}
+	return r
+}
+
+func stochasticNeeds() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
@@ -0,0 +1,167 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+// leastSquares returns the StochasticProblem of fitting a linear model to
+// samples of the model with the coefficients want and a small noise.
+func leastSquares(want []float64, samples int, src *rand.Rand) StochasticProblem {
+	a := make([][]float64, samples)
+	b := make([]float64, samples)
+	for i := range a {
+		a[i] = make([]float64, len(want))
+		for j := range a[i] {
+			a[i][j] = src.NormFloat64()
+		}
+		b[i] = floats.Dot(a[i], want) + 0.01*src.NormFloat64()
+	}
+	return StochasticProblem{
+		Func: func(x []float64, batch []int) float64 {
+			var f float64
+			for _, i := range batch {
+				r := floats.Dot(a[i], x) - b[i]
+				f += r * r
+			}
+			return f / float64(2*len(batch))
+		},
+		Grad: func(grad, x []float64, batch []int) {
+			for j := range grad {
+				grad[j] = 0
+			}
+			for _, i := range batch {
+				r := floats.Dot(a[i], x) - b[i]
+				floats.AddScaled(grad, r/float64(len(batch)), a[i])
+			}
+		},
+		Samples:   samples,
+		BatchSize: 10,
+		Src:       src,
+	}
+}
+
+func TestStochasticMethods(t *testing.T) {
+	want := []float64{1, -2, 0.5}
+	for _, test := range []struct {
+		name   string
+		method Method
+	}{
+		{"SGD", &SGD{LearningRate: ConstantRate(0.05)}},
+		{"SGDMomentum", &SGD{LearningRate: InverseTimeDecay{Initial: 0.02, Decay: 1e-3}, Momentum: 0.9}},
+		{"SGDNesterov", &SGD{LearningRate: ConstantRate(0.01), Momentum: 0.9, Nesterov: true}},
+		{"AdaGrad", &AdaGrad{LearningRate: ConstantRate(0.5)}},
+		{"RMSProp", &RMSProp{LearningRate: StepDecay{Initial: 0.01, Factor: 0.5, Steps: 500}}},
+		{"Adam", &Adam{LearningRate: ConstantRate(0.01)}},
+	} {
+		p := leastSquares(want, 500, rand.New(rand.NewSource(1)))
+		settings := DefaultSettingsStochastic()
+		settings.GradEvaluations = 3000
+		result, err := Local(Minibatches(p), make([]float64, len(want)), settings, test.method)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result.Status != GradientEvaluationLimit {
+			t.Errorf("%s: unexpected status: %v", test.name, result.Status)
+		}
+		if !floats.EqualApprox(result.X, want, 2e-2) {
+			t.Errorf("%s: unexpected minimum: got %v, want %v", test.name, result.X, want)
+		}
+	}
+}
+
+func TestStochasticConverge(t *testing.T) {
+	want := []float64{1, -2, 0.5}
+	p := leastSquares(want, 500, rand.New(rand.NewSource(1)))
+	method := &Adam{
+		LearningRate: ConstantRate(0.01),
+		Converge:     &StochasticConverge{GradientThreshold: 1e-2},
+	}
+	settings := DefaultSettingsStochastic()
+	settings.GradEvaluations = 100000
+	result, err := Local(Minibatches(p), make([]float64, len(want)), settings, method)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != GradientThreshold {
+		t.Errorf("unexpected status: %v", result.Status)
+	}
+	if !floats.EqualApprox(result.X, want, 5e-2) {
+		t.Errorf("unexpected minimum: got %v, want %v", result.X, want)
+	}
+
+	// The averaged function value stops decreasing at the noise level.
+	method.Converge = &StochasticConverge{Relative: 1e-3, Iterations: 50}
+	result, err = Local(Minibatches(p), make([]float64, len(want)), settings, method)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != FunctionConvergence {
+		t.Errorf("unexpected status: %v", result.Status)
+	}
+}
+
+func TestMinibatches(t *testing.T) {
+	const samples = 10
+	var batches [][]int
+	p := Minibatches(StochasticProblem{
+		Func: func(x []float64, batch []int) float64 {
+			batches = append(batches, append([]int(nil), batch...))
+			return float64(len(batch))
+		},
+		Samples:   samples,
+		BatchSize: 5,
+	})
+	count := make([]int, samples)
+	x := []float64{0}
+	for epoch := 1; epoch <= 3; epoch++ {
+		for i := 0; i < 2; i++ {
+			x[0]++
+			batches = batches[:0]
+			p.Func(x)
+			p.Func(x)
+			if len(batches[0]) != 5 {
+				t.Fatal("unexpected batch size")
+			}
+			for k, v := range batches[0] {
+				if batches[1][k] != v {
+					t.Fatal("different minibatches at the same location")
+				}
+				count[v]++
+			}
+		}
+		for i, c := range count {
+			if c != epoch {
+				t.Errorf("sample %d evaluated %d times after %d epochs", i, c, epoch)
+			}
+		}
+	}
+}
+
+func TestLearningRates(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		rate LearningRate
+		k    int
+		want float64
+	}{
+		{"ConstantRate", ConstantRate(0.1), 7, 0.1},
+		{"StepDecay", StepDecay{Initial: 1, Factor: 0.5, Steps: 10}, 25, 0.25},
+		{"ExponentialDecay", ExponentialDecay{Initial: 2, Decay: 0.5}, 3, 0.25},
+		{"InverseTimeDecay", InverseTimeDecay{Initial: 1, Decay: 0.5}, 6, 0.25},
+		{"CosineAnnealing", CosineAnnealing{Max: 1, Min: 0.5, Period: 4}, 2, 0.75},
+		{"CosineAnnealingRestart", CosineAnnealing{Max: 1, Min: 0.5, Period: 4}, 4, 1},
+	} {
+		if got := test.rate.Rate(test.k); math.Abs(got-test.want) > 1e-15 {
+			t.Errorf("%s: unexpected rate: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import "math/rand"
+
+// svrgStage is the stage of an iteration of SVRG.
+type svrgStage int
+
+const (
+	svrgComponent svrgStage = iota
+	svrgSnapshot
+	svrgFull
+	svrgMajor
+)
+
+// SVRG implements the stochastic variance reduced gradient method of
+//
+//  Johnson, R., Zhang, T.: Accelerating stochastic gradient descent using
+//  predictive variance reduction. NIPS 26 (2013), 315-323.
+//
+// for minimizing a finite sum. SVRG works in epochs. At the start of every
+// epoch, the full gradient μ is evaluated at a snapshot location x̃, the
+// current location. The epoch then takes EpochLength steps
+//  x_{k+1} = x_k - StepSize * (∇f_i(x_k) - ∇f_i(x̃) + μ)
+// with components i chosen uniformly at random. The step direction is an
+// unbiased estimate of the gradient whose variance vanishes as x_k and x̃
+// approach the minimum, so SVRG converges linearly on strongly convex problems
+// with a constant step size.
+//
+// SVRG must be used with Local on the Problem returned by FiniteSum. Every
+// step requests two ComponentGradEvaluations, at x_k and at x̃, which are
+// counted as gradient evaluations. At the end of every epoch, the function
+// value and the full gradient are evaluated and announced as a
+// MajorIteration, so the convergence tests of Settings apply to the full
+// gradient.
+type SVRG struct {
+	// StepSize is the constant step size. It must be positive, and it is
+	// typically 1/(10L) for components with L-Lipschitz gradients.
+	StepSize float64
+	// EpochLength is the number of steps in an epoch.
+	// If EpochLength is zero, twice the number of components is used.
+	EpochLength int
+
+	// Src is the source of random numbers. If Src is nil, a source seeded
+	// from the global source of the math/rand package is used.
+	Src *rand.Rand
+
+	stage    svrgStage
+	src      *rand.Rand
+	n        int // Number of components.
+	m        int
+	k        int       // Number of steps in the epoch.
+	x        []float64 // Current location.
+	snapshot []float64
+	mu       []float64 // Full gradient at snapshot.
+	g        []float64 // Component gradient at x.
+}
+
+func (s *SVRG) initProblem(p *Problem, settings *Settings) error {
+	var err error
+	s.n, err = finiteSumComponents(p, settings)
+	return err
+}
+
+func (s *SVRG) Init(loc *Location) (Operation, error) {
+	s.src = checkFiniteSum(s.StepSize, s.Src)
+	s.m = s.EpochLength
+	if s.m == 0 {
+		s.m = 2 * s.n
+	}
+	if s.m < 1 {
+		panic("svrg: EpochLength not positive")
+	}
+	dim := len(loc.X)
+	s.x = resize(s.x, dim)
+	s.snapshot = resize(s.snapshot, dim)
+	s.mu = resize(s.mu, dim)
+	s.g = resize(s.g, dim)
+	return s.startEpoch(loc)
+}
+
+func (s *SVRG) Iterate(loc *Location) (Operation, error) {
+	switch s.stage {
+	case svrgComponent:
+		// Evaluate the gradient of the same component at the snapshot.
+		copy(s.g, loc.Gradient)
+		copy(loc.X, s.snapshot)
+		s.stage = svrgSnapshot
+		return ComponentGradEvaluation, nil
+	case svrgSnapshot:
+		for j := range s.x {
+			s.x[j] -= s.StepSize * (s.g[j] - loc.Gradient[j] + s.mu[j])
+		}
+		s.k++
+		if s.k < s.m {
+			return s.component(loc)
+		}
+		copy(loc.X, s.x)
+		s.stage = svrgFull
+		return FuncEvaluation | GradEvaluation, nil
+	case svrgFull:
+		s.stage = svrgMajor
+		return MajorIteration, nil
+	}
+	return s.startEpoch(loc)
+}
+
+// startEpoch starts a new epoch at the current location, whose full gradient
+// is known.
+func (s *SVRG) startEpoch(loc *Location) (Operation, error) {
+	copy(s.x, loc.X)
+	copy(s.snapshot, loc.X)
+	copy(s.mu, loc.Gradient)
+	s.k = 0
+	return s.component(loc)
+}
+
+// component requests the gradient of a random component at x.
+func (s *SVRG) component(loc *Location) (Operation, error) {
+	loc.Component = s.src.Intn(s.n)
+	copy(loc.X, s.x)
+	s.stage = svrgComponent
+	return ComponentGradEvaluation, nil
+}
+
+func (*SVRG) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
@@ -24,6 +24,8 @@ const (
 	FunctionEvaluationLimit
 	GradientEvaluationLimit
 	HessianEvaluationLimit
+	MeshConvergence
+	MethodConverge
 )
 
 func (s Status) String() string {
@@ -100,6 +102,12 @@ var statuses = []struct {
 		early: true,
 		err:   errors.New("optimize: maximum number of Hessian evaluations reached"),
 	},
+	{
+		name: "MeshConvergence",
+	},
+	{
+		name: "MethodConverge",
+	},
 }
 
 // NewStatus returns a unique Status variable to represent a custom status.
@@ -0,0 +1,237 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	maxForcing = 0.5
+	// finiteDifferenceStep is the relative step of the finite-difference
+	// Hessian-vector products, the square root of the machine epsilon.
+	finiteDifferenceStep = 1.4901161193847656e-08
+)
+
+// TruncatedNewton implements the truncated Newton (Newton-CG) method for
+// gradient-based unconstrained minimization of large problems. Like Newton, it
+// computes the search direction d_k from the Newton system
+//  H_k d_k = -∇f_k,
+// but it solves the system only approximately by the conjugate gradient
+// method, which needs just products of the Hessian H_k with vectors and never
+// forms or factorizes H_k. The cost and storage of every inner CG iteration
+// are O(dim).
+//
+// The CG iterations stop when the residual satisfies
+//  |H_k d_k + ∇f_k| <= η_k |∇f_k|,
+// with the forcing sequence η_k = min(0.5, sqrt(|∇f_k|)), which gives
+// superlinear convergence near a minimum, or when a direction of non-positive
+// curvature of H_k is detected. In the latter case the last CG iterate is used,
+// or the steepest descent direction if it is detected at the first CG
+// iteration, so d_k is always a descent direction. The method is Algorithm 7.1
+// of
+//
+//  Nocedal, J., Wright, S.: Numerical Optimization (2nd ed). Springer (2006).
+//
+// If Problem.HessVec is not nil, or if Settings.Evaluator is not nil, every
+// Hessian-vector product is requested by a HessVecEvaluation and counted as a
+// Hessian evaluation. Otherwise the
+// products are approximated by forward differences of the gradient,
+//  H_k v ≈ (∇f(x_k + ε v) - ∇f_k) / ε,
+// and each product costs one evaluation of the gradient, which is requested
+// by a GradEvaluation at x_k + ε v.
+type TruncatedNewton struct {
+	// Linesearcher is used for selecting suitable steps along the descent
+	// direction d. Accepted steps should satisfy at least one of the Wolfe,
+	// Goldstein or Armijo conditions.
+	// If Linesearcher == nil, an appropriate default is chosen.
+	Linesearcher Linesearcher
+	// MaxIterations is the maximum number of inner CG iterations for every
+	// search direction. If MaxIterations is 0, it is defaulted to the
+	// dimension of the problem.
+	MaxIterations int
+
+	ls *LinesearchMethod
+
+	hessVec bool // The products are requested by HessVecEvaluations.
+
+	first   bool // ls has not been initialized.
+	major   bool // ls announced MajorIteration, so a new direction is needed.
+	solving bool // The Newton system is being solved.
+
+	x    []float64 // Location at the last major iteration.
+	f    float64   // Function value at x.
+	grad []float64 // Gradient at x.
+
+	// The inner CG iterations.
+	z   []float64 // Approximate solution of the Newton system.
+	r   []float64 // Residual H z + ∇f.
+	d   []float64 // CG search direction.
+	hd  []float64 // Product of the Hessian with d.
+	rr  float64   // Squared norm of r.
+	tol float64   // Tolerance of the norm of r.
+	eps float64   // Finite-difference step along d.
+	max int
+	k   int
+}
+
+func (tn *TruncatedNewton) initProblem(p *Problem, settings *Settings) error {
+	tn.hessVec = p.HessVec != nil || settings.Evaluator != nil
+	return nil
+}
+
+func (tn *TruncatedNewton) Init(loc *Location) (Operation, error) {
+	if tn.Linesearcher == nil {
+		tn.Linesearcher = &Bisection{}
+	}
+	if tn.MaxIterations < 0 {
+		panic("optimize: TruncatedNewton.MaxIterations is negative")
+	}
+	if tn.ls == nil {
+		tn.ls = &LinesearchMethod{}
+	}
+	tn.ls.Linesearcher = tn.Linesearcher
+	tn.ls.NextDirectioner = tn
+
+	dim := len(loc.X)
+	tn.max = tn.MaxIterations
+	if tn.max == 0 {
+		tn.max = dim
+	}
+	tn.x = resize(tn.x, dim)
+	tn.grad = resize(tn.grad, dim)
+	tn.z = resize(tn.z, dim)
+	tn.r = resize(tn.r, dim)
+	tn.d = resize(tn.d, dim)
+	tn.hd = resize(tn.hd, dim)
+
+	tn.first = true
+	tn.major = false
+	return tn.startSolve(loc)
+}
+
+func (tn *TruncatedNewton) Iterate(loc *Location) (Operation, error) {
+	if tn.solving {
+		if tn.hessVec {
+			copy(tn.hd, loc.HessVec)
+		} else {
+			// loc holds the gradient at x + eps*d.
+			floats.SubTo(tn.hd, loc.Gradient, tn.grad)
+			floats.Scale(1/tn.eps, tn.hd)
+		}
+		if tn.cgStep() {
+			return tn.finishSolve(loc)
+		}
+		return tn.solve(loc)
+	}
+	if tn.major {
+		tn.major = false
+		return tn.startSolve(loc)
+	}
+	op, err := tn.ls.Iterate(loc)
+	if op == MajorIteration {
+		tn.major = true
+	}
+	return op, err
+}
+
+// startSolve starts the inner CG iterations for the Newton system at loc.
+func (tn *TruncatedNewton) startSolve(loc *Location) (Operation, error) {
+	copy(tn.x, loc.X)
+	tn.f = loc.F
+	copy(tn.grad, loc.Gradient)
+
+	for i := range tn.z {
+		tn.z[i] = 0
+	}
+	copy(tn.r, loc.Gradient)
+	copy(tn.d, loc.Gradient)
+	floats.Scale(-1, tn.d)
+	tn.rr = floats.Dot(tn.r, tn.r)
+	norm := math.Sqrt(tn.rr)
+	tn.tol = math.Min(maxForcing, math.Sqrt(norm)) * norm
+	tn.k = 0
+	if tn.rr == 0 {
+		// loc is a stationary point and the Newton step is zero. The CG
+		// iterations would divide by the zero norm of d.
+		return tn.finishSolve(loc)
+	}
+	tn.solving = true
+	return tn.solve(loc)
+}
+
+// solve requests the product of the Hessian at x with the CG direction d.
+func (tn *TruncatedNewton) solve(loc *Location) (Operation, error) {
+	if tn.hessVec {
+		copy(loc.X, tn.x)
+		loc.Direction = resize(loc.Direction, len(tn.d))
+		copy(loc.Direction, tn.d)
+		return HessVecEvaluation, nil
+	}
+	tn.eps = finiteDifferenceStep * (1 + floats.Norm(tn.x, 2)) / floats.Norm(tn.d, 2)
+	floats.AddScaledTo(loc.X, tn.x, tn.eps, tn.d)
+	return GradEvaluation, nil
+}
+
+// cgStep carries out an inner CG iteration with the product hd of the Hessian
+// with the CG direction d and returns whether the iterations have finished.
+func (tn *TruncatedNewton) cgStep() bool {
+	dhd := floats.Dot(tn.d, tn.hd)
+	if dhd <= 0 {
+		// Non-positive curvature along d.
+		if tn.k == 0 {
+			copy(tn.z, tn.d)
+		}
+		return true
+	}
+	alpha := tn.rr / dhd
+	floats.AddScaled(tn.z, alpha, tn.d)
+	floats.AddScaled(tn.r, alpha, tn.hd)
+	tn.k++
+	rr := floats.Dot(tn.r, tn.r)
+	if math.Sqrt(rr) <= tn.tol || tn.k == tn.max {
+		return true
+	}
+	beta := rr / tn.rr
+	tn.rr = rr
+	floats.Scale(beta, tn.d)
+	floats.AddScaled(tn.d, -1, tn.r)
+	return false
+}
+
+// finishSolve restores loc to the location of the last major iteration and
+// starts the line search along the computed direction.
+func (tn *TruncatedNewton) finishSolve(loc *Location) (Operation, error) {
+	tn.solving = false
+	copy(loc.X, tn.x)
+	loc.F = tn.f
+	copy(loc.Gradient, tn.grad)
+	if tn.first {
+		tn.first = false
+		return tn.ls.Init(loc)
+	}
+	return tn.ls.Iterate(loc)
+}
+
+func (tn *TruncatedNewton) InitDirection(loc *Location, dir []float64) (stepSize float64) {
+	return tn.NextDirection(loc, dir)
+}
+
+func (tn *TruncatedNewton) NextDirection(loc *Location, dir []float64) (stepSize float64) {
+	copy(dir, tn.z)
+	return 1
+}
+
+func (*TruncatedNewton) Needs() struct {
+	Gradient bool
+	Hessian  bool
+} {
+	return struct {
+		Gradient bool
+		Hessian  bool
+	}{true, false}
+}
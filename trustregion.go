@@ -0,0 +1,191 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package optimize
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+)
+
+const (
+	defaultTrustRegionRadius = 1
+	trustRegionAccept        = 1e-4 // Minimum ratio of actual to predicted reduction of an accepted step.
+	trustRegionShrink        = 0.1  // The radius is halved below this ratio.
+	trustRegionExpand        = 0.75 // The radius is doubled above this ratio for steps near the boundary.
+	trustRegionRounding      = 1e-10
+)
+
+// hessianModel is a quasi-Newton approximation B of the Hessian of the
+// objective function that is used as the quadratic model of a trust-region
+// method.
+type hessianModel interface {
+	// mulVec stores B*v in dst.
+	mulVec(dst, v []float64)
+	// update updates B with the step s and the change y of the gradient
+	// along it.
+	update(s, y []float64)
+}
+
+// trustRegion implements the iterations of a trust-region method with the
+// quadratic model
+//  m(s) = f_k + ∇f_k·s + 1/2 s·B s
+// of a hessianModel. Every iteration minimizes the model approximately within
+// the trust region |s| <= Δ by the CG method of Steihaug, evaluates the
+// function value and the gradient at x_k + s, and updates B with the step
+// whether or not the step is accepted. The step is accepted if the ratio ρ of
+// the actual and the predicted reduction of f is larger than 1e-4. Δ is
+// doubled if ρ > 0.75 and the step reaches the boundary of the trust region,
+// and halved if ρ < 0.1. Near a minimum, where the actual reduction is lost
+// in the rounding errors of f, it is estimated from the gradients instead.
+// The method is Algorithm 6.2 of
+//
+//  Nocedal, J., Wright, S.: Numerical Optimization (2nd ed). Springer (2006).
+type trustRegion struct {
+	radius float64
+	major  bool // MajorIteration has been announced, so a new step is needed.
+
+	x    []float64 // Location at the last major iteration.
+	f    float64   // Function value at x.
+	grad []float64 // Gradient at x.
+	s    []float64 // Trial step.
+	bs   []float64 // B*s.
+	y    []float64 // Change of the gradient along s.
+	pred float64   // Reduction of f predicted by the model.
+
+	// Storage for the CG method of Steihaug.
+	r, d, bd []float64
+}
+
+func (tr *trustRegion) init(loc *Location, radius float64, model hessianModel) (Operation, error) {
+	dim := len(loc.X)
+	tr.x = resize(tr.x, dim)
+	tr.grad = resize(tr.grad, dim)
+	tr.s = resize(tr.s, dim)
+	tr.bs = resize(tr.bs, dim)
+	tr.y = resize(tr.y, dim)
+	tr.r = resize(tr.r, dim)
+	tr.d = resize(tr.d, dim)
+	tr.bd = resize(tr.bd, dim)
+
+	tr.radius = radius
+	tr.major = false
+	tr.accept(loc)
+	return tr.trial(loc, model)
+}
+
+func (tr *trustRegion) iterate(loc *Location, model hessianModel) (Operation, error) {
+	if tr.major {
+		tr.major = false
+		return tr.trial(loc, model)
+	}
+
+	// loc holds the function value and the gradient at x + s.
+	ared := tr.f - loc.F
+	if math.Abs(ared) <= trustRegionRounding*math.Abs(tr.f) {
+		// The difference of the function values is dominated by rounding
+		// errors, so the reduction is estimated from the gradients by the
+		// trapezoidal rule, which is exact for quadratic functions.
+		ared = -0.5 * (floats.Dot(tr.grad, tr.s) + floats.Dot(loc.Gradient, tr.s))
+	}
+	rho := ared / tr.pred
+	floats.SubTo(tr.y, loc.Gradient, tr.grad)
+	model.update(tr.s, tr.y)
+
+	norm := floats.Norm(tr.s, 2)
+	switch {
+	case rho > trustRegionExpand && norm > 0.8*tr.radius:
+		tr.radius *= 2
+	case rho < trustRegionShrink || math.IsNaN(rho):
+		tr.radius /= 2
+	}
+	if rho > trustRegionAccept {
+		tr.accept(loc)
+		tr.major = true
+		return MajorIteration, nil
+	}
+	return tr.trial(loc, model)
+}
+
+// accept makes loc the location of the last major iteration.
+func (tr *trustRegion) accept(loc *Location) {
+	copy(tr.x, loc.X)
+	tr.f = loc.F
+	copy(tr.grad, loc.Gradient)
+}
+
+// trial computes the next trial step from the location of the last major
+// iteration, stores the trial location in loc.X and returns the evaluation
+// at it.
+func (tr *trustRegion) trial(loc *Location, model hessianModel) (Operation, error) {
+	tr.steihaug(model)
+	model.mulVec(tr.bs, tr.s)
+	tr.pred = -floats.Dot(tr.grad, tr.s) - 0.5*floats.Dot(tr.s, tr.bs)
+	floats.AddTo(loc.X, tr.x, tr.s)
+	if floats.Equal(loc.X, tr.x) {
+		return NoOperation, ErrNoTrustRegionProgress
+	}
+	return FuncEvaluation | GradEvaluation, nil
+}
+
+// steihaug approximately minimizes the model within the trust region by the
+// CG method of Steihaug and stores the step in tr.s. The CG iterations stop
+// at the boundary of the trust region, at a direction of non-positive
+// curvature, or when the residual is smaller than
+// min(0.5, sqrt(|∇f_k|)) * |∇f_k|.
+func (tr *trustRegion) steihaug(model hessianModel) {
+	for i := range tr.s {
+		tr.s[i] = 0
+	}
+	copy(tr.r, tr.grad)
+	copy(tr.d, tr.grad)
+	floats.Scale(-1, tr.d)
+	rr := floats.Dot(tr.r, tr.r)
+	norm := math.Sqrt(rr)
+	if norm == 0 {
+		return
+	}
+	tol := math.Min(0.5, math.Sqrt(norm)) * norm
+
+	for k := 0; k < len(tr.s); k++ {
+		model.mulVec(tr.bd, tr.d)
+		dbd := floats.Dot(tr.d, tr.bd)
+		if dbd <= 0 {
+			tr.toBoundary()
+			return
+		}
+		alpha := rr / dbd
+		floats.AddScaled(tr.s, alpha, tr.d)
+		if floats.Norm(tr.s, 2) >= tr.radius {
+			floats.AddScaled(tr.s, -alpha, tr.d)
+			tr.toBoundary()
+			return
+		}
+		floats.AddScaled(tr.r, alpha, tr.bd)
+		rrNew := floats.Dot(tr.r, tr.r)
+		if math.Sqrt(rrNew) < tol {
+			return
+		}
+		beta := rrNew / rr
+		rr = rrNew
+		floats.Scale(beta, tr.d)
+		floats.AddScaled(tr.d, -1, tr.r)
+	}
+}
+
+// toBoundary moves the step s along the CG direction d to the boundary of the
+// trust region.
+func (tr *trustRegion) toBoundary() {
+	floats.AddScaled(tr.s, boundaryStep(tr.s, tr.d, tr.radius), tr.d)
+}
+
+// boundaryStep returns the positive root tau of |s + tau*d| = radius for s
+// within the trust region.
+func boundaryStep(s, d []float64, radius float64) float64 {
+	dd := floats.Dot(d, d)
+	sd := floats.Dot(s, d)
+	ss := floats.Dot(s, s)
+	return (-sd + math.Sqrt(sd*sd+dd*(radius*radius-ss))) / dd
+}
@@ -47,9 +47,23 @@ const (
 	// HessEvaluation specifies that the Hessian
 	// of the objective function should be evaluated.
 	HessEvaluation
+	// ComponentGradEvaluation specifies that the gradient of the component
+	// Location.Component of a finite-sum objective should be evaluated and
+	// stored in Location.Gradient. It must not be combined with
+	// GradEvaluation.
+	ComponentGradEvaluation
+	// HessVecEvaluation specifies that the product of the Hessian of the
+	// objective function with Location.Direction should be evaluated and
+	// stored in Location.HessVec.
+	HessVecEvaluation
+	// PartialGradEvaluation specifies that the partial derivatives of the
+	// objective function with respect to Location.Coordinates should be
+	// evaluated and stored in the corresponding elements of
+	// Location.PartialGradient.
+	PartialGradEvaluation
 
 	// Mask for the evaluating operations.
-	evalMask = FuncEvaluation | GradEvaluation | HessEvaluation
+	evalMask = FuncEvaluation | GradEvaluation | HessEvaluation | ComponentGradEvaluation | HessVecEvaluation | PartialGradEvaluation
 )
 
 func (op Operation) isEvaluation() bool {
@@ -58,10 +72,13 @@ func (op Operation) isEvaluation() bool {
 
 func (op Operation) String() string {
 	if op&evalMask != 0 {
-		return fmt.Sprintf("Evaluation(Func: %t, Grad: %t, Hess: %t, Extra: 0b%b)",
+		return fmt.Sprintf("Evaluation(Func: %t, Grad: %t, Hess: %t, ComponentGrad: %t, HessVec: %t, PartialGrad: %t, Extra: 0b%b)",
 			op&FuncEvaluation != 0,
 			op&GradEvaluation != 0,
 			op&HessEvaluation != 0,
+			op&ComponentGradEvaluation != 0,
+			op&HessVecEvaluation != 0,
+			op&PartialGradEvaluation != 0,
 			op&^(evalMask))
 	}
 	s, ok := operationNames[op]
@@ -84,6 +101,28 @@ type Location struct {
 	F        float64
 	Gradient []float64
 	Hessian  *mat64.SymDense
+
+	// Objectives holds the values of the objective functions of a
+	// MultiObjectiveProblem. F is then the value of the first objective.
+	// For a RootProblem, Objectives holds the residuals F(x) of the
+	// equations, and F is half of their squared norm.
+	Objectives []float64
+	// Jacobian holds the Jacobian of the residuals of a RootProblem.
+	Jacobian *mat64.Dense
+
+	// Component is the index of the component of a finite-sum objective
+	// whose gradient is evaluated by a ComponentGradEvaluation.
+	Component int
+	// Direction is the vector whose product with the Hessian is evaluated by
+	// a HessVecEvaluation and stored in HessVec.
+	Direction []float64
+	HessVec   []float64
+	// Coordinates are the coordinates whose partial derivatives are
+	// evaluated by a PartialGradEvaluation and stored in the corresponding
+	// elements of PartialGradient. The other elements of PartialGradient
+	// are not modified.
+	Coordinates     []int
+	PartialGradient []float64
 }
 
 // Result represents the answer of an optimization run. It contains the optimum
@@ -99,9 +138,16 @@ type Result struct {
 type Stats struct {
 	MajorIterations int           // Total number of major iterations
 	FuncEvaluations int           // Number of evaluations of Func
-	GradEvaluations int           // Number of evaluations of Grad
-	HessEvaluations int           // Number of evaluations of Hess
+	GradEvaluations int           // Number of evaluations of Grad, ComponentGrad and PartialGrad
+	HessEvaluations int           // Number of evaluations of Hess and HessVec
 	Runtime         time.Duration // Total runtime of the optimization
+
+	// RefusedEvaluations is the number of evaluations requested by the
+	// tasks of Global that were refused before they started, because they
+	// would have exceeded an evaluation limit or the optimization had
+	// already terminated. Evaluations that have started are always
+	// completed and counted in the fields above.
+	RefusedEvaluations int
 }
 
 // complementEval returns an evaluating operation that evaluates fields of loc
@@ -133,6 +179,37 @@ type Problem struct {
 	// Hess must not modify x.
 	Hess func(hess mat64.MutableSymmetric, x []float64)
 
+	// ComponentGrad evaluates the gradient of the component function i of
+	// a finite-sum objective with Components components at x and stores
+	// the result in-place in grad. ComponentGrad must not modify x.
+	// ComponentGrad and Components are set by FiniteSum, and they are
+	// needed by SVRG and SAGA, except ComponentGrad if Settings.Evaluator
+	// evaluates the gradients of the components. Every evaluation of ComponentGrad counts as
+	// an evaluation of the gradient in Stats and Settings.GradEvaluations.
+	ComponentGrad func(grad []float64, x []float64, i int)
+	Components    int
+
+	// HessVec evaluates the product of the Hessian at x with v and stores
+	// the result in-place in hv. HessVec must not modify x or v. If HessVec
+	// is not nil, TruncatedNewton uses it instead of finite differences of
+	// the gradient. If Settings.Evaluator is not nil, TruncatedNewton
+	// requests the products from the Evaluator and HessVec may be nil.
+	// Every evaluation of HessVec counts as an evaluation of the Hessian in
+	// Stats and Settings.HessEvaluations.
+	HessVec func(hv, x, v []float64)
+
+	// PartialGrad evaluates the partial derivatives of the objective
+	// function at x with respect to the coordinates in coords and stores the
+	// results in-place in the corresponding elements of grad. PartialGrad
+	// must not modify x, coords or the other elements of grad. If
+	// PartialGrad is not nil, CoordinateDescent uses it for its line
+	// minimizations. If Settings.Evaluator is not nil, CoordinateDescent
+	// requests the partial derivatives from the Evaluator and PartialGrad
+	// may be nil. PartialGrad must be nil if the objective function is not
+	// differentiable, for example with an L1 regularization term. Every evaluation of PartialGrad counts as an
+	// evaluation of the gradient in Stats and Settings.GradEvaluations.
+	PartialGrad func(grad, x []float64, coords []int)
+
 	// Status reports the status of the objective function being optimized and any
 	// error. This can be used to terminate early, for example when the function is
 	// not able to evaluate itself. The user can use one of the pre-provided Status
@@ -140,6 +217,13 @@ type Problem struct {
 	Status func() (Status, error)
 }
 
+// Bound represents the closed interval [Min, Max] of allowed values of one
+// variable. Box-constrained methods take a slice of Bounds, one per dimension.
+type Bound struct {
+	Min float64
+	Max float64
+}
+
 // TODO(btracey): Think about making this an exported function when the
 // constraint interface is designed.
 func (p Problem) satisfies(method Needser) error {
@@ -215,14 +299,14 @@ type Settings struct {
 
 	// GradEvaluations is the maximum allowed number of gradient evaluations.
 	// GradientEvaluationLimit status is returned if the total number of calls
-	// to Grad equals or exceeds this number.
+	// to Grad, ComponentGrad and PartialGrad equals or exceeds this number.
 	// If it equals zero, this setting has no effect.
 	// The default value is 0.
 	GradEvaluations int
 
 	// HessEvaluations is the maximum allowed number of Hessian evaluations.
 	// HessianEvaluationLimit status is returned if the total number of calls
-	// to Hess equals or exceeds this number.
+	// to Hess and HessVec equals or exceeds this number.
 	// If it equals zero, this setting has no effect.
 	// The default value is 0.
 	HessEvaluations int
@@ -231,6 +315,28 @@ type Settings struct {
 
 	// Concurrent represents how many concurrent evaluations are possible.
 	Concurrent int
+
+	// Evaluator carries out the evaluations instead of the routines of the
+	// Problem, for example on remote workers. If Evaluator is not nil, the
+	// routines of Problem, including the optional ones such as ComponentGrad,
+	// are not used and may be nil, and the Evaluator returns an error for
+	// the evaluations it cannot carry out. Components is still used.
+	Evaluator Evaluator
+
+	// Deterministic makes Global reproducible with Concurrent tasks. The
+	// tasks then run in lockstep: they request their next evaluations one
+	// after another in a fixed order, the evaluations are carried out
+	// concurrently, and their results and the major iterations are committed
+	// in the same fixed order. Two runs with the same settings and the same
+	// seeded sources of random numbers in the method give identical results,
+	// apart from the runtime, as long as Runtime is zero. The tasks do not
+	// get separate random streams: a method with one source of random
+	// numbers, such as GuessAndCheck or GeneticAlgorithm, draws from it in
+	// the fixed order of the tasks, so the results are reproducible for the
+	// same Concurrent but may change with the number of tasks. Tasks that
+	// wait for the slowest evaluation of a round are idle, so the throughput
+	// may be lower than with Deterministic false.
+	Deterministic bool
 }
 
 // DefaultSettings returns a new Settings struct containing the default settings.
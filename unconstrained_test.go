@@ -1009,6 +1009,77 @@ func TestNelderMead(t *testing.T) {
 	testLocal(t, tests, &NelderMead{})
 }
 
+func TestPowell(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradFreeTests...)
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, unconstrainedTest{
+		name: "ExtendedRosenbrock",
+		p: Problem{
+			Func: functions.ExtendedRosenbrock{}.Func,
+		},
+		x: []float64{-1.2, 1, -1.2, 1, -1.2, 1, -1.2, 1, -1.2, 1},
+	})
+	testLocal(t, tests, &Powell{})
+}
+
+func TestCoordinateDescent(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradFreeTests...)
+	tests = append(tests, gradientDescentTests...)
+	testLocal(t, tests, &CoordinateDescent{})
+}
+
+func TestNEWUOA(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradFreeTests...)
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, unconstrainedTest{
+		name: "ExtendedRosenbrock",
+		p: Problem{
+			Func: functions.ExtendedRosenbrock{}.Func,
+		},
+		x: []float64{-1.2, 1, -1.2, 1, -1.2, 1, -1.2, 1, -1.2, 1},
+	})
+	testLocalConverged(t, tests, &NEWUOA{}, StepConvergence)
+}
+
+// meshTests returns the tests for the direct search methods on a mesh. The
+// mesh around the starting location [-1, 0, 0] of HelicalValley contains
+// x[0] = 0, where its function is not defined, and the polls of PatternSearch
+// and MADS stall in the flat valley of BiggsEXP6.
+func meshTests() []unconstrainedTest {
+	var tests []unconstrainedTest
+	for _, test := range append(append([]unconstrainedTest{}, gradFreeTests...), gradientDescentTests...) {
+		if test.name != "HelicalValley" && test.name != "BiggsEXP6" {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+func TestHookeJeeves(t *testing.T) {
+	testLocalConverged(t, meshTests(), &HookeJeeves{}, MeshConvergence)
+}
+
+func TestPatternSearch(t *testing.T) {
+	testLocalConverged(t, meshTests(), &PatternSearch{}, MeshConvergence)
+
+	// The minimal basis makes little progress along the curved valleys of
+	// the larger problems.
+	var tests []unconstrainedTest
+	for _, test := range meshTests() {
+		if len(test.x) <= 3 && !test.long {
+			tests = append(tests, test)
+		}
+	}
+	testLocalConverged(t, tests, &PatternSearch{Basis: MinimalBasis}, MeshConvergence)
+}
+
+func TestMADS(t *testing.T) {
+	testLocalConverged(t, meshTests(), &MADS{}, MeshConvergence)
+}
+
 func TestGradientDescent(t *testing.T) {
 	testLocal(t, gradientDescentTests, &GradientDescent{})
 }
@@ -1027,6 +1098,21 @@ func TestGradientDescentBisection(t *testing.T) {
 	})
 }
 
+func TestGradientDescentNonmonotone(t *testing.T) {
+	testLocal(t, gradientDescentTests, &GradientDescent{
+		Linesearcher: &Nonmonotone{},
+	})
+}
+
+func TestSpectralGradient(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, cgTests...)
+	for _, step := range []SpectralStep{BB1, BB2, AlternatingBB} {
+		testLocal(t, tests, &SpectralGradient{Step: step})
+	}
+}
+
 func TestCG(t *testing.T) {
 	var tests []unconstrainedTest
 	tests = append(tests, gradientDescentTests...)
@@ -1134,6 +1220,165 @@ func TestHagerZhangFirstOrderStep(t *testing.T) {
 	})
 }
 
+func TestCGHagerZhangLinesearch(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, cgTests...)
+	testLocal(t, tests, &CG{
+		Variant:      &HagerZhang{},
+		Linesearcher: &HagerZhangLinesearch{CurvatureFactor: 0.1},
+	})
+}
+
+func TestTruncatedNewton(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, cgTests...)
+	testLocal(t, tests, &TruncatedNewton{})
+
+	// Exact Hessian-vector products from the Hessians of the Newton tests.
+	// The inexact Newton directions do not reach the tight tolerances of the
+	// Newton tests on the badly scaled problems, where the line search fails
+	// to find a decrease of the function value, so the tolerances are
+	// relaxed.
+	for _, test := range newtonTests {
+		if test.gradTol == 0 || test.gradTol < 1e-6 {
+			test.gradTol = 1e-6
+		}
+		dim := len(test.x)
+		hess := mat64.NewSymDense(dim, nil)
+		hessFn := test.p.Hess
+		test.p.HessVec = func(hv, x, v []float64) {
+			hessFn(hess, x)
+			mat64.NewVector(dim, hv).MulVec(hess, mat64.NewVector(dim, v))
+		}
+		testLocal(t, []unconstrainedTest{test}, &TruncatedNewton{})
+	}
+}
+
+func TestTruncatedNewtonHessVec(t *testing.T) {
+	f := functions.Wood{}
+	dim := 4
+	hess := mat64.NewSymDense(dim, nil)
+	var calls int
+	p := Problem{
+		Func: f.Func,
+		Grad: f.Grad,
+		HessVec: func(hv, x, v []float64) {
+			calls++
+			f.Hess(hess, x)
+			mat64.NewVector(dim, hv).MulVec(hess, mat64.NewVector(dim, v))
+		},
+	}
+	x := []float64{-3, -1, -3, -1}
+
+	settings := DefaultSettings()
+	settings.Recorder = nil
+	result, err := Local(p, x, settings, &TruncatedNewton{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.HessEvaluations == 0 || result.HessEvaluations != calls {
+		t.Errorf("%v Hessian evaluations counted for %v products", result.HessEvaluations, calls)
+	}
+
+	calls = 0
+	settings.HessEvaluations = 5
+	result, err = Local(p, x, settings, &TruncatedNewton{})
+	if err != nil {
+		t.Fatalf("unexpected error with a limit: %v", err)
+	}
+	if result.Status != HessianEvaluationLimit {
+		t.Errorf("unexpected status with a limit: %v", result.Status)
+	}
+	if calls != settings.HessEvaluations {
+		t.Errorf("%v products evaluated with a limit of %v", calls, settings.HessEvaluations)
+	}
+}
+
+func TestTruncatedNewtonStationary(t *testing.T) {
+	// The starting point is the minimum of the function, so the gradient is
+	// exactly zero and no products must be requested. As with the other
+	// line search methods, the zero direction then ends the run with an
+	// error.
+	for _, hessVec := range []bool{false, true} {
+		var nan bool
+		p := Problem{
+			Func: func(x []float64) float64 {
+				return floats.Dot(x, x)
+			},
+			Grad: func(grad, x []float64) {
+				for _, v := range x {
+					nan = nan || math.IsNaN(v)
+				}
+				floats.ScaleTo(grad, 2, x)
+			},
+		}
+		if hessVec {
+			p.HessVec = func(hv, x, v []float64) {
+				floats.ScaleTo(hv, 2, v)
+			}
+		}
+		settings := DefaultSettings()
+		settings.Recorder = nil
+		settings.GradientThreshold = 0
+		result, _ := Local(p, []float64{0, 0}, settings, &TruncatedNewton{})
+		if nan {
+			t.Errorf("gradient evaluated at NaN with HessVec %v", hessVec)
+		}
+		if result.HessEvaluations != 0 || result.GradEvaluations != 1 {
+			t.Errorf("derivatives evaluated at a stationary point with HessVec %v: %v gradients, %v products",
+				hessVec, result.GradEvaluations, result.HessEvaluations)
+		}
+		if !floats.Equal(result.X, []float64{0, 0}) {
+			t.Errorf("stationary point left with HessVec %v, got %v", hessVec, result.X)
+		}
+	}
+}
+
+func TestSR1(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, quasiNewtonTests...)
+	tests = append(tests, bfgsTests...)
+	testLocal(t, tests, &SR1{})
+}
+
+func TestLSR1(t *testing.T) {
+	// The badly scaled starting points of quasiNewtonTests take LSR1 along
+	// narrow curved valleys in which the few stored pairs describe the
+	// curvature poorly, so only the tests of limited-memory methods are run.
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, lbfgsTests...)
+	testLocal(t, tests, &LSR1{})
+}
+
+func TestLSR1SingularRestart(t *testing.T) {
+	// The second pair has y parallel to s and s orthogonal to the first
+	// column of Ψ with the updated scaling, so M is singular with both pairs
+	// and with the second pair alone.
+	l := &LSR1{}
+	_, err := l.Init(&Location{X: []float64{0, 0}, Gradient: []float64{1, 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l.update([]float64{1, 0}, []float64{1, 1})
+	if len(l.s) != 1 {
+		t.Fatalf("first pair not stored")
+	}
+	l.update([]float64{1, 2}, []float64{3, 6})
+	if len(l.s) != 0 || len(l.y) != 0 || len(l.psi) != 0 || len(l.minv) != 0 {
+		t.Errorf("memory not cleared: %v pairs, %v columns of Ψ", len(l.s), len(l.psi))
+	}
+	v := []float64{1, -2}
+	got := make([]float64, 2)
+	l.mulVec(got, v)
+	if want := []float64{3, -6}; !floats.Equal(got, want) {
+		t.Errorf("unexpected product with the cleared memory: got %v, want %v", got, want)
+	}
+}
+
 func TestBFGS(t *testing.T) {
 	var tests []unconstrainedTest
 	tests = append(tests, gradientDescentTests...)
@@ -1150,11 +1395,63 @@ func TestLBFGS(t *testing.T) {
 	testLocal(t, tests, &LBFGS{})
 }
 
+func TestBFGSIdentityScaling(t *testing.T) {
+	testLocal(t, gradientDescentTests, &BFGS{Scaling: IdentityScaling})
+}
+
+func TestBFGSDiagonalScaling(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, quasiNewtonTests...)
+	tests = append(tests, bfgsTests...)
+	testLocal(t, tests, &BFGS{Scaling: DiagonalScaling})
+}
+
+func TestLBFGSIdentityScaling(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, quasiNewtonTests...)
+	testLocal(t, tests, &LBFGS{Scaling: IdentityScaling})
+}
+
+func TestLBFGSDiagonalScaling(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, cgTests...)
+	tests = append(tests, lbfgsTests...)
+	testLocal(t, tests, &LBFGS{Scaling: DiagonalScaling})
+}
+
+func TestDampedBFGSBacktracking(t *testing.T) {
+	testLocal(t, gradientDescentTests, &BFGS{
+		Linesearcher: &Backtracking{},
+		Damped:       true,
+	})
+}
+
+func TestDampedLBFGSBacktracking(t *testing.T) {
+	var tests []unconstrainedTest
+	tests = append(tests, gradientDescentTests...)
+	tests = append(tests, cgTests...)
+	tests = append(tests, bfgsTests...)
+	testLocal(t, tests, &LBFGS{
+		Linesearcher: &Backtracking{},
+		Damped:       true,
+	})
+}
+
 func TestNewton(t *testing.T) {
 	testLocal(t, newtonTests, &Newton{})
 }
 
 func testLocal(t *testing.T, tests []unconstrainedTest, method Method) {
+	testLocalConverged(t, tests, method, FunctionConvergence)
+}
+
+// testLocalConverged is like testLocal, but gradient-free tests can also
+// correctly terminate with the converged status of a method that tests the
+// convergence itself.
+func testLocalConverged(t *testing.T, tests []unconstrainedTest, method Method, converged Status) {
 	for _, test := range tests {
 		if test.long && testing.Short() {
 			continue
@@ -1222,9 +1519,9 @@ func testLocal(t *testing.T, tests []unconstrainedTest, method Method) {
 
 		if !method.Needs().Gradient && !method.Needs().Hessian {
 			// Gradient-free tests can correctly terminate only with
-			// FunctionConvergence status.
-			if result.Status != FunctionConvergence {
-				t.Errorf("Status not %v, %v instead", FunctionConvergence, result.Status)
+			// FunctionConvergence or the converged status.
+			if result.Status != FunctionConvergence && result.Status != converged {
+				t.Errorf("Status not %v or %v, %v instead", FunctionConvergence, converged, result.Status)
 			}
 		}
 